@@ -1,23 +1,61 @@
 package Render
 
 import (
+	"Syllybea/UIcomponents"
+	"Syllybea/bibliography"
+	"Syllybea/grading"
+	"Syllybea/i18n"
+	"Syllybea/mid"
+	"Syllybea/status"
+	"Syllybea/utils"
+	"Syllybea/workload"
+	"fmt"
 	"github.com/labstack/echo/v4"
 	"html/template"
 	"io"
+	"path/filepath"
+	"slices"
+	"time"
 )
 
 // TemplateRenderer is a custom renderer for Echo that uses the Go html/template package.
 type TemplateRenderer struct {
-	Templates *template.Template
+	// Templates holds the views once per locale, with the translation
+	// functions bound to that locale.
+	Templates map[string]*template.Template
 }
 
-// Render implements echo's Renderer interface.
+// Render implements echo's Renderer interface. Views are rendered in the
+// locale negotiated for the request.
 func (t *TemplateRenderer) Render(w io.Writer, name string, data interface{}, c echo.Context) error {
-	return t.Templates.ExecuteTemplate(w, name, data)
+	tmpl, ok := t.Templates[mid.GetLocale(c)]
+	if !ok {
+		tmpl = t.Templates[i18n.Default]
+	}
+	return tmpl.ExecuteTemplate(w, name, data)
 }
 
-func NewTemplate() *TemplateRenderer {
-	funcs := template.FuncMap{
+// NewTemplate parses the views in dir once for every locale.
+func NewTemplate(dir string) *TemplateRenderer {
+	renderer := &TemplateRenderer{Templates: map[string]*template.Template{}}
+	for _, l := range i18n.Locales {
+		renderer.Templates[l.Tag] = template.Must(template.New("").Funcs(funcs(l)).ParseGlob(filepath.Join(dir, "*.html")))
+	}
+	return renderer
+}
+
+// Loaded reports an error unless the views were parsed for every locale.
+func (t *TemplateRenderer) Loaded() error {
+	for _, l := range i18n.Locales {
+		if tmpl, ok := t.Templates[l.Tag]; !ok || tmpl.Lookup("base") == nil {
+			return fmt.Errorf("views not loaded for locale %s", l.Tag)
+		}
+	}
+	return nil
+}
+
+func funcs(locale i18n.Locale) template.FuncMap {
+	return template.FuncMap{
 		"add1": func(i int) int { return i + 1 },
 		"contains": func(arr []string, item string) bool {
 			for _, v := range arr {
@@ -27,8 +65,20 @@ func NewTemplate() *TemplateRenderer {
 			}
 			return false
 		},
-	}
-	return &TemplateRenderer{
-		Templates: template.Must(template.New("").Funcs(funcs).ParseGlob("views/*.html")),
+		"academicYear":   utils.AcademicYearLabel,
+		"semester":       func(s string) string { return i18n.T(locale.Tag, utils.SemesterLabel(s)) },
+		"weekdays":       func() []string { return utils.WeekdayLetters },
+		"citationStyles": func() []bibliography.Option { return bibliography.Styles },
+		"gradeTypes":     func() []grading.TypeRule { return grading.Types },
+		"hours":          workload.Hours,
+		"languages":      func() []UIcomponents.Language { return UIcomponents.Languages },
+		"statuses":       func() []status.Status { return status.Listed },
+		"hasStatus":      func(list []status.Status, s status.Status) bool { return slices.Contains(list, s) },
+
+		// Translation to the locale of the request.
+		"t":       func(message string, args ...interface{}) string { return i18n.T(locale.Tag, message, args...) },
+		"locale":  func() i18n.Locale { return locale },
+		"locales": func() []i18n.Locale { return i18n.Locales },
+		"date":    func(t time.Time) string { return i18n.Date(locale.Tag, t) },
 	}
 }
@@ -0,0 +1,20 @@
+package UIcomponents
+
+// AuditChange is one changed field of an audit entry, formatted for display.
+type AuditChange struct {
+	Field  string
+	Before string
+	After  string
+}
+
+// AuditRow is an audit log entry as shown on the manager audit page.
+type AuditRow struct {
+	ID         int
+	Date       string
+	Actor      string
+	Action     string
+	EntityType string
+	EntityID   int
+	IP         string
+	Changes    []AuditChange
+}
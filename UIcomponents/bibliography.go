@@ -0,0 +1,72 @@
+package UIcomponents
+
+import (
+	"Syllybea/bibliography"
+	"Syllybea/i18n"
+)
+
+// Bibliography lists of a draft.
+const (
+	BibliographyRequired    = "required"
+	BibliographyRecommended = "recommended"
+)
+
+// BibliographyItem is an entry of a bibliography list in the form.
+type BibliographyItem struct {
+	bibliography.Entry
+	Index       int
+	Citation    string // The entry formatted in the citation style of the draft
+	DuplicateOf string // The entry this one cites again, e.g. "Required reading 2"
+}
+
+// BibliographyList is a bibliography list of a draft, as rendered by the form.
+type BibliographyList struct {
+	Kind      string // BibliographyRequired or BibliographyRecommended
+	Section   string // Form section and partial template, e.g. "bibliographyRequired"
+	Actions   string // Suffix of the list's form actions, e.g. "BibliographyRequired"
+	Items     []BibliographyItem
+	Note      string
+	Types     []bibliography.Option
+	Languages []bibliography.Option
+}
+
+// BibliographyList returns the required or recommended list of the draft.
+// Duplicates are looked for across both lists, required entries first, and
+// named in the given locale.
+func (d *Draft) BibliographyList(kind, locale string) BibliographyList {
+	all := append(append([]bibliography.Entry{}, d.BibliographyRequired...), d.BibliographyRecommended...)
+	dup := bibliography.Duplicates(all)
+	label := func(i int) string {
+		if i < len(d.BibliographyRequired) {
+			return i18n.T(locale, "קריאת חובה %d", i+1)
+		}
+		return i18n.T(locale, "קריאת רשות %d", i-len(d.BibliographyRequired)+1)
+	}
+
+	list := BibliographyList{
+		Kind:      kind,
+		Section:   "bibliographyRequired",
+		Actions:   "BibliographyRequired",
+		Note:      d.BibliographyNote,
+		Types:     bibliography.Types,
+		Languages: bibliography.Languages,
+	}
+	entries, offset := d.BibliographyRequired, 0
+	if kind == BibliographyRecommended {
+		list.Section, list.Actions = "bibliographyRecommended", "BibliographyRecommended"
+		entries, offset = d.BibliographyRecommended, len(d.BibliographyRequired)
+	}
+	for i, e := range entries {
+		item := BibliographyItem{Entry: e, Index: i, Citation: d.Citation(e)}
+		if j := dup[offset+i]; j >= 0 {
+			item.DuplicateOf = label(j)
+		}
+		list.Items = append(list.Items, item)
+	}
+	return list
+}
+
+// Citation formats a bibliography entry in the citation style of the draft.
+func (d *Draft) Citation(e bibliography.Entry) string {
+	return e.Format(d.CitationStyle)
+}
@@ -0,0 +1,31 @@
+package UIcomponents
+
+import "Syllybea/status"
+
+// OfferingSyllabus is a syllabus listed under an offering on the course page.
+type OfferingSyllabus struct {
+	ID       int
+	Lecturer string
+	Status   status.Status
+	Date     string
+}
+
+// OfferingView is one offering of a course with its lecturers and syllabi.
+// Syllabi that were never assigned to an offering are grouped under ID 0.
+type OfferingView struct {
+	ID        int
+	Term      string
+	Section   string
+	Lecturers []string
+	Syllabi   []OfferingSyllabus
+}
+
+// CourseRow is a course in the course catalog, with its offerings in the selected term.
+type CourseRow struct {
+	ID            int
+	Name          string
+	Department    string
+	TermOfferings int
+	TermSyllabi   int
+	Missing       bool // Offered in the term but no syllabus exists yet
+}
@@ -1,9 +1,28 @@
 package UIcomponents
 
+import (
+	"Syllybea/status"
+	"html/template"
+)
+
 type HeaderData struct {
 	Title string
 	Name  string
+	Role  string // "Instructor" or "Manager"; controls manager-only navigation
+}
+
+// Nav is what the sidebar is rendered from: the path of the page shown, whose
+// item is marked active, and the role of the user.
+type Nav struct {
+	Active string
+	Role   string
 }
+
+// Nav is the sidebar of the page at the given path for the user of the header.
+func (h HeaderData) Nav(active string) Nav {
+	return Nav{Active: active, Role: h.Role}
+}
+
 type PageData struct {
 	Header  HeaderData
 	Content interface{} // now correctly named Content
@@ -16,6 +35,7 @@ type CoursesData struct {
 	InReview     int
 	Approved     int
 	DateSections []DateSection
+	Terms        []TermOption // Choices of the term filter
 }
 
 // DateSection groups cards by a date label.
@@ -25,11 +45,32 @@ type DateSection struct {
 }
 
 type Card struct {
-	Title       string
-	ID          int
-	Date        string
-	Lecturer    string
-	Field       string
-	Status      string
-	StatusLabel string
+	Title      string
+	ID         int
+	Date       string
+	Lecturer   string
+	LecturerID int
+	Field      string
+	Status     status.Status
+}
+
+// DepartmentStats holds the syllabus counts of one department for the manager view.
+type DepartmentStats struct {
+	DepartmentID int
+	Department   string
+	Total        int
+	Drafts       int
+	InReview     int
+	Approved     int
+}
+
+// SearchResult is one ranked hit of the syllabus content search.
+type SearchResult struct {
+	ID       int
+	Title    string
+	Lecturer string
+	Field    string
+	Status   status.Status
+	Score    float64
+	Snippets []template.HTML // Escaped text with <mark>ed matches
 }
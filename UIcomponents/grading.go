@@ -0,0 +1,23 @@
+package UIcomponents
+
+import (
+	"Syllybea/grading"
+	"strconv"
+)
+
+// GradeProblems lists what keeps the grade composition of the draft from
+// being valid, in the language shown.
+func (d *Draft) GradeProblems() []string {
+	return grading.Validate(d.GradeComponents, d.Shown().Code)
+}
+
+// GradeSummary describes the grading policy of the draft in the language
+// shown, one line per component.
+func (d *Draft) GradeSummary() []string {
+	return grading.Summary(d.GradeComponents, d.Shown().Code)
+}
+
+// GradeTotal is the sum of the component weights, as shown under the form.
+func (d *Draft) GradeTotal() string {
+	return strconv.FormatFloat(grading.Total(d.GradeComponents), 'f', -1, 64)
+}
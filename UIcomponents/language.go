@@ -0,0 +1,359 @@
+package UIcomponents
+
+import (
+	"Syllybea/i18n"
+	"encoding/json"
+	"strings"
+)
+
+// Languages a syllabus can be written in. The fields of a Draft hold its
+// Hebrew text; the text in every other language is kept in Draft.Translations.
+const (
+	Hebrew  = "he"
+	English = "en"
+)
+
+// Language is a language a syllabus can be written and shown in.
+type Language struct {
+	Code string
+	Name string // Name of the language in itself
+	Dir  string // Text direction, "rtl" or "ltr"
+}
+
+// Languages lists the languages a syllabus can be written in, Hebrew first.
+var Languages = []Language{
+	{Code: Hebrew, Name: "עברית", Dir: "rtl"},
+	{Code: English, Name: "English", Dir: "ltr"},
+}
+
+// ParseLanguage returns the language with the given code, Hebrew when the code is unknown.
+func ParseLanguage(code string) Language {
+	for _, l := range Languages {
+		if l.Code == strings.ToLower(strings.TrimSpace(code)) {
+			return l
+		}
+	}
+	return Languages[0]
+}
+
+// LessonText is the text of a row of the syllabus table.
+type LessonText struct {
+	MainTopic       string `json:"mainTopic,omitempty"`
+	LessonTopics    string `json:"lessonTopics,omitempty"`
+	Subtopics       string `json:"subtopics,omitempty"`
+	ReadingMaterial string `json:"readingMaterial,omitempty"`
+}
+
+// Translation is the text of a draft in a language other than Hebrew. Its
+// lists are parallel to the lists of the draft: the lessons to SyllabusRows,
+// the learning outcomes to LearningOutcomes, and so on. Everything else in a
+// draft, like dates, hours, grade components and bibliography, is shared by
+// all languages.
+type Translation struct {
+	CourseName           string       `json:"courseName,omitempty"`
+	Prerequisites        string       `json:"prerequisites,omitempty"`
+	CourseRequirements   []string     `json:"courseRequirements,omitempty"`
+	LearningOutcomes     []string     `json:"learningOutcomes,omitempty"`
+	CourseObjectives     []string     `json:"courseObjectives,omitempty"`
+	OtherCourseStructure string       `json:"otherCourseStructure,omitempty"`
+	ActiveLearning1      string       `json:"activeLearning1,omitempty"`
+	ActiveLearning2      string       `json:"activeLearning2,omitempty"`
+	ActiveLearning3      string       `json:"activeLearning3,omitempty"`
+	ActiveLearning4      string       `json:"activeLearning4,omitempty"`
+	Lessons              []LessonText `json:"lessons,omitempty"`
+	AssignmentsStructure []string     `json:"assignmentsStructure,omitempty"`
+}
+
+// Shown is the language of the text currently in the fields of the draft.
+func (d *Draft) Shown() Language {
+	return ParseLanguage(d.shown)
+}
+
+// Dir is the direction of the text currently in the fields of the draft.
+func (d *Draft) Dir() string {
+	return d.Shown().Dir
+}
+
+// Title is the name of the course in the language shown, the catalog name
+// when it has not been translated.
+func (d *Draft) Title() string {
+	if d.CourseName != "" {
+		return d.CourseName
+	}
+	return d.SelectedCourse
+}
+
+// ShowLanguage puts the text of the draft in the given language in its fields,
+// so that the form and the preview work on it as they do on the Hebrew text.
+// Marshal saves it with its Hebrew text in its fields whatever language it is
+// shown in.
+func (d *Draft) ShowLanguage(code string) {
+	lang := ParseLanguage(code).Code
+	if lang == d.Shown().Code {
+		return
+	}
+	if d.Shown().Code != Hebrew {
+		d.swapText(d.Shown().Code)
+	}
+	if lang != Hebrew {
+		d.swapText(lang)
+	}
+	d.shown = lang
+}
+
+// Marshal encodes the draft as it is saved: with its Hebrew text in its fields
+// and the text in other languages in its Translations. The draft is shown in
+// the same language afterwards.
+func (d *Draft) Marshal() ([]byte, error) {
+	shown := d.Shown().Code
+	d.ShowLanguage(Hebrew)
+	defer d.ShowLanguage(shown)
+	return json.Marshal(d)
+}
+
+// HasTranslation reports whether any text of the draft was written in the given language.
+func (d *Draft) HasTranslation(code string) bool {
+	return code == Hebrew || d.completeness(code).Filled > 0
+}
+
+// swapText exchanges the text in the fields of the draft with its text in
+// lang. Lists are padded to the length of the lists they replace, so that
+// they stay parallel to the lists of links and hours that all languages share.
+func (d *Draft) swapText(lang string) {
+	if d.Translations == nil {
+		d.Translations = map[string]*Translation{}
+	}
+	t := d.Translations[lang]
+	if t == nil {
+		t = &Translation{}
+		d.Translations[lang] = t
+	}
+
+	d.CourseName, t.CourseName = t.CourseName, d.CourseName
+	d.Prerequisites, t.Prerequisites = t.Prerequisites, d.Prerequisites
+	d.OtherCourseStructure, t.OtherCourseStructure = t.OtherCourseStructure, d.OtherCourseStructure
+	d.ActiveLearning1, t.ActiveLearning1 = t.ActiveLearning1, d.ActiveLearning1
+	d.ActiveLearning2, t.ActiveLearning2 = t.ActiveLearning2, d.ActiveLearning2
+	d.ActiveLearning3, t.ActiveLearning3 = t.ActiveLearning3, d.ActiveLearning3
+	d.ActiveLearning4, t.ActiveLearning4 = t.ActiveLearning4, d.ActiveLearning4
+	d.CourseRequirements, t.CourseRequirements = pad(t.CourseRequirements, len(d.CourseRequirements)), d.CourseRequirements
+	d.LearningOutcomes, t.LearningOutcomes = pad(t.LearningOutcomes, len(d.LearningOutcomes)), d.LearningOutcomes
+	d.CourseObjectives, t.CourseObjectives = pad(t.CourseObjectives, len(d.CourseObjectives)), d.CourseObjectives
+	d.AssignmentsStructure, t.AssignmentsStructure = pad(t.AssignmentsStructure, len(d.AssignmentsStructure)), d.AssignmentsStructure
+
+	lessons := make([]LessonText, len(d.SyllabusRows))
+	for i := range d.SyllabusRows {
+		row := &d.SyllabusRows[i]
+		lessons[i] = LessonText{row.MainTopic, row.LessonTopics, row.Subtopics, row.ReadingMaterial}
+		var text LessonText
+		if i < len(t.Lessons) {
+			text = t.Lessons[i]
+		}
+		row.MainTopic, row.LessonTopics, row.Subtopics, row.ReadingMaterial =
+			text.MainTopic, text.LessonTopics, text.Subtopics, text.ReadingMaterial
+	}
+	t.Lessons = lessons
+}
+
+// pad extends a list with empty entries to at least n entries.
+func pad(list []string, n int) []string {
+	for len(list) < n {
+		list = append(list, "")
+	}
+	return list
+}
+
+// Lists of a draft whose entries are translated, named like the form sections
+// that edit them.
+const (
+	ListCourseRequirements   = "courseRequirements"
+	ListLearningOutcomes     = "learningOutcomes"
+	ListCourseObjectives     = "courseObjectives"
+	ListAssignmentsStructure = "assignmentsStructure"
+	ListSyllabusRows         = "syllabusRows"
+)
+
+// RemoveTranslated removes the entry at index of a list from the text of the
+// draft in the languages not shown, as it was removed from the list shown.
+func (d *Draft) RemoveTranslated(list string, index int) {
+	for _, t := range d.Translations {
+		if list == ListSyllabusRows {
+			if index < len(t.Lessons) {
+				t.Lessons = append(t.Lessons[:index], t.Lessons[index+1:]...)
+			}
+			continue
+		}
+		if l := t.list(list); l != nil && index < len(*l) {
+			*l = append((*l)[:index], (*l)[index+1:]...)
+		}
+	}
+}
+
+// InsertTranslated inserts an empty entry at index of a list in the text of
+// the draft in the languages not shown, as it was inserted in the list shown.
+func (d *Draft) InsertTranslated(list string, index int) {
+	for _, t := range d.Translations {
+		if list == ListSyllabusRows {
+			if index < len(t.Lessons) {
+				t.Lessons = append(t.Lessons[:index], append([]LessonText{{}}, t.Lessons[index:]...)...)
+			}
+			continue
+		}
+		if l := t.list(list); l != nil && index < len(*l) {
+			*l = append((*l)[:index], append([]string{""}, (*l)[index:]...)...)
+		}
+	}
+}
+
+func (t *Translation) list(name string) *[]string {
+	switch name {
+	case ListCourseRequirements:
+		return &t.CourseRequirements
+	case ListLearningOutcomes:
+		return &t.LearningOutcomes
+	case ListCourseObjectives:
+		return &t.CourseObjectives
+	case ListAssignmentsStructure:
+		return &t.AssignmentsStructure
+	}
+	return nil
+}
+
+// Completeness is how much of the text of a draft is written in a language.
+type Completeness struct {
+	Language Language
+	Filled   int
+	Total    int
+}
+
+// Percent is the written share of the text, 0 to 100.
+func (c Completeness) Percent() int {
+	if c.Total == 0 {
+		return 100
+	}
+	return c.Filled * 100 / c.Total
+}
+
+// Completeness tells, for every language, how much of the text of the draft
+// is written in it. A field counts when it is required or written in any
+// language, so a lesson written only in Hebrew is missing in English.
+func (d *Draft) Completeness() []Completeness {
+	result := make([]Completeness, len(Languages))
+	for i, l := range Languages {
+		result[i] = d.completeness(l.Code)
+	}
+	return result
+}
+
+func (d *Draft) completeness(code string) Completeness {
+	texts := map[string]Translation{}
+	for _, l := range Languages {
+		texts[l.Code] = d.text(l.Code)
+	}
+	own := texts[code]
+	c := Completeness{Language: ParseLanguage(code)}
+
+	count := func(required bool, field func(t Translation) string) {
+		written := required
+		for _, t := range texts {
+			if strings.TrimSpace(field(t)) != "" {
+				written = true
+			}
+		}
+		if !written {
+			return
+		}
+		c.Total++
+		if strings.TrimSpace(field(own)) != "" {
+			c.Filled++
+		}
+	}
+	countList := func(list func(t Translation) []string) {
+		n := 1 // A list needs at least one entry
+		for _, t := range texts {
+			n = max(n, len(list(t)))
+		}
+		for i := 0; i < n; i++ {
+			count(i == 0, func(t Translation) string {
+				if l := list(t); i < len(l) {
+					return l[i]
+				}
+				return ""
+			})
+		}
+	}
+
+	count(true, func(t Translation) string { return t.CourseName })
+	count(false, func(t Translation) string { return t.Prerequisites })
+	count(false, func(t Translation) string { return t.OtherCourseStructure })
+	count(true, func(t Translation) string { return t.ActiveLearning1 })
+	count(true, func(t Translation) string { return t.ActiveLearning2 })
+	count(true, func(t Translation) string { return t.ActiveLearning3 })
+	count(true, func(t Translation) string { return t.ActiveLearning4 })
+	countList(func(t Translation) []string { return t.CourseRequirements })
+	countList(func(t Translation) []string { return t.LearningOutcomes })
+	countList(func(t Translation) []string { return t.CourseObjectives })
+	countList(func(t Translation) []string { return t.AssignmentsStructure })
+	for i := range d.SyllabusRows {
+		lesson := func(t Translation) LessonText {
+			if i < len(t.Lessons) {
+				return t.Lessons[i]
+			}
+			return LessonText{}
+		}
+		count(false, func(t Translation) string { return lesson(t).MainTopic })
+		count(false, func(t Translation) string { return lesson(t).LessonTopics })
+		count(false, func(t Translation) string { return lesson(t).Subtopics })
+		count(false, func(t Translation) string { return lesson(t).ReadingMaterial })
+	}
+	return c
+}
+
+// text returns the text of the draft in a language, wherever it is kept at
+// the moment. The Hebrew course name is the catalog name.
+func (d *Draft) text(code string) Translation {
+	if code == d.Shown().Code {
+		t := Translation{
+			CourseName:           d.CourseName,
+			Prerequisites:        d.Prerequisites,
+			CourseRequirements:   d.CourseRequirements,
+			LearningOutcomes:     d.LearningOutcomes,
+			CourseObjectives:     d.CourseObjectives,
+			OtherCourseStructure: d.OtherCourseStructure,
+			ActiveLearning1:      d.ActiveLearning1,
+			ActiveLearning2:      d.ActiveLearning2,
+			ActiveLearning3:      d.ActiveLearning3,
+			ActiveLearning4:      d.ActiveLearning4,
+			AssignmentsStructure: d.AssignmentsStructure,
+		}
+		for _, row := range d.SyllabusRows {
+			t.Lessons = append(t.Lessons, LessonText{row.MainTopic, row.LessonTopics, row.Subtopics, row.ReadingMaterial})
+		}
+		if code == Hebrew && t.CourseName == "" {
+			t.CourseName = d.SelectedCourse
+		}
+		return t
+	}
+
+	// The text not shown is kept in the translations; while another language
+	// is shown, its translation holds the Hebrew.
+	key := code
+	if code == Hebrew {
+		key = d.Shown().Code
+	}
+	var t Translation
+	if kept := d.Translations[key]; kept != nil {
+		t = *kept
+	}
+	if code == Hebrew && t.CourseName == "" {
+		t.CourseName = d.SelectedCourse
+	}
+	return t
+}
+
+// Label translates a fixed label of the syllabus, like a section title, or a
+// note about it to the language shown, through the message catalog of the
+// user interface. With arguments, the label is a format for them.
+func (d *Draft) Label(hebrew string, args ...interface{}) string {
+	return i18n.T(d.Shown().Code, hebrew, args...)
+}
@@ -0,0 +1,208 @@
+package UIcomponents
+
+import (
+	"Syllybea/grading"
+	"Syllybea/i18n"
+	"strings"
+)
+
+// OutcomeLinks maps a learning outcome of a syllabus to the program outcomes it
+// contributes to and to the grade components and lessons that assess it. The
+// links follow renamed components and renumbered lessons; see RelinkAssessments
+// and RelinkLessons.
+type OutcomeLinks struct {
+	ProgramOutcomes []int    `json:"programOutcomes,omitempty"` // IDs of department program outcomes
+	Assessments     []string `json:"assessments,omitempty"`     // Names of grade components
+	Lessons         []string `json:"lessons,omitempty"`         // Lesson numbers
+}
+
+// HasProgramOutcome reports whether the outcome contributes to a program outcome.
+func (l OutcomeLinks) HasProgramOutcome(id int) bool {
+	for _, v := range l.ProgramOutcomes {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+// HasAssessment reports whether the outcome is assessed by a grade component.
+func (l OutcomeLinks) HasAssessment(name string) bool {
+	return containsTrimmed(l.Assessments, name)
+}
+
+// HasLesson reports whether the outcome is assessed in a lesson.
+func (l OutcomeLinks) HasLesson(number string) bool {
+	return containsTrimmed(l.Lessons, number)
+}
+
+// ProgramOutcomeOption is a program outcome of the draft's department.
+type ProgramOutcomeOption struct {
+	ID          int
+	Code        string
+	Description string
+}
+
+// OutcomeMatrixRow is a learning outcome of the draft with its links.
+type OutcomeMatrixRow struct {
+	Index      int
+	Outcome    string
+	Links      OutcomeLinks
+	Assessed   bool
+	Program    []string // Codes of the program outcomes it contributes to
+	AssessedBy []string // Grade components and lessons that assess it
+}
+
+// OutcomeMatrix is the learning outcome matrix of a draft, as rendered by the
+// form and the preview.
+type OutcomeMatrix struct {
+	Rows            []OutcomeMatrixRow
+	ProgramOutcomes []ProgramOutcomeOption
+	Assessments     []string // Grade component names
+	Lessons         []string // Lesson numbers
+	Note            string
+}
+
+// HasLinks reports whether any learning outcome of the matrix is mapped.
+func (m OutcomeMatrix) HasLinks() bool {
+	for _, row := range m.Rows {
+		if len(row.Program) > 0 || len(row.AssessedBy) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// OutcomeLinksAt returns the links of the learning outcome at index i.
+func (d *Draft) OutcomeLinksAt(i int) OutcomeLinks {
+	if i < len(d.OutcomeLinks) {
+		return d.OutcomeLinks[i]
+	}
+	return OutcomeLinks{}
+}
+
+// RemoveOutcomeLinks drops the links of the learning outcome at index i, so the
+// links of the outcomes after it stay with them.
+func (d *Draft) RemoveOutcomeLinks(i int) {
+	if i >= 0 && i < len(d.OutcomeLinks) {
+		d.OutcomeLinks = append(d.OutcomeLinks[:i], d.OutcomeLinks[i+1:]...)
+	}
+}
+
+// RelinkAssessments keeps the links to grade components that were renamed: a
+// link to the name of the component at a position in before becomes a link to
+// the name of the component at that position in after.
+func (d *Draft) RelinkAssessments(before, after []grading.Component) {
+	renames := map[string]string{}
+	for i := 0; i < len(before) && i < len(after); i++ {
+		addRename(renames, before[i].PartName, after[i].PartName)
+	}
+	for i := range d.OutcomeLinks {
+		d.OutcomeLinks[i].Assessments = renamed(d.OutcomeLinks[i].Assessments, renames)
+	}
+}
+
+// RelinkLessons keeps the links to lessons that were renumbered: a link to the
+// number of the row at a position in before becomes a link to the number of
+// the row at that position in after.
+func (d *Draft) RelinkLessons(before, after []SyllabusRow) {
+	renames := map[string]string{}
+	for i := 0; i < len(before) && i < len(after); i++ {
+		addRename(renames, before[i].LessonNumber, after[i].LessonNumber)
+	}
+	for i := range d.OutcomeLinks {
+		d.OutcomeLinks[i].Lessons = renamed(d.OutcomeLinks[i].Lessons, renames)
+	}
+}
+
+// addRename records that old is now called name. Blank names are not
+// recorded, and a name that was at several positions keeps its first rename.
+func addRename(renames map[string]string, old, name string) {
+	old, name = strings.TrimSpace(old), strings.TrimSpace(name)
+	if old == "" || name == "" || old == name {
+		return
+	}
+	if _, ok := renames[old]; !ok {
+		renames[old] = name
+	}
+}
+
+// renamed returns the list with its renamed values replaced, once each.
+func renamed(list []string, renames map[string]string) []string {
+	if len(renames) == 0 {
+		return list
+	}
+	var result []string
+	for _, v := range list {
+		if name, ok := renames[strings.TrimSpace(v)]; ok {
+			v = name
+		}
+		if !containsTrimmed(result, v) {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// OutcomeMatrix returns the matrix of the draft's non-empty learning outcomes.
+// Links to grade components and lessons no longer in the draft are ignored.
+func (d *Draft) OutcomeMatrix() OutcomeMatrix {
+	m := OutcomeMatrix{ProgramOutcomes: d.ProgramOutcomes, Note: d.OutcomeNote}
+	for _, comp := range d.GradeComponents {
+		if name := strings.TrimSpace(comp.PartName); name != "" && !containsTrimmed(m.Assessments, name) {
+			m.Assessments = append(m.Assessments, name)
+		}
+	}
+	for _, row := range d.SyllabusRows {
+		if n := strings.TrimSpace(row.LessonNumber); n != "" && !containsTrimmed(m.Lessons, n) {
+			m.Lessons = append(m.Lessons, n)
+		}
+	}
+
+	for i, outcome := range d.LearningOutcomes {
+		if strings.TrimSpace(outcome) == "" {
+			continue
+		}
+		row := OutcomeMatrixRow{Index: i, Outcome: outcome, Links: d.OutcomeLinksAt(i)}
+		for _, name := range m.Assessments {
+			if row.Links.HasAssessment(name) {
+				row.AssessedBy = append(row.AssessedBy, name)
+			}
+		}
+		for _, n := range m.Lessons {
+			if row.Links.HasLesson(n) {
+				row.AssessedBy = append(row.AssessedBy, i18n.T(d.Shown().Code, "שיעור %s", n))
+			}
+		}
+		row.Assessed = len(row.AssessedBy) > 0
+		for _, po := range m.ProgramOutcomes {
+			if row.Links.HasProgramOutcome(po.ID) {
+				row.Program = append(row.Program, po.Code)
+			}
+		}
+		m.Rows = append(m.Rows, row)
+	}
+	return m
+}
+
+// UnassessedOutcomes lists the learning outcomes no grade component or lesson assesses.
+func (d *Draft) UnassessedOutcomes() []string {
+	var outcomes []string
+	for _, row := range d.OutcomeMatrix().Rows {
+		if !row.Assessed {
+			outcomes = append(outcomes, row.Outcome)
+		}
+	}
+	return outcomes
+}
+
+// containsTrimmed reports whether list holds s, ignoring surrounding spaces.
+func containsTrimmed(list []string, s string) bool {
+	s = strings.TrimSpace(s)
+	for _, v := range list {
+		if strings.TrimSpace(v) == s {
+			return true
+		}
+	}
+	return false
+}
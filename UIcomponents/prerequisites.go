@@ -0,0 +1,27 @@
+package UIcomponents
+
+// PrerequisiteCourse is a course the syllabus lists as a prerequisite. The
+// name is kept with the reference so the syllabus still reads well when the
+// course is renamed or removed from the catalog.
+type PrerequisiteCourse struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	Missing  bool   `json:"-"` // The course is no longer in the catalog
+	Approved bool   `json:"-"` // The course has an approved syllabus
+}
+
+// CourseOption is a course that can be chosen as a prerequisite.
+type CourseOption struct {
+	ID         int
+	Name       string
+	Department string
+}
+
+// PrerequisiteNames lists the names of the prerequisite courses.
+func (d *Draft) PrerequisiteNames() []string {
+	names := make([]string, 0, len(d.PrerequisiteCourses))
+	for _, p := range d.PrerequisiteCourses {
+		names = append(names, p.Name)
+	}
+	return names
+}
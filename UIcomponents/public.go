@@ -0,0 +1,35 @@
+package UIcomponents
+
+import "Syllybea/workload"
+
+// PublicSyllabus is a published syllabus listed in the public catalog.
+type PublicSyllabus struct {
+	Slug      string
+	Course    string
+	Term      string
+	Section   string
+	Lecturer  string
+	Published string
+}
+
+// PublicDepartment is a department in the public catalog with its published syllabi.
+type PublicDepartment struct {
+	Name    string
+	Syllabi []PublicSyllabus
+}
+
+// PublicCatalogData is the data rendered by the "public-page" template.
+type PublicCatalogData struct {
+	Departments []PublicDepartment
+	Terms       []TermOption
+	TermID      int // Selected term, 0 for all terms
+}
+
+// PublishedDraft is the copy of a draft kept when it is published: the draft
+// with the program outcomes and workload norm of its department as they were
+// then, so the public page does not change when the department's do.
+type PublishedDraft struct {
+	Draft
+	DepartmentOutcomes []ProgramOutcomeOption `json:"departmentOutcomes,omitempty"`
+	DepartmentNorm     *workload.Norm         `json:"departmentNorm,omitempty"` // nil in copies published before the norm was kept
+}
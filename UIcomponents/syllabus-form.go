@@ -1,5 +1,12 @@
 package UIcomponents
 
+import (
+	"Syllybea/bibliography"
+	"Syllybea/grading"
+	"Syllybea/workload"
+	"time"
+)
+
 // SyllabusRow represents one row of the syllabus table.
 type SyllabusRow struct {
 	LessonNumber    string
@@ -7,12 +14,24 @@ type SyllabusRow struct {
 	LessonTopics    string
 	Subtopics       string
 	ReadingMaterial string
+	Date            string  `json:",omitempty"` // YYYY-MM-DD, set when the schedule is generated from the term
+	Holiday         string  `json:",omitempty"` // Name of the holiday the lesson falls on
+	StudyHours      float64 `json:",omitempty"` // Estimated self-study hours for the lesson
+}
+
+// DisplayDate formats the lesson date as DD/MM/YYYY, or "" when the row has no date.
+func (r SyllabusRow) DisplayDate() string {
+	d, err := time.Parse("2006-01-02", r.Date)
+	if err != nil {
+		return ""
+	}
+	return d.Format("02/01/2006")
 }
 
-// GradeComponent represents one row of the grade composition.
-type GradeComponent struct {
-	PartName   string
-	Percentage string
+// TermOption is one entry of a term dropdown.
+type TermOption struct {
+	ID    int
+	Label string
 }
 
 type Comments struct {
@@ -23,33 +42,88 @@ type Comments struct {
 }
 
 type Draft struct {
-	ID                      int              `json:"ID"`
-	LecturerName            string           `json:"lecturerName"`
-	LecturerEmail           string           `json:"lecturerEmail"`
-	OfficeDay               string           `json:"officeDay"`
-	OfficeStart             string           `json:"officeStart"`
-	OfficeEnd               string           `json:"officeEnd"`
-	SyllabusDepartment      string           `json:"syllabusDepartment"` // Selected department
-	Departments             []string         `json:"departments"`
-	SelectedCourse          string           `json:"selectedCourse"` // Selected course
-	Courses                 []string         `json:"courses"`        // List of available courses
-	CourseRequirements      []string         `json:"courseRequirements"`
-	LearningOutcomes        []string         `json:"learningOutcomes"`
-	CourseObjectives        []string         `json:"courseObjectives"`
-	Credits                 string           `json:"credits"`
-	WeeklyHours             string           `json:"weeklyHours"`
-	Year                    string           `json:"year"`
-	Semester                string           `json:"semester"`
-	Prerequisites           string           `json:"prerequisites"`
-	CourseStructure         []string         `json:"courseStructure"`
-	OtherCourseStructure    string           `json:"otherCourseStructure"`
-	ActiveLearning1         string           `json:"activeLearning1"`
-	ActiveLearning2         string           `json:"activeLearning2"`
-	ActiveLearning3         string           `json:"activeLearning3"`
-	ActiveLearning4         string           `json:"activeLearning4"`
-	SyllabusRows            []SyllabusRow    `json:"syllabusRows"`
-	GradeComponents         []GradeComponent `json:"gradeComponents"`
-	AssignmentsStructure    []string         `json:"assignmentsStructure"`
-	BibliographyRequired    []string         `json:"bibliographyRequired"`
-	BibliographyRecommended []string         `json:"bibliographyRecommended"`
+	ID                      int                     `json:"ID"`
+	LecturerName            string                  `json:"lecturerName"`
+	LecturerEmail           string                  `json:"lecturerEmail"`
+	OfficeDay               string                  `json:"officeDay"`
+	OfficeStart             string                  `json:"officeStart"`
+	OfficeEnd               string                  `json:"officeEnd"`
+	SyllabusDepartment      string                  `json:"syllabusDepartment"` // Selected department
+	Departments             []string                `json:"departments"`
+	SelectedCourse          string                  `json:"selectedCourse"`       // Selected course
+	CourseName              string                  `json:"courseName,omitempty"` // Name of the course in the language shown, when it differs from the catalog name
+	Courses                 []string                `json:"courses"`              // List of available courses
+	CourseRequirements      []string                `json:"courseRequirements"`
+	LearningOutcomes        []string                `json:"learningOutcomes"`
+	OutcomeLinks            []OutcomeLinks          `json:"outcomeLinks,omitempty"` // Parallel to LearningOutcomes
+	ProgramOutcomes         []ProgramOutcomeOption  `json:"-"`                      // Program outcomes of the department, filled per request
+	OutcomeNote             string                  `json:"-"`                      // Why the syllabus cannot be submitted yet
+	CourseObjectives        []string                `json:"courseObjectives"`
+	Credits                 string                  `json:"credits"`
+	WeeklyHours             string                  `json:"weeklyHours"`
+	Year                    string                  `json:"year"`
+	Semester                string                  `json:"semester"`
+	TermID                  int                     `json:"-"`                             // Academic term the syllabus is for, kept in its offering
+	UnsavedTermID           int                     `json:"unsavedTermID,omitempty"`       // Term chosen before the offering of the syllabus exists
+	Terms                   []TermOption            `json:"-"`                             // Term dropdown choices, filled per request
+	MeetingDays             []string                `json:"meetingDays,omitempty"`         // Weekly meeting days ("א".."ו"), used to generate the lesson schedule
+	ScheduleNote            string                  `json:"-"`                             // Why the schedule could not be generated
+	Section                 string                  `json:"section,omitempty"`             // Group of the course offering, when there are several
+	Prerequisites           string                  `json:"prerequisites"`                 // Prerequisites other than catalog courses, free text
+	PrerequisiteCourses     []PrerequisiteCourse    `json:"prerequisiteCourses,omitempty"` // Catalog courses required before this one
+	PrerequisitesFor        int                     `json:"prerequisitesFor,omitempty"`    // Catalog course whose prerequisites PrerequisiteCourses started from
+	PrerequisiteOptions     []CourseOption          `json:"-"`                             // Courses that can be added as prerequisites, filled per request
+	PrerequisiteNotes       []string                `json:"-"`                             // Problems with the prerequisites, filled per request
+	CourseStructure         []string                `json:"courseStructure"`
+	OtherCourseStructure    string                  `json:"otherCourseStructure"`
+	ActiveLearning1         string                  `json:"activeLearning1"`
+	ActiveLearning2         string                  `json:"activeLearning2"`
+	ActiveLearning3         string                  `json:"activeLearning3"`
+	ActiveLearning4         string                  `json:"activeLearning4"`
+	SyllabusRows            []SyllabusRow           `json:"syllabusRows"`
+	GradeComponents         []grading.Component     `json:"gradeComponents"`
+	GradeNote               string                  `json:"-"` // Why the syllabus cannot be submitted with its grade composition
+	AssignmentsStructure    []string                `json:"assignmentsStructure"`
+	AssignmentHours         []float64               `json:"assignmentHours,omitempty"` // Estimated hours per assignment, parallel to AssignmentsStructure
+	WorkloadNorm            workload.Norm           `json:"-"`                         // Credit-hour norm of the department, filled per request
+	BibliographyRequired    []bibliography.Entry    `json:"bibliographyRequired"`
+	BibliographyRecommended []bibliography.Entry    `json:"bibliographyRecommended"`
+	CitationStyle           string                  `json:"citationStyle,omitempty"`  // Style the bibliography is formatted in, APA by default
+	BibliographyNote        string                  `json:"-"`                        // Why pasted BibTeX or RIS could not be imported
+	TemplateID              int                     `json:"templateID,omitempty"`     // Department template the draft started from
+	LockedSections          []string                `json:"lockedSections,omitempty"` // Sections fixed by that template
+	PublishedAt             string                  `json:"-"`                        // Publication date on the public page, empty in the staff preview
+	Language                string                  `json:"language,omitempty"`       // Language the form edits, Hebrew when empty
+	Translations            map[string]*Translation `json:"translations,omitempty"`   // Text of the syllabus in other languages, by language code
+	shown                   string                  // Language of the text in the fields, Hebrew when empty
+}
+
+// IsLocked reports whether a form section was locked by the department template.
+func (d *Draft) IsLocked(section string) bool {
+	for _, s := range d.LockedSections {
+		if s == section {
+			return true
+		}
+	}
+	return false
+}
+
+// KeepTerm records where the term of the draft is kept: in its offering once
+// the offering exists, with the draft itself until then.
+func (d *Draft) KeepTerm(offeringID int) {
+	if offeringID != 0 {
+		d.UnsavedTermID = 0
+		return
+	}
+	d.UnsavedTermID = d.TermID
+}
+
+// HasLessonDates reports whether the lesson schedule was generated with dates.
+func (d *Draft) HasLessonDates() bool {
+	for _, row := range d.SyllabusRows {
+		if row.Date != "" {
+			return true
+		}
+	}
+	return false
 }
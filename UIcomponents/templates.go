@@ -0,0 +1,39 @@
+package UIcomponents
+
+// TemplateSection is one lockable section of a syllabus template.
+type TemplateSection struct {
+	Key    string // Matches the form's updateField / partial template name
+	Label  string
+	Text   string // Section content, one item per line
+	Locked bool
+}
+
+// TemplateSectionKeys lists the sections a department template can pre-fill, in form order.
+var TemplateSectionKeys = []string{
+	"courseRequirements",
+	"learningOutcomes",
+	"courseObjectives",
+	"gradeComponents",
+	"assignmentsStructure",
+	"bibliographyRequired",
+	"bibliographyRecommended",
+}
+
+// TemplateSectionLabels holds the Hebrew title of each template section.
+var TemplateSectionLabels = map[string]string{
+	"courseRequirements":      "דרישות הקורס",
+	"learningOutcomes":        "תוצרי למידה",
+	"courseObjectives":        "מטרות הקורס",
+	"gradeComponents":         "הרכב הציון",
+	"assignmentsStructure":    "מבנה המטלות",
+	"bibliographyRequired":    "קריאת חובה",
+	"bibliographyRecommended": "קריאת רשות",
+}
+
+// TemplateForm holds the data of the template editor.
+type TemplateForm struct {
+	ID           int
+	Name         string
+	DepartmentID int
+	Sections     []TemplateSection
+}
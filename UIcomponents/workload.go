@@ -0,0 +1,53 @@
+package UIcomponents
+
+import (
+	"Syllybea/workload"
+	"strconv"
+	"strings"
+)
+
+// IsEmpty reports whether the row is a blank line of the syllabus table.
+func (r SyllabusRow) IsEmpty() bool {
+	return strings.TrimSpace(r.LessonNumber+r.MainTopic+r.LessonTopics+r.Subtopics+r.ReadingMaterial) == "" &&
+		r.Date == "" && r.StudyHours == 0
+}
+
+// StudyHoursText is the estimated self-study hours as shown in the form, "" when not estimated.
+func (r SyllabusRow) StudyHoursText() string {
+	if r.StudyHours == 0 {
+		return ""
+	}
+	return workload.Hours(r.StudyHours)
+}
+
+// AssignmentHoursText is the estimated hours of the assignment at index i, "" when not estimated.
+func (d *Draft) AssignmentHoursText(i int) string {
+	if i >= len(d.AssignmentHours) || d.AssignmentHours[i] == 0 {
+		return ""
+	}
+	return workload.Hours(d.AssignmentHours[i])
+}
+
+// Workload estimates the student workload of the draft against the norm of its
+// department, with warnings in the language the draft is shown in. Blank table
+// rows and assignments are left out.
+func (d *Draft) Workload() workload.Estimate {
+	course := workload.Course{MeetingsPerWeek: len(d.MeetingDays)}
+	course.Credits, _ = strconv.ParseFloat(strings.TrimSpace(d.Credits), 64)
+	course.WeeklyHours, _ = strconv.ParseFloat(strings.TrimSpace(d.WeeklyHours), 64)
+	for _, row := range d.SyllabusRows {
+		if !row.IsEmpty() {
+			course.StudyHours = append(course.StudyHours, row.StudyHours)
+		}
+	}
+	for i, name := range d.AssignmentsStructure {
+		hours := 0.0
+		if i < len(d.AssignmentHours) {
+			hours = d.AssignmentHours[i]
+		}
+		if strings.TrimSpace(name) != "" || hours != 0 {
+			course.AssignmentHours = append(course.AssignmentHours, hours)
+		}
+	}
+	return workload.Calculate(course, d.WorkloadNorm, d.Shown().Code)
+}
@@ -0,0 +1,375 @@
+package bibliography
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// ParseBibTeX reads the entries of a BibTeX database. @string, @preamble and
+// @comment blocks are skipped; string macros are not expanded. Text between
+// entries is a comment, so an @ in it that does not start an entry, as in an
+// email address, is skipped too.
+func ParseBibTeX(text string) ([]Entry, error) {
+	p := &bibParser{s: []rune(text)}
+	var entries []Entry
+	for {
+		at := p.indexOf('@')
+		if at < 0 {
+			break
+		}
+		p.pos = at + 1
+		kind := strings.ToLower(p.ident())
+		p.skipSpace()
+		if kind == "" || p.eof() || (p.peek() != '{' && p.peek() != '(') {
+			continue
+		}
+		closer := '}'
+		if p.peek() == '(' {
+			closer = ')'
+		}
+		p.pos++
+
+		switch kind {
+		case "comment", "string", "preamble":
+			if err := p.skipBlock(closer); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		// The citation key is not kept.
+		for !p.eof() && p.peek() != ',' && p.peek() != closer {
+			p.pos++
+		}
+		fields, err := p.fields(closer)
+		if err != nil {
+			return nil, fmt.Errorf("bibtex @%s: %w", kind, err)
+		}
+		entries = append(entries, bibEntry(kind, fields))
+	}
+	return entries, nil
+}
+
+// bibEntry builds an entry from the fields of a BibTeX entry of the given type.
+func bibEntry(kind string, f map[string]string) Entry {
+	e := Entry{
+		Type:      TypeBook,
+		Title:     f["title"],
+		Year:      firstYear(f["year"] + " " + f["date"]),
+		Publisher: f["publisher"],
+		ISBN:      f["isbn"],
+		DOI:       NormalizeDOI(f["doi"]),
+		URL:       f["url"],
+		Language:  languageCode(f["language"] + f["langid"]),
+	}
+	for _, a := range strings.Split(f["author"], " and ") {
+		if a = strings.TrimSpace(a); a != "" {
+			e.Authors = append(e.Authors, a)
+		}
+	}
+	if len(e.Authors) == 0 {
+		for _, a := range strings.Split(f["editor"], " and ") {
+			if a = strings.TrimSpace(a); a != "" {
+				e.Authors = append(e.Authors, a)
+			}
+		}
+	}
+
+	switch kind {
+	case "article":
+		e.Type = TypeArticle
+		e.Publisher = firstOf(f["journal"], f["journaltitle"], e.Publisher)
+	case "inproceedings", "conference":
+		e.Type = TypeArticle
+		e.Publisher = firstOf(f["booktitle"], e.Publisher)
+	case "online", "electronic", "www":
+		e.Type = TypeWeb
+	case "misc":
+		if e.URL != "" && e.Publisher == "" {
+			e.Type = TypeWeb
+		}
+		e.Publisher = firstOf(e.Publisher, f["howpublished"])
+	case "phdthesis", "mastersthesis", "thesis":
+		e.Publisher = firstOf(f["school"], f["institution"], e.Publisher)
+	case "techreport", "report":
+		e.Publisher = firstOf(f["institution"], e.Publisher)
+	}
+	return e
+}
+
+// WriteBibTeX writes entries as a BibTeX database. Citation keys are made from
+// the first author and year; free-text entries become @misc notes.
+func WriteBibTeX(w io.Writer, entries []Entry) error {
+	bw := bufio.NewWriter(w)
+	used := map[string]bool{}
+	for _, e := range entries {
+		if e.IsEmpty() {
+			continue
+		}
+		kind := "book"
+		switch e.Type {
+		case TypeArticle:
+			kind = "article"
+		case TypeWeb:
+			kind = "online"
+		case "":
+			kind = "misc"
+		}
+
+		base := citationKey(e)
+		key := base
+		for suffix := 'b'; used[key]; suffix++ {
+			key = base + string(suffix)
+		}
+		used[key] = true
+
+		fmt.Fprintf(bw, "@%s{%s,\n", kind, key)
+		field := func(name, value string) {
+			if value != "" {
+				fmt.Fprintf(bw, "  %s = {%s},\n", name, bibEscape(value))
+			}
+		}
+		if e.IsFreeText() {
+			field("note", e.Title)
+		} else {
+			field("author", strings.Join(e.Authors, " and "))
+			field("title", e.Title)
+			field("year", e.Year)
+			if e.Type == TypeArticle {
+				field("journal", e.Publisher)
+			} else {
+				field("publisher", e.Publisher)
+			}
+			field("isbn", e.ISBN)
+			field("doi", e.DOI)
+			field("url", e.URL)
+			field("langid", languageName(e.Language))
+		}
+		bw.WriteString("}\n\n")
+	}
+	return bw.Flush()
+}
+
+// citationKey builds a key such as "cohen2020" from the first author and year.
+func citationKey(e Entry) string {
+	var b strings.Builder
+	if len(e.Authors) > 0 {
+		family, _ := splitName(e.Authors[0])
+		for _, r := range strings.ToLower(family) {
+			if r < unicode.MaxASCII && unicode.IsLetter(r) {
+				b.WriteRune(r)
+			}
+		}
+	}
+	if b.Len() == 0 {
+		b.WriteString("ref")
+	}
+	b.WriteString(e.Year)
+	return b.String()
+}
+
+// bibEscape escapes the characters BibTeX treats specially inside braces.
+// Braces and backslashes are escaped too, so a value cannot close its field.
+var bibEscape = strings.NewReplacer(`\`, `\textbackslash{}`, `{`, `\{`, `}`, `\}`, `&`, `\&`, `%`, `\%`, `#`, `\#`, `_`, `\_`, `$`, `\$`).Replace
+
+// bibParser is a cursor over BibTeX source.
+type bibParser struct {
+	s   []rune
+	pos int
+}
+
+func (p *bibParser) eof() bool  { return p.pos >= len(p.s) }
+func (p *bibParser) peek() rune { return p.s[p.pos] }
+
+func (p *bibParser) indexOf(r rune) int {
+	for i := p.pos; i < len(p.s); i++ {
+		if p.s[i] == r {
+			return i
+		}
+	}
+	return -1
+}
+
+func (p *bibParser) skipSpace() {
+	for !p.eof() && unicode.IsSpace(p.peek()) {
+		p.pos++
+	}
+}
+
+// ident reads a field name, entry type or bare value.
+func (p *bibParser) ident() string {
+	p.skipSpace()
+	start := p.pos
+	for !p.eof() {
+		r := p.peek()
+		if unicode.IsSpace(r) || strings.ContainsRune(`{}()=,#"`, r) {
+			break
+		}
+		p.pos++
+	}
+	return string(p.s[start:p.pos])
+}
+
+// skipBlock skips to the closer of the current block, honoring nested braces.
+func (p *bibParser) skipBlock(closer rune) error {
+	depth := 0
+	for ; !p.eof(); p.pos++ {
+		switch r := p.peek(); {
+		case r == '{':
+			depth++
+		case r == '}' && depth > 0:
+			depth--
+		case r == closer && depth == 0:
+			p.pos++
+			return nil
+		}
+	}
+	return errors.New("bibtex: unterminated block")
+}
+
+// fields reads "name = value" pairs up to the closer of the entry.
+func (p *bibParser) fields(closer rune) (map[string]string, error) {
+	fields := map[string]string{}
+	for {
+		p.skipSpace()
+		if p.eof() {
+			return nil, errors.New("unterminated entry")
+		}
+		if p.peek() == closer {
+			p.pos++
+			return fields, nil
+		}
+		if p.peek() == ',' {
+			p.pos++
+			continue
+		}
+
+		name := strings.ToLower(p.ident())
+		p.skipSpace()
+		if name == "" || p.eof() || p.peek() != '=' {
+			return nil, fmt.Errorf("expected = after field %q", name)
+		}
+		p.pos++
+
+		var value strings.Builder
+		for {
+			part, err := p.value()
+			if err != nil {
+				return nil, fmt.Errorf("field %s: %w", name, err)
+			}
+			value.WriteString(part)
+			p.skipSpace()
+			if p.eof() || p.peek() != '#' {
+				break
+			}
+			p.pos++
+		}
+		fields[name] = cleanValue(value.String())
+	}
+}
+
+// value reads a braced, quoted or bare value.
+func (p *bibParser) value() (string, error) {
+	p.skipSpace()
+	if p.eof() {
+		return "", errors.New("missing value")
+	}
+	switch p.peek() {
+	case '{':
+		start := p.pos + 1
+		depth := 0
+		for ; !p.eof(); p.pos++ {
+			switch p.peek() {
+			case '{':
+				depth++
+			case '}':
+				depth--
+				if depth == 0 {
+					p.pos++
+					return string(p.s[start : p.pos-1]), nil
+				}
+			}
+		}
+		return "", errors.New("unbalanced braces")
+	case '"':
+		p.pos++
+		start := p.pos
+		depth := 0
+		for ; !p.eof(); p.pos++ {
+			switch p.peek() {
+			case '{':
+				depth++
+			case '}':
+				depth--
+			case '"':
+				if depth == 0 {
+					p.pos++
+					return string(p.s[start : p.pos-1]), nil
+				}
+			}
+		}
+		return "", errors.New("unterminated quoted value")
+	}
+	return p.ident(), nil
+}
+
+// cleanValue drops the braces and escapes of a BibTeX value and collapses whitespace.
+func cleanValue(v string) string {
+	v = strings.NewReplacer(`\textbackslash{}`, `\`, `\{`, "{", `\}`, "}", "{", "", "}", "", `\&`, "&", `\%`, "%", `\#`, "#", `\_`, "_", `\$`, "$", "~", " ", "--", "–").Replace(v)
+	return strings.Join(strings.Fields(v), " ")
+}
+
+// firstYear returns the first four-digit year in s.
+func firstYear(s string) string {
+	run := 0
+	for i, r := range s {
+		if r >= '0' && r <= '9' {
+			run++
+			if run == 4 && (i+1 == len(s) || s[i+1] < '0' || s[i+1] > '9') {
+				return s[i-3 : i+1]
+			}
+		} else {
+			run = 0
+		}
+	}
+	return ""
+}
+
+// firstOf returns the first non-empty value.
+func firstOf(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// languageCode maps a language name or code to the code stored in entries.
+func languageCode(lang string) string {
+	switch l := strings.ToLower(strings.TrimSpace(lang)); l {
+	case "":
+		return ""
+	case "hebrew", "he", "heb", "עברית":
+		return "he"
+	case "english", "en", "eng", "american", "british", "אנגלית":
+		return "en"
+	default:
+		return l
+	}
+}
+
+// languageName is the BibTeX language name of a code.
+func languageName(code string) string {
+	switch code {
+	case "he":
+		return "hebrew"
+	case "en":
+		return "english"
+	}
+	return code
+}
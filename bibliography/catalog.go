@@ -0,0 +1,176 @@
+package bibliography
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// CSLJSON is the media type of CSL-JSON, the citation metadata format served by
+// doi.org and by most library catalogs.
+const CSLJSON = "application/vnd.citationstyles.csl+json"
+
+// CatalogResolver resolves works over HTTP from a library catalog or a DOI
+// resolver answering in CSL-JSON. The URLs are templates in which "{id}" is
+// replaced by the normalized ISBN or DOI, for example
+// "https://library.example.ac.il/api/isbn/{id}" or "https://doi.org/{id}".
+// A kind of identifier with no URL is not looked up.
+type CatalogResolver struct {
+	ISBNURL string
+	DOIURL  string
+	Client  *http.Client // defaults to a client with a 10 second timeout
+}
+
+// Resolve implements Resolver.
+func (cr *CatalogResolver) Resolve(ctx context.Context, identifier string) (Entry, error) {
+	isbn, doi := ParseIdentifier(identifier)
+	tmpl, id := cr.ISBNURL, isbn
+	if doi != "" {
+		tmpl, id = cr.DOIURL, doi
+	}
+	if tmpl == "" || id == "" {
+		return Entry{}, ErrNotFound
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.ReplaceAll(tmpl, "{id}", escapePath(id)), nil)
+	if err != nil {
+		return Entry{}, fmt.Errorf("CatalogResolver: %w", err)
+	}
+	req.Header.Set("Accept", CSLJSON+", application/json")
+	client := cr.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return Entry{}, fmt.Errorf("CatalogResolver: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return Entry{}, ErrNotFound
+	case resp.StatusCode != http.StatusOK:
+		return Entry{}, fmt.Errorf("CatalogResolver: %s: %s", req.URL, resp.Status)
+	}
+
+	var item cslItem
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&item); err != nil {
+		return Entry{}, fmt.Errorf("CatalogResolver: %s: %w", req.URL, err)
+	}
+	e := item.entry()
+	if e.Title == "" {
+		return Entry{}, ErrNotFound
+	}
+	if e.ISBN == "" {
+		e.ISBN = isbn
+	}
+	if e.DOI == "" {
+		e.DOI = doi
+	}
+	return e, nil
+}
+
+// escapePath escapes an identifier for a URL path, keeping the slashes of a DOI.
+func escapePath(id string) string {
+	segments := strings.Split(id, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	return strings.Join(segments, "/")
+}
+
+// cslItem is the part of a CSL-JSON item a bibliography entry is made of.
+type cslItem struct {
+	Type      string    `json:"type"`
+	Title     cslString `json:"title"`
+	Author    []cslName `json:"author"`
+	Editor    []cslName `json:"editor"`
+	Issued    cslDate   `json:"issued"`
+	Publisher string    `json:"publisher"`
+	Container cslString `json:"container-title"`
+	ISBN      cslString `json:"ISBN"`
+	DOI       string    `json:"DOI"`
+	URL       string    `json:"URL"`
+	Language  string    `json:"language"`
+}
+
+type cslName struct {
+	Family  string `json:"family"`
+	Given   string `json:"given"`
+	Literal string `json:"literal"`
+}
+
+type cslDate struct {
+	DateParts [][]interface{} `json:"date-parts"`
+	Literal   string          `json:"literal"`
+}
+
+// cslString is a CSL-JSON string that some sources send as an array of strings,
+// of which the first is kept.
+type cslString string
+
+func (s *cslString) UnmarshalJSON(data []byte) error {
+	var list []string
+	if err := json.Unmarshal(data, &list); err == nil {
+		if len(list) > 0 {
+			*s = cslString(list[0])
+		}
+		return nil
+	}
+	var str string
+	if err := json.Unmarshal(data, &str); err != nil {
+		return err
+	}
+	*s = cslString(str)
+	return nil
+}
+
+// entry converts a CSL-JSON item to a bibliography entry.
+func (item cslItem) entry() Entry {
+	e := Entry{
+		Type:      TypeBook,
+		Title:     strings.TrimSpace(string(item.Title)),
+		Publisher: strings.TrimSpace(item.Publisher),
+		ISBN:      NormalizeISBN(string(item.ISBN)),
+		DOI:       NormalizeDOI(item.DOI),
+		URL:       strings.TrimSpace(item.URL),
+		Language:  languageCode(strings.SplitN(item.Language, "-", 2)[0]),
+	}
+	switch item.Type {
+	case "article", "article-journal", "article-magazine", "article-newspaper", "paper-conference", "review":
+		e.Type = TypeArticle
+		if e.Publisher == "" {
+			e.Publisher = strings.TrimSpace(string(item.Container))
+		}
+	case "webpage", "post", "post-weblog":
+		e.Type = TypeWeb
+	}
+
+	names := item.Author
+	if len(names) == 0 {
+		names = item.Editor
+	}
+	for _, n := range names {
+		switch {
+		case n.Family != "" && n.Given != "":
+			e.Authors = append(e.Authors, n.Family+", "+n.Given)
+		case n.Family != "":
+			e.Authors = append(e.Authors, n.Family)
+		case n.Literal != "":
+			e.Authors = append(e.Authors, n.Literal)
+		}
+	}
+
+	if len(item.Issued.DateParts) > 0 && len(item.Issued.DateParts[0]) > 0 {
+		e.Year = firstYear(fmt.Sprint(item.Issued.DateParts[0][0]))
+	} else {
+		e.Year = firstYear(item.Issued.Literal)
+	}
+	return e
+}
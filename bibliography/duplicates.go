@@ -0,0 +1,128 @@
+package bibliography
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Duplicates finds entries that cite the same work as an earlier entry. The
+// result holds, for each entry, the index of the first entry it duplicates, or
+// -1. Works match by DOI, by ISBN (10 and 13 digit forms alike), or by title
+// and year, ignoring case, punctuation and spacing.
+func Duplicates(entries []Entry) []int {
+	dup := make([]int, len(entries))
+	seen := map[string]int{}
+	for i, e := range entries {
+		dup[i] = -1
+		if e.IsEmpty() {
+			continue
+		}
+		for _, key := range e.keys() {
+			if first, ok := seen[key]; ok {
+				dup[i] = first
+				break
+			}
+		}
+		if dup[i] >= 0 {
+			continue
+		}
+		for _, key := range e.keys() {
+			seen[key] = i
+		}
+	}
+	return dup
+}
+
+// Key identifies the work an entry cites: its DOI, else its ISBN, else its
+// title and year. It is "" for an empty entry.
+func (e Entry) Key() string {
+	if keys := e.keys(); len(keys) > 0 && !e.IsEmpty() {
+		return keys[0]
+	}
+	return ""
+}
+
+// keys lists the identifiers an entry is matched on.
+func (e Entry) keys() []string {
+	var keys []string
+	if doi := NormalizeDOI(e.DOI); doi != "" {
+		keys = append(keys, "doi:"+doi)
+	}
+	if isbn := NormalizeISBN(e.ISBN); isbn != "" {
+		keys = append(keys, "isbn:"+isbn)
+	}
+	if title := foldTitle(e.Title); title != "" {
+		keys = append(keys, "title:"+title+"|"+e.Year)
+	}
+	return keys
+}
+
+// NormalizeDOI lowercases a DOI and strips the resolver prefix, so that
+// "https://doi.org/10.1000/XYZ" and "10.1000/xyz" compare equal.
+func NormalizeDOI(doi string) string {
+	doi = strings.ToLower(strings.TrimSpace(doi))
+	for _, prefix := range []string{"https://doi.org/", "http://doi.org/", "https://dx.doi.org/", "http://dx.doi.org/", "doi:"} {
+		doi = strings.TrimPrefix(doi, prefix)
+	}
+	return strings.TrimSpace(doi)
+}
+
+// NormalizeISBN returns the 13 digit form of an ISBN, or "" when isbn is not a
+// valid ISBN-10 or ISBN-13.
+func NormalizeISBN(isbn string) string {
+	var digits []byte
+	for _, r := range strings.ToUpper(isbn) {
+		if r >= '0' && r <= '9' || r == 'X' {
+			digits = append(digits, byte(r))
+		}
+	}
+	switch len(digits) {
+	case 10:
+		sum := 0
+		for i, d := range digits {
+			v := int(d - '0')
+			if d == 'X' {
+				if i != 9 {
+					return ""
+				}
+				v = 10
+			}
+			sum += (10 - i) * v
+		}
+		if sum%11 != 0 {
+			return ""
+		}
+		isbn13 := append([]byte("978"), digits[:9]...)
+		return string(append(isbn13, isbn13CheckDigit(isbn13)))
+	case 13:
+		if strings.ContainsRune(string(digits), 'X') || isbn13CheckDigit(digits[:12]) != digits[12] {
+			return ""
+		}
+		return string(digits)
+	}
+	return ""
+}
+
+// isbn13CheckDigit computes the check digit of the first 12 digits of an ISBN-13.
+func isbn13CheckDigit(digits []byte) byte {
+	sum := 0
+	for i, d := range digits[:12] {
+		w := 1
+		if i%2 == 1 {
+			w = 3
+		}
+		sum += w * int(d-'0')
+	}
+	return byte('0' + (10-sum%10)%10)
+}
+
+// foldTitle reduces a title to its lowercase letters and digits.
+func foldTitle(title string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(title) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,130 @@
+// Package bibliography holds structured bibliography entries: citation
+// formatting, BibTeX and RIS import and export, and duplicate detection.
+package bibliography
+
+import (
+	"encoding/json"
+	"strings"
+	"unicode"
+)
+
+// Entry types. An entry without a type is a free-text reference, kept exactly
+// as the lecturer typed it.
+const (
+	TypeBook    = "book"
+	TypeArticle = "article"
+	TypeWeb     = "web"
+)
+
+// Types are the entry types offered in the form, with their Hebrew labels.
+var Types = []Option{
+	{TypeBook, "ספר"},
+	{TypeArticle, "מאמר"},
+	{TypeWeb, "אתר"},
+	{"", "טקסט חופשי"},
+}
+
+// Languages are the entry languages offered in the form.
+var Languages = []Option{
+	{"he", "עברית"},
+	{"en", "אנגלית"},
+}
+
+// Option is a value and label of a dropdown.
+type Option struct {
+	Key   string
+	Label string
+}
+
+// Entry is a bibliography entry of a syllabus.
+type Entry struct {
+	Type      string   `json:"type,omitempty"`
+	Authors   []string `json:"authors,omitempty"` // "Family, Given", in citation order
+	Title     string   `json:"title"`
+	Year      string   `json:"year,omitempty"`
+	Publisher string   `json:"publisher,omitempty"` // The journal of an article, the site of a web page
+	ISBN      string   `json:"isbn,omitempty"`
+	DOI       string   `json:"doi,omitempty"`
+	URL       string   `json:"url,omitempty"`
+	Language  string   `json:"language,omitempty"` // "he", "en", ...
+}
+
+// UnmarshalJSON reads an entry, accepting the plain strings bibliography
+// entries were stored as before they were structured.
+func (e *Entry) UnmarshalJSON(data []byte) error {
+	var text string
+	if json.Unmarshal(data, &text) == nil {
+		*e = Entry{Title: text}
+		return nil
+	}
+	type plain Entry
+	return json.Unmarshal(data, (*plain)(e))
+}
+
+// IsEmpty reports whether nothing was filled in.
+func (e Entry) IsEmpty() bool {
+	return strings.TrimSpace(e.Title) == "" && len(e.Authors) == 0 && e.ISBN == "" && e.DOI == "" && e.URL == ""
+}
+
+// IsFreeText reports whether the entry is a free-text reference.
+func (e Entry) IsFreeText() bool {
+	return e.Type == ""
+}
+
+// AuthorsText joins the authors for editing in a single field.
+func (e Entry) AuthorsText() string {
+	return strings.Join(e.Authors, "; ")
+}
+
+// SplitAuthors splits an authors field written as "Family, Given; Family, Given".
+func SplitAuthors(text string) []string {
+	var authors []string
+	for _, a := range strings.Split(text, ";") {
+		if a = strings.Join(strings.Fields(a), " "); a != "" {
+			authors = append(authors, a)
+		}
+	}
+	return authors
+}
+
+// Hebrew reports whether the entry is in Hebrew: so marked, or, without a
+// language, with a title in Hebrew letters.
+func (e Entry) Hebrew() bool {
+	if e.Language != "" {
+		return e.Language == "he"
+	}
+	for _, r := range e.Title {
+		if unicode.Is(unicode.Hebrew, r) {
+			return true
+		}
+		if unicode.IsLetter(r) {
+			return false
+		}
+	}
+	return false
+}
+
+// splitName splits an author name into family name and given names. Names are
+// written "Family, Given"; without a comma the last word is the family name.
+func splitName(name string) (family string, given []string) {
+	if f, g, ok := strings.Cut(name, ","); ok {
+		return strings.TrimSpace(f), strings.Fields(g)
+	}
+	words := strings.Fields(name)
+	if len(words) == 0 {
+		return "", nil
+	}
+	return words[len(words)-1], words[:len(words)-1]
+}
+
+// initials abbreviates given names, "Mary Ann" to "M. A.".
+func initials(given []string) string {
+	parts := make([]string, 0, len(given))
+	for _, g := range given {
+		for _, r := range g {
+			parts = append(parts, string(r)+".")
+			break
+		}
+	}
+	return strings.Join(parts, " ")
+}
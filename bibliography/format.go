@@ -0,0 +1,259 @@
+package bibliography
+
+import (
+	"strings"
+)
+
+// Citation styles.
+const (
+	APA     = "apa"     // APA 7th edition
+	MLA     = "mla"     // MLA 9th edition
+	Chicago = "chicago" // Chicago author-date
+	IEEE    = "ieee"
+)
+
+// Styles are the citation styles a syllabus can be shown in.
+var Styles = []Option{
+	{APA, "APA"},
+	{MLA, "MLA"},
+	{Chicago, "Chicago"},
+	{IEEE, "IEEE"},
+}
+
+// ParseStyle returns the citation style named s, or APA when s names none.
+func ParseStyle(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	for _, style := range Styles {
+		if style.Key == s {
+			return s
+		}
+	}
+	return APA
+}
+
+// Format formats the entry as a reference in the given citation style.
+// Free-text entries are returned as written.
+func (e Entry) Format(style string) string {
+	if e.IsFreeText() {
+		return strings.TrimSpace(e.Title)
+	}
+	switch ParseStyle(style) {
+	case MLA:
+		return e.formatMLA()
+	case Chicago:
+		return e.formatChicago()
+	case IEEE:
+		return e.formatIEEE()
+	}
+	return e.formatAPA()
+}
+
+// Author: A. (Year). Title. Publisher. https://doi.org/...
+func (e Entry) formatAPA() string {
+	he := e.Hebrew()
+	names := make([]string, 0, len(e.Authors))
+	for _, a := range e.Authors {
+		family, given := splitName(a)
+		if in := initials(given); in != "" {
+			family += ", " + in
+		}
+		names = append(names, family)
+	}
+	// Up to 20 authors are listed; beyond that the first 19, an ellipsis and the last.
+	if len(names) > 20 {
+		names = append(append(names[:19:19], "..."), names[len(names)-1])
+	}
+
+	year := "(" + e.Year + ")."
+	if e.Year == "" {
+		year = "(n.d.)."
+		if he {
+			year = "(ללא תאריך)."
+		}
+	}
+
+	var parts []string
+	if len(names) > 0 {
+		parts = append(parts, sentence(joinNames(names, he, "&", true)), year, sentence(e.Title))
+	} else {
+		parts = append(parts, sentence(e.Title), year)
+	}
+	parts = append(parts, sentence(e.Publisher), e.link())
+	return join(parts)
+}
+
+// Family, Given, and Given Family. Title. Publisher, Year.
+func (e Entry) formatMLA() string {
+	he := e.Hebrew()
+	var authors string
+	switch n := len(e.Authors); {
+	case n == 1:
+		authors = invertedName(e.Authors[0])
+	case n == 2:
+		authors = joinNames([]string{invertedName(e.Authors[0]), directName(e.Authors[1])}, he, "and", true)
+	case n > 2:
+		authors = invertedName(e.Authors[0]) + ", et al."
+		if he {
+			authors = invertedName(e.Authors[0]) + " ואחרים"
+		}
+	}
+
+	title := sentence(e.Title)
+	if e.Type != TypeBook {
+		title = quoted(e.Title)
+	}
+	source := e.Publisher
+	if e.Year != "" {
+		if source != "" {
+			source += ", "
+		}
+		source += e.Year
+	}
+	return join([]string{sentence(authors), title, sentence(source), e.link()})
+}
+
+// Family, Given, and Given Family. Year. Title. Publisher.
+func (e Entry) formatChicago() string {
+	he := e.Hebrew()
+	names := make([]string, 0, len(e.Authors))
+	for i, a := range e.Authors {
+		if i == 0 {
+			names = append(names, invertedName(a))
+		} else {
+			names = append(names, directName(a))
+		}
+	}
+
+	year := e.Year
+	if year == "" {
+		year = "n.d."
+		if he {
+			year = "ללא תאריך"
+		}
+	}
+	title := sentence(e.Title)
+	if e.Type != TypeBook {
+		title = quoted(e.Title)
+	}
+
+	var parts []string
+	if len(names) > 0 {
+		parts = append(parts, sentence(joinNames(names, he, "and", true)), sentence(year), title)
+	} else {
+		parts = append(parts, title, sentence(year))
+	}
+	parts = append(parts, sentence(e.Publisher), e.link())
+	return join(parts)
+}
+
+// G. Family and G. Family, Title. Publisher, Year.
+func (e Entry) formatIEEE() string {
+	he := e.Hebrew()
+	names := make([]string, 0, len(e.Authors))
+	for _, a := range e.Authors {
+		family, given := splitName(a)
+		names = append(names, strings.TrimSpace(initials(given)+" "+family))
+	}
+	authors := joinNames(names, he, "and", len(names) > 2)
+	if len(names) > 6 {
+		authors = names[0] + " et al."
+		if he {
+			authors = names[0] + " ואחרים"
+		}
+	}
+
+	if authors != "" {
+		authors += ","
+	}
+	title := sentence(e.Title)
+	if e.Type != TypeBook {
+		title = `"` + strings.TrimRight(e.Title, ".,") + `,"`
+	}
+	var source []string
+	for _, s := range []string{e.Publisher, e.Year} {
+		if s != "" {
+			source = append(source, s)
+		}
+	}
+
+	parts := []string{authors, title, sentence(strings.Join(source, ", "))}
+	switch {
+	case e.DOI != "":
+		parts = append(parts, "doi: "+e.DOI+".")
+	case e.URL != "":
+		parts = append(parts, "[Online]. Available: "+e.URL)
+	}
+	return join(parts)
+}
+
+// link is the DOI of the entry as a URL, or its URL.
+func (e Entry) link() string {
+	if e.DOI != "" {
+		return "https://doi.org/" + e.DOI
+	}
+	return e.URL
+}
+
+// invertedName writes an author "Family, Given".
+func invertedName(name string) string {
+	family, given := splitName(name)
+	if len(given) == 0 {
+		return family
+	}
+	return family + ", " + strings.Join(given, " ")
+}
+
+// directName writes an author "Given Family".
+func directName(name string) string {
+	family, given := splitName(name)
+	return strings.TrimSpace(strings.Join(given, " ") + " " + family)
+}
+
+// joinNames joins author names into a list, with conj ("&" or "and") before
+// the last name. Hebrew lists attach the conjunction "ו" to the last name instead.
+func joinNames(names []string, hebrew bool, conj string, serialComma bool) string {
+	switch len(names) {
+	case 0:
+		return ""
+	case 1:
+		return names[0]
+	}
+	head := strings.Join(names[:len(names)-1], ", ")
+	last := names[len(names)-1]
+	if hebrew {
+		return head + " ו" + last
+	}
+	if serialComma {
+		return head + ", " + conj + " " + last
+	}
+	return head + " " + conj + " " + last
+}
+
+// sentence ends s with a period unless it already ends with punctuation.
+func sentence(s string) string {
+	s = strings.TrimSpace(s)
+	if s == "" || strings.HasSuffix(s, ".") || strings.HasSuffix(s, "?") || strings.HasSuffix(s, "!") {
+		return s
+	}
+	return s + "."
+}
+
+// quoted writes a title in quotation marks, with the period inside them.
+func quoted(title string) string {
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return ""
+	}
+	return `"` + sentence(title) + `"`
+}
+
+// join joins the non-empty parts of a reference with spaces.
+func join(parts []string) string {
+	var out []string
+	for _, p := range parts {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return strings.Join(out, " ")
+}
@@ -0,0 +1,126 @@
+package bibliography
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ErrNotFound is returned by a Resolver that does not know the work.
+var ErrNotFound = errors.New("bibliography: work not found")
+
+// Resolver looks up the details of a work by its ISBN or DOI.
+type Resolver interface {
+	Resolve(ctx context.Context, identifier string) (Entry, error)
+}
+
+// ParseIdentifier tells whether s is a DOI or an ISBN, and returns it in
+// normalized form. Both are "" when s is neither.
+func ParseIdentifier(s string) (isbn, doi string) {
+	if d := NormalizeDOI(s); strings.HasPrefix(d, "10.") && strings.Contains(d, "/") {
+		return "", d
+	}
+	return NormalizeISBN(s), ""
+}
+
+// Identifier is the ISBN or DOI of an entry to resolve it by, the DOI first.
+func (e Entry) Identifier() string {
+	if e.DOI != "" {
+		return e.DOI
+	}
+	return e.ISBN
+}
+
+// Merge fills the empty details of e with those of a resolved work. Details
+// the lecturer typed in are kept.
+func (e Entry) Merge(resolved Entry) Entry {
+	set := func(dst *string, src string) {
+		if strings.TrimSpace(*dst) == "" {
+			*dst = strings.TrimSpace(src)
+		}
+	}
+	// A new entry is a book until the lecturer says otherwise, so its type is
+	// only theirs once they have typed in its title.
+	if strings.TrimSpace(e.Title) == "" && strings.TrimSpace(resolved.Type) != "" {
+		e.Type = strings.TrimSpace(resolved.Type)
+	}
+	set(&e.Title, resolved.Title)
+	set(&e.Year, resolved.Year)
+	set(&e.Publisher, resolved.Publisher)
+	set(&e.ISBN, resolved.ISBN)
+	set(&e.DOI, resolved.DOI)
+	set(&e.URL, resolved.URL)
+	set(&e.Language, resolved.Language)
+	if len(e.Authors) == 0 {
+		e.Authors = resolved.Authors
+	}
+	return e
+}
+
+// Resolvers tries each resolver in turn and returns the first work found. A
+// resolver that fails does not stop the others from being tried; its error is
+// returned only when none of them found the work.
+type Resolvers []Resolver
+
+// Resolve implements Resolver.
+func (rs Resolvers) Resolve(ctx context.Context, identifier string) (Entry, error) {
+	var errs []error
+	for _, r := range rs {
+		e, err := r.Resolve(ctx, identifier)
+		if err == nil {
+			return e, nil
+		}
+		if !errors.Is(err, ErrNotFound) {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return Entry{}, errors.Join(errs...)
+	}
+	return Entry{}, ErrNotFound
+}
+
+// FileResolver resolves works listed in a JSON file holding an array of
+// entries. It serves tests and catalogs exported to a file.
+type FileResolver struct {
+	works map[string]Entry
+}
+
+// NewFileResolver reads the works of a JSON file.
+func NewFileResolver(path string) (*FileResolver, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("NewFileResolver: %w", err)
+	}
+	var entries []Entry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("NewFileResolver: %s: %w", path, err)
+	}
+
+	f := &FileResolver{works: map[string]Entry{}}
+	for _, e := range entries {
+		if doi := NormalizeDOI(e.DOI); doi != "" {
+			f.works["doi:"+doi] = e
+		}
+		if isbn := NormalizeISBN(e.ISBN); isbn != "" {
+			f.works["isbn:"+isbn] = e
+		}
+	}
+	return f, nil
+}
+
+// Resolve implements Resolver.
+func (f *FileResolver) Resolve(_ context.Context, identifier string) (Entry, error) {
+	isbn, doi := ParseIdentifier(identifier)
+	key := "isbn:" + isbn
+	if doi != "" {
+		key = "doi:" + doi
+	}
+	if e, ok := f.works[key]; ok {
+		return e, nil
+	}
+	return Entry{}, ErrNotFound
+}
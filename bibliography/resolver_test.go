@@ -0,0 +1,208 @@
+package bibliography
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFileResolver(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "works.json")
+	works := `[
+		{"type": "book", "title": "Algorithms", "authors": ["Cormen, Thomas"], "year": "2009", "isbn": "978-0-262-03384-8"},
+		{"type": "article", "title": "A Note on Two Problems", "authors": ["Dijkstra, E. W."], "year": "1959", "doi": "https://doi.org/10.1007/BF01386390"}
+	]`
+	if err := os.WriteFile(path, []byte(works), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := NewFileResolver(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		identifier, title string
+	}{
+		{"9780262033848", "Algorithms"},
+		{"978 0 262 03384 8", "Algorithms"},
+		{"10.1007/BF01386390", "A Note on Two Problems"},
+		{"doi:10.1007/bf01386390", "A Note on Two Problems"},
+	} {
+		e, err := f.Resolve(context.Background(), tc.identifier)
+		if err != nil {
+			t.Errorf("Resolve(%q): %v", tc.identifier, err)
+			continue
+		}
+		if e.Title != tc.title {
+			t.Errorf("Resolve(%q) = %q, want %q", tc.identifier, e.Title, tc.title)
+		}
+	}
+
+	if _, err := f.Resolve(context.Background(), "10.1000/unknown"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Resolve of an unknown DOI: got %v, want ErrNotFound", err)
+	}
+}
+
+func TestNewFileResolverErrors(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := NewFileResolver(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("NewFileResolver of a missing file: got no error")
+	}
+	path := filepath.Join(dir, "bad.json")
+	if err := os.WriteFile(path, []byte(`{"title": "not an array"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewFileResolver(path); err == nil {
+		t.Error("NewFileResolver of a file that is not an array: got no error")
+	}
+}
+
+// catalog serves CSL-JSON items by the path they are requested at, and
+// records the Accept header of the last request.
+func catalog(t *testing.T, items map[string]string) (*httptest.Server, *string) {
+	t.Helper()
+	var accept string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accept = r.Header.Get("Accept")
+		switch item, ok := items[r.URL.EscapedPath()]; {
+		case strings.HasPrefix(r.URL.Path, "/broken/"):
+			http.Error(w, "down", http.StatusServiceUnavailable)
+		case !ok:
+			http.NotFound(w, r)
+		default:
+			w.Header().Set("Content-Type", CSLJSON)
+			w.Write([]byte(item))
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &accept
+}
+
+func TestCatalogResolver(t *testing.T) {
+	srv, accept := catalog(t, map[string]string{
+		"/isbn/9780262033848": `{
+			"type": "book",
+			"title": "Introduction to Algorithms",
+			"author": [{"family": "Cormen", "given": "Thomas"}, {"literal": "MIT Press Staff"}],
+			"issued": {"date-parts": [[2009, 7]]},
+			"publisher": "MIT Press",
+			"language": "en-US"
+		}`,
+		"/doi/10.1007/bf01386390": `{
+			"type": "article-journal",
+			"title": ["A Note on Two Problems in Connexion with Graphs"],
+			"author": [{"family": "Dijkstra", "given": "E. W."}],
+			"issued": {"date-parts": [["1959"]]},
+			"container-title": ["Numerische Mathematik"],
+			"DOI": "10.1007/BF01386390"
+		}`,
+		"/isbn/9780000000002": `{"type": "book", "title": ""}`,
+	})
+	cr := &CatalogResolver{ISBNURL: srv.URL + "/isbn/{id}", DOIURL: srv.URL + "/doi/{id}"}
+	ctx := context.Background()
+
+	book, err := cr.Resolve(ctx, "978-0-262-03384-8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Entry{
+		Type:      TypeBook,
+		Authors:   []string{"Cormen, Thomas", "MIT Press Staff"},
+		Title:     "Introduction to Algorithms",
+		Year:      "2009",
+		Publisher: "MIT Press",
+		ISBN:      "9780262033848",
+		Language:  "en",
+	}
+	if !reflect.DeepEqual(book, want) {
+		t.Errorf("Resolve of an ISBN = %+v, want %+v", book, want)
+	}
+	if *accept != CSLJSON+", application/json" {
+		t.Errorf("Accept = %q, want CSL-JSON", *accept)
+	}
+
+	article, err := cr.Resolve(ctx, "https://doi.org/10.1007/BF01386390")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if article.Type != TypeArticle || article.Publisher != "Numerische Mathematik" || article.Year != "1959" {
+		t.Errorf("Resolve of a DOI = %+v, want a 1959 article in Numerische Mathematik", article)
+	}
+
+	for _, identifier := range []string{"9780131103627", "9780000000002"} {
+		if _, err := cr.Resolve(ctx, identifier); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Resolve(%q): got %v, want ErrNotFound", identifier, err)
+		}
+	}
+
+	// A kind of identifier with no URL is not looked up.
+	isbnOnly := &CatalogResolver{ISBNURL: srv.URL + "/isbn/{id}"}
+	if _, err := isbnOnly.Resolve(ctx, "10.1007/BF01386390"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Resolve of a DOI with no DOI URL: got %v, want ErrNotFound", err)
+	}
+
+	broken := &CatalogResolver{ISBNURL: srv.URL + "/broken/{id}"}
+	if _, err := broken.Resolve(ctx, "9780262033848"); err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("Resolve from a failing catalog: got %v, want an error other than ErrNotFound", err)
+	}
+}
+
+type resolverFunc func(ctx context.Context, identifier string) (Entry, error)
+
+func (f resolverFunc) Resolve(ctx context.Context, identifier string) (Entry, error) {
+	return f(ctx, identifier)
+}
+
+func TestResolvers(t *testing.T) {
+	down := errors.New("catalog down")
+	notFound := resolverFunc(func(context.Context, string) (Entry, error) { return Entry{}, ErrNotFound })
+	failing := resolverFunc(func(context.Context, string) (Entry, error) { return Entry{}, down })
+	found := resolverFunc(func(context.Context, string) (Entry, error) { return Entry{Title: "Found"}, nil })
+	ctx := context.Background()
+
+	if e, err := (Resolvers{notFound, failing, found}).Resolve(ctx, "9780262033848"); err != nil || e.Title != "Found" {
+		t.Errorf("Resolve past a failing resolver = %+v, %v; want the work found after it", e, err)
+	}
+	if _, err := (Resolvers{failing, notFound}).Resolve(ctx, "9780262033848"); !errors.Is(err, down) {
+		t.Errorf("Resolve with no work found: got %v, want the error of the failing resolver", err)
+	}
+	if _, err := (Resolvers{notFound, notFound}).Resolve(ctx, "9780262033848"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Resolve with no work found: got %v, want ErrNotFound", err)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	resolved := Entry{
+		Type:      TypeArticle,
+		Authors:   []string{"Dijkstra, E. W."},
+		Title:     "A Note on Two Problems",
+		Year:      "1959",
+		Publisher: "Numerische Mathematik",
+		DOI:       "10.1007/BF01386390",
+	}
+
+	typed := Entry{Type: TypeBook, Title: "Dijkstra's note", DOI: "10.1007/BF01386390"}
+	got := typed.Merge(resolved)
+	want := Entry{
+		Type:      TypeBook,
+		Authors:   []string{"Dijkstra, E. W."},
+		Title:     "Dijkstra's note",
+		Year:      "1959",
+		Publisher: "Numerische Mathematik",
+		DOI:       "10.1007/BF01386390",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Merge into a typed-in entry = %+v, want %+v", got, want)
+	}
+
+	// A new entry with only its identifier takes the type of the work.
+	if got := (Entry{Type: TypeBook, DOI: "10.1007/BF01386390"}).Merge(resolved); got.Type != TypeArticle {
+		t.Errorf("Merge into a new entry: type %q, want %q", got.Type, TypeArticle)
+	}
+}
@@ -0,0 +1,179 @@
+package bibliography
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// extend appends a continuation line to a value.
+func extend(value *string, more string) {
+	*value += " " + more
+}
+
+// ParseRIS reads the entries of an RIS file. Each entry starts with a TY tag
+// and ends with ER.
+func ParseRIS(text string) ([]Entry, error) {
+	var entries []Entry
+	var cur *Entry
+	var journal, bookTitle string
+	// continued extends the value of the last tag read with a continuation
+	// line; it is nil before the first tag and discards the continuations of
+	// tags that are not kept, such as abstracts.
+	var continued func(string)
+	for n, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		line = strings.TrimRight(line, " \t")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		tag, value, ok := risLine(line)
+		if !ok {
+			// Long values may continue on the next line.
+			if cur != nil && continued != nil {
+				continued(strings.TrimSpace(line))
+				continue
+			}
+			return nil, fmt.Errorf("ris: line %d: expected \"TAG  - value\"", n+1)
+		}
+
+		if tag == "TY" {
+			cur = &Entry{Type: risType(value)}
+			journal, bookTitle = "", ""
+			continued = func(string) {}
+			continue
+		}
+		if cur == nil {
+			return nil, fmt.Errorf("ris: line %d: %s before TY", n+1, tag)
+		}
+		continued = func(string) {}
+		switch tag {
+		case "AU", "A1":
+			cur.Authors = append(cur.Authors, value)
+			continued = func(more string) { extend(&cur.Authors[len(cur.Authors)-1], more) }
+		case "TI", "T1":
+			cur.Title = value
+			continued = func(more string) { extend(&cur.Title, more) }
+		case "T2", "BT":
+			bookTitle = value
+			continued = func(more string) { extend(&bookTitle, more) }
+		case "JO", "JF", "JA":
+			journal = value
+			continued = func(more string) { extend(&journal, more) }
+		case "PY", "Y1", "DA":
+			if cur.Year == "" {
+				cur.Year = firstYear(value)
+			}
+		case "PB":
+			cur.Publisher = value
+			continued = func(more string) { extend(&cur.Publisher, more) }
+		case "SN":
+			// SN holds an ISSN for journals; only book numbers are kept.
+			if cur.Type != TypeArticle && cur.ISBN == "" {
+				cur.ISBN = value
+			}
+		case "DO":
+			cur.DOI = NormalizeDOI(value)
+		case "UR", "L2":
+			if cur.URL == "" {
+				cur.URL = value
+			}
+		case "LA":
+			cur.Language = languageCode(value)
+		case "ER":
+			if cur.Type == TypeArticle {
+				cur.Publisher = firstOf(journal, bookTitle, cur.Publisher)
+			}
+			entries = append(entries, *cur)
+			cur = nil
+			continued = nil
+		}
+	}
+	if cur != nil {
+		return nil, errors.New("ris: last entry has no ER tag")
+	}
+	return entries, nil
+}
+
+// risLine splits an RIS line "TY  - BOOK" into its tag and value.
+func risLine(line string) (tag, value string, ok bool) {
+	if len(line) < 5 || line[2:5] != "  -" {
+		return "", "", false
+	}
+	tag = line[:2]
+	for _, r := range tag {
+		if !(r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return "", "", false
+		}
+	}
+	return tag, strings.TrimSpace(line[5:]), true
+}
+
+// risType maps an RIS reference type to an entry type.
+func risType(ty string) string {
+	switch strings.ToUpper(strings.TrimSpace(ty)) {
+	case "JOUR", "JFULL", "MGZN", "NEWS", "CPAPER", "CONF":
+		return TypeArticle
+	case "ELEC", "WEB", "BLOG":
+		return TypeWeb
+	}
+	return TypeBook
+}
+
+// WriteRIS writes entries as an RIS file. Free-text entries are written as
+// generic references with the text as their title.
+func WriteRIS(w io.Writer, entries []Entry) error {
+	bw := bufio.NewWriter(w)
+	line := func(tag, value string) {
+		if value != "" {
+			fmt.Fprintf(bw, "%s  - %s\r\n", tag, value)
+		}
+	}
+	for _, e := range entries {
+		if e.IsEmpty() {
+			continue
+		}
+		switch e.Type {
+		case TypeArticle:
+			line("TY", "JOUR")
+		case TypeWeb:
+			line("TY", "ELEC")
+		case TypeBook:
+			line("TY", "BOOK")
+		default:
+			line("TY", "GEN")
+		}
+		for _, a := range e.Authors {
+			line("AU", a)
+		}
+		line("TI", e.Title)
+		line("PY", e.Year)
+		if e.Type == TypeArticle {
+			line("JO", e.Publisher)
+		} else {
+			line("PB", e.Publisher)
+		}
+		line("SN", e.ISBN)
+		line("DO", e.DOI)
+		line("UR", e.URL)
+		line("LA", languageName(e.Language))
+		bw.WriteString("ER  - \r\n")
+	}
+	return bw.Flush()
+}
+
+// ErrUnknownFormat is returned by Parse for text that is neither BibTeX nor RIS.
+var ErrUnknownFormat = errors.New("bibliography: not BibTeX or RIS")
+
+// Parse reads BibTeX or RIS, recognizing the format from the text.
+func Parse(text string) ([]Entry, error) {
+	trimmed := strings.TrimSpace(text)
+	switch {
+	case strings.HasPrefix(trimmed, "TY  -"):
+		return ParseRIS(trimmed)
+	case strings.HasPrefix(trimmed, "@") || strings.Contains(trimmed, "\n@"):
+		return ParseBibTeX(trimmed)
+	}
+	return nil, ErrUnknownFormat
+}
@@ -0,0 +1,205 @@
+// Package config holds the settings of the server. They are loaded from
+// defaults, then a YAML file, then environment variables, then command-line
+// flags, each source overriding the ones before it, and validated at startup.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// Config is the configuration of the server. The tags of a setting name its
+// key in the YAML file, its environment variable and its flag; settings
+// without a flag, like secrets, cannot be given on the command line.
+type Config struct {
+	Server       Server       `yaml:"server"`
+	Database     Database     `yaml:"database"`
+	Auth         Auth         `yaml:"auth"`
+	Trash        Trash        `yaml:"trash"`
+	Bibliography Bibliography `yaml:"bibliography"`
+	Telemetry    Telemetry    `yaml:"telemetry"`
+	Log          Log          `yaml:"log"`
+}
+
+// Server configures the HTTP server.
+type Server struct {
+	Addr            string        `yaml:"addr" env:"SYLLABEA_ADDR" flag:"addr" usage:"address the server listens on"`
+	ViewsDir        string        `yaml:"views_dir" env:"SYLLABEA_VIEWS_DIR" flag:"views-dir" usage:"directory of the HTML templates"`
+	StaticDir       string        `yaml:"static_dir" env:"SYLLABEA_STATIC_DIR" flag:"static-dir" usage:"directory of the files served under /static"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"SYLLABEA_SHUTDOWN_TIMEOUT" flag:"shutdown-timeout" usage:"how long to wait for requests and workers when stopping"`
+}
+
+// Database configures the MySQL connection pool. The server retries to
+// connect for ConnectTimeout at startup, as the database may still be starting.
+type Database struct {
+	DSN             string        `yaml:"dsn" env:"MYSQL_DSN" flag:"dsn" usage:"MySQL data source name, user:password@tcp(host:port)/database"`
+	MaxOpenConns    int           `yaml:"max_open_conns" env:"SYLLABEA_DB_MAX_OPEN_CONNS" flag:"db-max-open-conns" usage:"maximum open connections, 0 for no limit"`
+	MaxIdleConns    int           `yaml:"max_idle_conns" env:"SYLLABEA_DB_MAX_IDLE_CONNS" flag:"db-max-idle-conns" usage:"maximum idle connections"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" env:"SYLLABEA_DB_CONN_MAX_LIFETIME" flag:"db-conn-max-lifetime" usage:"maximum time a connection is reused, 0 for no limit"`
+	ConnectTimeout  time.Duration `yaml:"connect_timeout" env:"SYLLABEA_DB_CONNECT_TIMEOUT" flag:"db-connect-timeout" usage:"how long to retry connecting to the database at startup"`
+}
+
+// Auth configures the sessions of logged-in users.
+type Auth struct {
+	JWTSecret       string        `yaml:"jwt_secret" env:"SYLLABEA_JWT_SECRET"`
+	SessionLifetime time.Duration `yaml:"session_lifetime" env:"SYLLABEA_SESSION_LIFETIME" flag:"session-lifetime" usage:"how long a login lasts"`
+	SecureCookies   bool          `yaml:"secure_cookies" env:"SYLLABEA_SECURE_COOKIES" flag:"secure-cookies" usage:"send the session cookie over HTTPS only"`
+}
+
+// Trash configures how long deleted syllabi are kept.
+type Trash struct {
+	RetentionDays int           `yaml:"retention_days" env:"TRASH_RETENTION_DAYS" flag:"trash-retention-days" usage:"days a syllabus stays in the trash before it is purged"`
+	PurgeInterval time.Duration `yaml:"purge_interval" env:"SYLLABEA_TRASH_PURGE_INTERVAL" flag:"trash-purge-interval" usage:"how often the trash is purged"`
+}
+
+// Bibliography configures where bibliography entries are completed from: a
+// works file, the library catalog and a DOI resolver, in that order. The URLs
+// are templates with "{id}" for the ISBN or DOI. DOIURL is doi.org unless set
+// to "" in the file or by the flag, which disables the lookup.
+type Bibliography struct {
+	File           string `yaml:"file" env:"BIBLIOGRAPHY_FILE" flag:"bibliography-file" usage:"JSON file of known works"`
+	CatalogISBNURL string `yaml:"catalog_isbn_url" env:"LIBRARY_CATALOG_ISBN_URL" flag:"catalog-isbn-url" usage:"library catalog URL of an ISBN, with {id}"`
+	CatalogDOIURL  string `yaml:"catalog_doi_url" env:"LIBRARY_CATALOG_DOI_URL" flag:"catalog-doi-url" usage:"library catalog URL of a DOI, with {id}"`
+	DOIURL         string `yaml:"doi_url" env:"DOI_RESOLVER_URL" flag:"doi-url" usage:"DOI resolver URL of a DOI, with {id}, or empty to not look DOIs up"`
+}
+
+// Telemetry configures the metrics and traces of the server. Metrics are
+// served under /metrics on a listener of their own, away from the public
+// address, unless MetricsAddr is empty; spans are exported only if Tracing
+// says where.
+type Telemetry struct {
+	MetricsAddr string `yaml:"metrics_addr" env:"SYLLABEA_METRICS_ADDR" flag:"metrics-addr" usage:"address /metrics is served on, empty to not serve it"`
+	Tracing     string `yaml:"tracing" env:"SYLLABEA_TRACING" flag:"tracing" usage:"where request traces are exported: none or stdout"`
+}
+
+// Exporters of the spans of request traces.
+const (
+	TracingNone   = "none"
+	TracingStdout = "stdout"
+)
+
+// Log configures the logs of the server.
+type Log struct {
+	Format string `yaml:"format" env:"SYLLABEA_LOG_FORMAT" flag:"log-format" usage:"format of the log lines: text or json"`
+	Level  string `yaml:"level" env:"SYLLABEA_LOG_LEVEL" flag:"log-level" usage:"least severe level logged: debug, info, warn or error"`
+}
+
+// Formats of the log lines.
+const (
+	LogText = "text"
+	LogJSON = "json"
+)
+
+// Default returns the configuration used where no source sets a value.
+func Default() Config {
+	return Config{
+		Server: Server{
+			Addr:            ":9090",
+			ViewsDir:        "views",
+			StaticDir:       "static",
+			ShutdownTimeout: 15 * time.Second,
+		},
+		Database: Database{
+			DSN:             "root:admin@tcp(localhost:3306)/syllabus",
+			MaxIdleConns:    2,
+			ConnMaxLifetime: 5 * time.Minute,
+			ConnectTimeout:  time.Minute,
+		},
+		Auth: Auth{
+			SessionLifetime: 24 * time.Hour,
+		},
+		Trash: Trash{
+			RetentionDays: 30,
+			PurgeInterval: time.Hour,
+		},
+		Bibliography: Bibliography{
+			DOIURL: "https://doi.org/{id}",
+		},
+		Telemetry: Telemetry{
+			MetricsAddr: "localhost:9091",
+			Tracing:     TracingNone,
+		},
+		Log: Log{
+			Format: LogText,
+			Level:  "info",
+		},
+	}
+}
+
+// minSecretLength is the shortest JWT secret accepted, in bytes.
+const minSecretLength = 16
+
+// Validate reports every setting that is missing or out of range.
+func (c Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	_, _, err := net.SplitHostPort(c.Server.Addr)
+	check(err == nil, "server.addr %q: must be host:port, e.g. \":9090\"", c.Server.Addr)
+	check(c.Server.ViewsDir != "", "server.views_dir is required")
+	check(c.Server.StaticDir != "", "server.static_dir is required")
+	check(c.Server.ShutdownTimeout > 0, "server.shutdown_timeout must be positive")
+
+	_, err = mysql.ParseDSN(c.Database.DSN)
+	check(c.Database.DSN != "" && err == nil, "database.dsn is not a valid MySQL data source name")
+	check(c.Database.MaxOpenConns >= 0, "database.max_open_conns must not be negative")
+	check(c.Database.MaxIdleConns >= 0, "database.max_idle_conns must not be negative")
+	check(c.Database.ConnMaxLifetime >= 0, "database.conn_max_lifetime must not be negative")
+	check(c.Database.ConnectTimeout >= 0, "database.connect_timeout must not be negative")
+
+	check(len(c.Auth.JWTSecret) >= minSecretLength,
+		"auth.jwt_secret must be at least %d characters (set SYLLABEA_JWT_SECRET)", minSecretLength)
+	check(c.Auth.SessionLifetime > 0, "auth.session_lifetime must be positive")
+
+	check(c.Trash.RetentionDays >= 1, "trash.retention_days must be a positive number of days")
+	check(c.Trash.PurgeInterval > 0, "trash.purge_interval must be positive")
+
+	for _, u := range []struct{ key, url string }{
+		{"bibliography.catalog_isbn_url", c.Bibliography.CatalogISBNURL},
+		{"bibliography.catalog_doi_url", c.Bibliography.CatalogDOIURL},
+		{"bibliography.doi_url", c.Bibliography.DOIURL},
+	} {
+		check(u.url == "" || strings.Contains(u.url, "{id}"), "%s must contain {id}", u.key)
+	}
+	if c.Telemetry.MetricsAddr != "" {
+		_, _, err = net.SplitHostPort(c.Telemetry.MetricsAddr)
+		check(err == nil, "telemetry.metrics_addr %q: must be host:port, e.g. \"localhost:9091\"", c.Telemetry.MetricsAddr)
+	}
+	check(c.Telemetry.Tracing == TracingNone || c.Telemetry.Tracing == TracingStdout,
+		"telemetry.tracing %q: must be %q or %q", c.Telemetry.Tracing, TracingNone, TracingStdout)
+
+	check(c.Log.Format == LogText || c.Log.Format == LogJSON,
+		"log.format %q: must be %q or %q", c.Log.Format, LogText, LogJSON)
+	var level slog.Level
+	check(level.UnmarshalText([]byte(c.Log.Level)) == nil,
+		"log.level %q: must be debug, info, warn or error", c.Log.Level)
+	return errors.Join(errs...)
+}
+
+// redacted replaces a secret that is set, so printing it shows only that it is.
+const redacted = "********"
+
+// Redacted returns a copy of the configuration that is safe to print: the
+// JWT secret and the database password are replaced.
+func (c Config) Redacted() Config {
+	if c.Auth.JWTSecret != "" {
+		c.Auth.JWTSecret = redacted
+	}
+	if dsn, err := mysql.ParseDSN(c.Database.DSN); err == nil && dsn.Passwd != "" {
+		dsn.Passwd = redacted
+		c.Database.DSN = dsn.FormatDSN()
+	} else if err != nil {
+		c.Database.DSN = redacted
+	}
+	return c
+}
@@ -0,0 +1,154 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileEnv names the environment variable of the configuration file, which
+// the -config flag overrides.
+const FileEnv = "SYLLABEA_CONFIG"
+
+// setting is one configurable value, found by walking the fields of Config.
+type setting struct {
+	key   string // Dotted YAML key, e.g. "server.addr"
+	env   string
+	flag  string
+	usage string
+	value reflect.Value
+}
+
+// Load returns the configuration given by the file, the environment (read
+// through getenv) and the flags in args, over the defaults. It does not
+// validate the result; see Validate.
+func Load(name string, args []string, getenv func(string) string) (Config, error) {
+	cfg := Default()
+	settings := settingsOf(reflect.ValueOf(&cfg).Elem(), "")
+
+	// Flags are applied last, after the file and the environment.
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	path := fs.String("config", "", "path of the YAML configuration file (env "+FileEnv+")")
+	var fromFlags []func() error
+	for _, s := range settings {
+		if s.flag == "" {
+			continue
+		}
+		s := s
+		fs.Func(s.flag, s.usage+" (env "+s.env+")", func(v string) error {
+			fromFlags = append(fromFlags, func() error { return set(s, v, "-"+s.flag) })
+			return nil
+		})
+	}
+	if err := fs.Parse(args); err != nil {
+		return cfg, err
+	}
+	if fs.NArg() > 0 {
+		return cfg, fmt.Errorf("unexpected arguments: %v", fs.Args())
+	}
+
+	if *path == "" {
+		*path = getenv(FileEnv)
+	}
+	if *path != "" {
+		if err := loadFile(&cfg, *path); err != nil {
+			return cfg, err
+		}
+	}
+
+	for _, s := range settings {
+		if v := getenv(s.env); v != "" {
+			if err := set(s, v, s.env); err != nil {
+				return cfg, err
+			}
+		}
+	}
+	for _, apply := range fromFlags {
+		if err := apply(); err != nil {
+			return cfg, err
+		}
+	}
+	return cfg, nil
+}
+
+// loadFile reads a YAML configuration file over cfg. Unknown keys are
+// rejected, so a misspelled setting is not silently ignored.
+func loadFile(cfg *Config, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config file: %w", err)
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// settingsOf lists the settings of a configuration struct and the structs in it.
+func settingsOf(v reflect.Value, prefix string) []setting {
+	var settings []setting
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		key := prefix + field.Tag.Get("yaml")
+		if field.Type.Kind() == reflect.Struct {
+			settings = append(settings, settingsOf(v.Field(i), key+".")...)
+			continue
+		}
+		settings = append(settings, setting{
+			key:   key,
+			env:   field.Tag.Get("env"),
+			flag:  field.Tag.Get("flag"),
+			usage: field.Tag.Get("usage"),
+			value: v.Field(i),
+		})
+	}
+	return settings
+}
+
+// set parses a setting from the text of an environment variable or flag,
+// named by source in errors.
+func set(s setting, text, source string) error {
+	var err error
+	switch s.value.Interface().(type) {
+	case string:
+		s.value.SetString(text)
+	case int:
+		var n int
+		n, err = strconv.Atoi(text)
+		s.value.SetInt(int64(n))
+	case bool:
+		var b bool
+		b, err = strconv.ParseBool(text)
+		s.value.SetBool(b)
+	case time.Duration:
+		var d time.Duration
+		d, err = time.ParseDuration(text)
+		s.value.SetInt(int64(d))
+	default:
+		err = fmt.Errorf("unsupported type %s", s.value.Type())
+	}
+	if err != nil {
+		return fmt.Errorf("%s (%s) %q: %w", source, s.key, text, err)
+	}
+	return nil
+}
+
+// Print writes the configuration as YAML, with its secrets redacted.
+func (c Config) Print(w io.Writer) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(c.Redacted()); err != nil {
+		return err
+	}
+	return enc.Close()
+}
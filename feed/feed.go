@@ -0,0 +1,150 @@
+// Package feed writes JSON Feed (version 1.1) and RSS 2.0 feeds that feed
+// readers can subscribe to.
+package feed
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"time"
+)
+
+// Item is an entry of a feed. URL is absolute and also identifies the entry.
+type Item struct {
+	ID        string
+	URL       string
+	Title     string
+	Summary   string
+	Author    string
+	Published time.Time
+}
+
+// Feed is a list of items, newest first.
+type Feed struct {
+	Title       string
+	Description string
+	HomeURL     string
+	FeedURL     string // Absolute URL of the JSON feed
+	Language    string
+	Items       []Item
+}
+
+type jsonAuthor struct {
+	Name string `json:"name"`
+}
+
+type jsonItem struct {
+	ID            string       `json:"id"`
+	URL           string       `json:"url"`
+	Title         string       `json:"title"`
+	ContentText   string       `json:"content_text"`
+	DatePublished string       `json:"date_published"`
+	Authors       []jsonAuthor `json:"authors,omitempty"`
+}
+
+type jsonFeed struct {
+	Version     string     `json:"version"`
+	Title       string     `json:"title"`
+	Description string     `json:"description,omitempty"`
+	HomePageURL string     `json:"home_page_url"`
+	FeedURL     string     `json:"feed_url"`
+	Language    string     `json:"language,omitempty"`
+	Items       []jsonItem `json:"items"`
+}
+
+// WriteJSON writes the feed as a JSON Feed.
+func (f Feed) WriteJSON(w io.Writer) error {
+	out := jsonFeed{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       f.Title,
+		Description: f.Description,
+		HomePageURL: f.HomeURL,
+		FeedURL:     f.FeedURL,
+		Language:    f.Language,
+		Items:       make([]jsonItem, 0, len(f.Items)),
+	}
+	for _, it := range f.Items {
+		item := jsonItem{
+			ID:            it.ID,
+			URL:           it.URL,
+			Title:         it.Title,
+			ContentText:   it.Summary,
+			DatePublished: it.Published.Format(time.RFC3339),
+		}
+		if it.Author != "" {
+			item.Authors = []jsonAuthor{{Name: it.Author}}
+		}
+		out.Items = append(out.Items, item)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link"`
+	Description string  `xml:"description"`
+	Author      string  `xml:"dc:creator,omitempty"`
+	GUID        rssGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	Language      string    `xml:"language,omitempty"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rss struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	DC      string     `xml:"xmlns:dc,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+// WriteRSS writes the feed as RSS 2.0. RSS has no author name without an
+// e-mail address, so authors are given as Dublin Core creators.
+func (f Feed) WriteRSS(w io.Writer) error {
+	out := rss{
+		Version: "2.0",
+		DC:      "http://purl.org/dc/elements/1.1/",
+		Channel: rssChannel{
+			Title:       f.Title,
+			Link:        f.HomeURL,
+			Description: f.Description,
+			Language:    f.Language,
+		},
+	}
+	if len(f.Items) > 0 {
+		out.Channel.LastBuildDate = f.Items[0].Published.Format(time.RFC1123Z)
+	}
+	for _, it := range f.Items {
+		out.Channel.Items = append(out.Channel.Items, rssItem{
+			Title:       it.Title,
+			Link:        it.URL,
+			Description: it.Summary,
+			Author:      it.Author,
+			GUID:        rssGUID{IsPermaLink: it.ID == it.URL, Value: it.ID},
+			PubDate:     it.Published.Format(time.RFC1123Z),
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(out); err != nil {
+		return err
+	}
+	return enc.Flush()
+}
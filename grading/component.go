@@ -0,0 +1,136 @@
+// Package grading models the grade composition of a syllabus: typed, weighted
+// components with passing thresholds and "best N of M" rules, their validation,
+// and a summary of the resulting grading policy.
+package grading
+
+import (
+	"Syllybea/i18n"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Component types.
+const (
+	TypeExam          = "exam"
+	TypeAssignment    = "assignment"
+	TypeProject       = "project"
+	TypeParticipation = "participation"
+	TypeQuiz          = "quiz"
+)
+
+// TypeRule is a component type with the constraints components of it are held to.
+type TypeRule struct {
+	Key       string
+	Label     string  // In Hebrew, translated through the message catalog
+	MaxWeight float64 // Highest weight allowed, 0 for no limit
+	BestOf    bool    // Whether only the best N of M instances may count
+	MinPass   bool    // Whether a minimum passing score may be required
+}
+
+// Types are the component types, in the order the form offers them.
+var Types = []TypeRule{
+	{Key: TypeExam, Label: "מבחן", MinPass: true},
+	{Key: TypeAssignment, Label: "מטלות", BestOf: true, MinPass: true},
+	{Key: TypeProject, Label: "פרויקט", MinPass: true},
+	{Key: TypeParticipation, Label: "השתתפות", MaxWeight: 10},
+	{Key: TypeQuiz, Label: "בחנים", BestOf: true, MaxWeight: 30},
+}
+
+// Rule returns the rule of a component type.
+func Rule(key string) (TypeRule, bool) {
+	for _, t := range Types {
+		if t.Key == key {
+			return t, true
+		}
+	}
+	return TypeRule{}, false
+}
+
+// Component is one part of the final grade.
+type Component struct {
+	PartName string  `json:"partName"`
+	Type     string  `json:"type,omitempty"`
+	Weight   float64 `json:"weight"`            // Percent of the final grade
+	MinPass  float64 `json:"minPass,omitempty"` // Lowest score (0-100) in the component that passes the course, 0 for none
+	BestOf   int     `json:"bestOf,omitempty"`  // N of "best N of M", 0 when every instance counts
+	OutOf    int     `json:"outOf,omitempty"`   // M of "best N of M"
+}
+
+// UnmarshalJSON reads a component, accepting the free-text "Percentage" weights
+// components were stored with before they were typed.
+func (c *Component) UnmarshalJSON(data []byte) error {
+	type plain Component
+	var v struct {
+		plain
+		Percentage string `json:"percentage"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*c = Component(v.plain)
+	if c.Weight == 0 && v.Percentage != "" {
+		c.Weight, _ = ParseWeight(v.Percentage)
+	}
+	return nil
+}
+
+// ParseWeight reads a percentage such as "30", "30%" or "12.5".
+func ParseWeight(s string) (float64, error) {
+	s = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(s), "%"))
+	if s == "" {
+		return 0, nil
+	}
+	w, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(w) || math.IsInf(w, 0) {
+		return 0, fmt.Errorf("invalid percentage %q", s)
+	}
+	return w, nil
+}
+
+// IsEmpty reports whether the component is a blank row of the form.
+func (c Component) IsEmpty() bool {
+	return strings.TrimSpace(c.PartName) == "" && c.Weight == 0
+}
+
+// TypeLabel is the name of the component type in a locale, or "" when it has none.
+func (c Component) TypeLabel(locale string) string {
+	rule, ok := Rule(c.Type)
+	if !ok {
+		return ""
+	}
+	return i18n.T(locale, rule.Label)
+}
+
+// WeightText is the weight as typed in the form, "" for none.
+func (c Component) WeightText() string {
+	return number(c.Weight)
+}
+
+// MinPassText is the minimum passing score as typed in the form, "" for none.
+func (c Component) MinPassText() string {
+	return number(c.MinPass)
+}
+
+// Rules describes the passing threshold and "best N of M" rule of the
+// component in a locale, e.g. "ציון מינימלי 56; 8 הטובים מתוך 10".
+func (c Component) Rules(locale string) string {
+	var rules []string
+	if c.MinPass > 0 {
+		rules = append(rules, i18n.T(locale, "ציון מינימלי %s", number(c.MinPass)))
+	}
+	if c.BestOf > 0 {
+		rules = append(rules, i18n.T(locale, "%d הטובים מתוך %d", c.BestOf, c.OutOf))
+	}
+	return strings.Join(rules, "; ")
+}
+
+// number formats a weight or score without trailing zeros, "" for zero.
+func number(f float64) string {
+	if f == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
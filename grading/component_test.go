@@ -0,0 +1,26 @@
+package grading
+
+import "testing"
+
+func TestParseWeight(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want float64
+	}{
+		{"", 0},
+		{"30", 30},
+		{" 30% ", 30},
+		{"12.5", 12.5},
+	} {
+		got, err := ParseWeight(tc.in)
+		if err != nil || got != tc.want {
+			t.Errorf("ParseWeight(%q) = %v, %v; want %v", tc.in, got, err, tc.want)
+		}
+	}
+
+	for _, in := range []string{"NaN", "nan%", "Inf", "-Inf", "+Infinity", "1e400", "abc"} {
+		if got, err := ParseWeight(in); err == nil {
+			t.Errorf("ParseWeight(%q) = %v, want an error", in, got)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package grading
+
+import (
+	"Syllybea/i18n"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Total is the sum of the component weights.
+func Total(components []Component) float64 {
+	total := 0.0
+	for _, c := range components {
+		total += c.Weight
+	}
+	return total
+}
+
+// Validate checks a grade composition: it has components, every component is
+// named, typed and weighted within the limits of its type, its rules are ones
+// its type allows, and the weights add up to 100. It returns the problems
+// found, in the given locale.
+func Validate(components []Component, locale string) []string {
+	t := func(message string, args ...interface{}) string {
+		return i18n.T(locale, message, args...)
+	}
+	var problems []string
+	count := 0
+	for i, c := range components {
+		if c.IsEmpty() {
+			continue
+		}
+		count++
+		name := strings.TrimSpace(c.PartName)
+		if name == "" {
+			name = t("רכיב %d", i+1)
+			problems = append(problems, t("%s: חסר שם לרכיב", name))
+		}
+		if c.Weight <= 0 || c.Weight > 100 {
+			problems = append(problems, t("%s: המשקל חייב להיות גדול מ-0 ועד 100", name))
+		}
+
+		rule, ok := Rule(c.Type)
+		if !ok {
+			problems = append(problems, t("%s: יש לבחור סוג רכיב", name))
+			continue
+		}
+		label := t(rule.Label)
+		if rule.MaxWeight > 0 && c.Weight > rule.MaxWeight {
+			problems = append(problems, t("%s: משקל רכיב מסוג %s מוגבל ל-%s%%", name, label, number(rule.MaxWeight)))
+		}
+		if c.MinPass != 0 {
+			if !rule.MinPass {
+				problems = append(problems, t("%s: לא ניתן לדרוש ציון מינימלי ברכיב מסוג %s", name, label))
+			} else if c.MinPass < 0 || c.MinPass > 100 {
+				problems = append(problems, t("%s: הציון המינימלי חייב להיות בין 0 ל-100", name))
+			}
+		}
+		if c.BestOf != 0 || c.OutOf != 0 {
+			if !rule.BestOf {
+				problems = append(problems, t("%s: כלל \"N הטובים מתוך M\" אינו חל על רכיב מסוג %s", name, label))
+			} else if c.BestOf < 1 || c.OutOf <= c.BestOf {
+				problems = append(problems, t("%s: ב\"N הטובים מתוך M\" יש להזין N של 1 לפחות ו-M גדול ממנו", name))
+			}
+		}
+	}
+
+	if count == 0 {
+		return append(problems, t("יש להוסיף לפחות רכיב ציון אחד"))
+	}
+	if total := Total(components); math.Abs(total-100) > 0.001 {
+		problems = append(problems, t("סך משקלי הרכיבים הוא %s%% ולא 100%%", strconv.FormatFloat(total, 'f', -1, 64)))
+	}
+	return problems
+}
+
+// Summary describes the grading policy in a locale, one sentence per
+// component, e.g. "מבחן מסכם (מבחן): 60% מהציון הסופי. נדרש ציון 56 לפחות כדי
+// לעבור את הקורס."
+func Summary(components []Component, locale string) []string {
+	var lines []string
+	for _, c := range components {
+		if c.IsEmpty() {
+			continue
+		}
+		line := strings.TrimSpace(c.PartName)
+		if label := c.TypeLabel(locale); label != "" && label != line {
+			line += " (" + label + ")"
+		}
+		line += ": " + i18n.T(locale, "%s%% מהציון הסופי.", number(c.Weight))
+		if c.BestOf > 0 {
+			line += " " + i18n.T(locale, "ייחשבו %d הציונים הטובים מתוך %d.", c.BestOf, c.OutOf)
+		}
+		if c.MinPass > 0 {
+			line += " " + i18n.T(locale, "נדרש ציון %s לפחות כדי לעבור את הקורס.", number(c.MinPass))
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// Line writes a component as a line of text, "name | weight | type | min=56 | best=8/10",
+// the form department templates hold grade components in.
+func (c Component) Line() string {
+	parts := []string{strings.TrimSpace(c.PartName), number(c.Weight)}
+	if c.Type != "" {
+		parts = append(parts, c.Type)
+	}
+	if c.MinPass > 0 {
+		parts = append(parts, "min="+number(c.MinPass))
+	}
+	if c.BestOf > 0 {
+		parts = append(parts, fmt.Sprintf("best=%d/%d", c.BestOf, c.OutOf))
+	}
+	return strings.Join(parts, " | ")
+}
+
+// ParseLine reads a component written by Line. The type may also be given by
+// its Hebrew label, and anything after the name may be left out.
+func ParseLine(line string) Component {
+	fields := strings.Split(line, "|")
+	c := Component{PartName: strings.TrimSpace(fields[0])}
+	if len(fields) > 1 {
+		c.Weight, _ = ParseWeight(fields[1])
+	}
+	for _, f := range fields[min(len(fields), 2):] {
+		f = strings.TrimSpace(f)
+		switch {
+		case strings.HasPrefix(f, "min="):
+			c.MinPass, _ = strconv.ParseFloat(strings.TrimPrefix(f, "min="), 64)
+		case strings.HasPrefix(f, "best="):
+			n, m, _ := strings.Cut(strings.TrimPrefix(f, "best="), "/")
+			c.BestOf, _ = strconv.Atoi(strings.TrimSpace(n))
+			c.OutOf, _ = strconv.Atoi(strings.TrimSpace(m))
+		default:
+			for _, t := range Types {
+				if f == t.Key || f == t.Label {
+					c.Type = t.Key
+				}
+			}
+		}
+	}
+	return c
+}
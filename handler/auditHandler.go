@@ -0,0 +1,179 @@
+package handler
+
+import (
+	"Syllybea/UIcomponents"
+	"Syllybea/reports"
+	"Syllybea/repository"
+	"Syllybea/types"
+	"encoding/json"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// auditPageLimit caps the entries shown on the page; the CSV export is not capped.
+const auditPageLimit = 500
+
+// auditEntityTypes are the entity types offered in the audit page filter.
+var auditEntityTypes = []string{"syllabus", "comment", "template", "course", "department", "user", "offering", "term", "holiday", "program_outcome", "workload_norm"}
+
+// auditPageData is the data rendered by the "audit-page" template.
+type auditPageData struct {
+	Header      UIcomponents.HeaderData
+	Filter      repository.AuditFilter
+	Users       []types.User
+	Actions     []string
+	EntityTypes []string
+	Rows        []UIcomponents.AuditRow
+	ExportQuery string
+}
+
+// auditFilterFromQuery reads the audit filters from the query string.
+func auditFilterFromQuery(c echo.Context) repository.AuditFilter {
+	f := repository.AuditFilter{
+		Action:     c.QueryParam("action"),
+		EntityType: c.QueryParam("entity"),
+		FromDate:   c.QueryParam("from"),
+		ToDate:     c.QueryParam("to"),
+	}
+	f.ActorID, _ = strconv.Atoi(c.QueryParam("actor"))
+	f.EntityID, _ = strconv.Atoi(c.QueryParam("entity_id"))
+	return f
+}
+
+// handleAuditPage lets managers browse and filter the audit log.
+func handleAuditPage(c echo.Context, repo *repository.Repository) error {
+	user, err := requireManager(c, repo)
+	if user == nil {
+		return err
+	}
+
+	filter := auditFilterFromQuery(c)
+	filter.Limit = auditPageLimit
+	entries, err := repo.GetAuditLog(filter)
+	if err != nil {
+		c.Logger().Error("GetAuditLog error:", err)
+		return c.String(http.StatusInternalServerError, "Error fetching audit log")
+	}
+
+	users, err := repo.GetAllUsers()
+	if err != nil {
+		c.Logger().Error("GetAllUsers error:", err)
+		return c.String(http.StatusInternalServerError, "Error fetching users")
+	}
+	actions, err := repo.GetAuditActions()
+	if err != nil {
+		c.Logger().Error("GetAuditActions error:", err)
+		return c.String(http.StatusInternalServerError, "Error fetching audit log")
+	}
+
+	rows := make([]UIcomponents.AuditRow, 0, len(entries))
+	for _, e := range entries {
+		rows = append(rows, auditRow(c, e))
+	}
+
+	data := auditPageData{
+		Header:      UIcomponents.HeaderData{Title: "Audit", Name: user.Name, Role: user.Role},
+		Filter:      filter,
+		Users:       users,
+		Actions:     actions,
+		EntityTypes: auditEntityTypes,
+		Rows:        rows,
+		ExportQuery: c.QueryString(),
+	}
+	return c.Render(http.StatusOK, "audit-page", data)
+}
+
+// handleAuditCSV exports the filtered audit log as CSV.
+func handleAuditCSV(c echo.Context, repo *repository.Repository) error {
+	user, err := requireManager(c, repo)
+	if user == nil {
+		return err
+	}
+
+	entries, err := repo.GetAuditLog(auditFilterFromQuery(c))
+	if err != nil {
+		c.Logger().Error("GetAuditLog error:", err)
+		return c.String(http.StatusInternalServerError, "Error fetching audit log")
+	}
+
+	table := reports.Table{
+		Name:    "audit-log",
+		Columns: []string{"id", "timestamp", "actor_id", "actor", "action", "entity_type", "entity_id", "ip", "changes"},
+	}
+	for _, e := range entries {
+		table.Rows = append(table.Rows, []string{
+			strconv.Itoa(e.ID),
+			e.CreatedAt.Format("2006-01-02 15:04:05"),
+			strconv.Itoa(e.ActorID),
+			e.ActorName,
+			e.Action,
+			e.EntityType,
+			strconv.Itoa(e.EntityID),
+			e.IP,
+			string(e.Changes),
+		})
+	}
+
+	c.Response().Header().Set(echo.HeaderContentType, "text/csv; charset=utf-8")
+	c.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="audit-log.csv"`)
+	c.Response().WriteHeader(http.StatusOK)
+	return table.WriteCSV(c.Response())
+}
+
+// auditRow converts an audit entry into its display form, with the changed
+// fields sorted by name.
+func auditRow(c echo.Context, e types.AuditEntry) UIcomponents.AuditRow {
+	row := UIcomponents.AuditRow{
+		ID:         e.ID,
+		Date:       e.CreatedAt.Format("02/01/2006 15:04"),
+		Actor:      e.ActorName,
+		Action:     e.Action,
+		EntityType: e.EntityType,
+		EntityID:   e.EntityID,
+		IP:         e.IP,
+	}
+	if row.Actor == "" {
+		row.Actor = tr(c, "מערכת")
+	}
+
+	var changes map[string]struct {
+		Before interface{} `json:"before"`
+		After  interface{} `json:"after"`
+	}
+	if err := json.Unmarshal(e.Changes, &changes); err != nil {
+		return row
+	}
+	for field, ch := range changes {
+		row.Changes = append(row.Changes, UIcomponents.AuditChange{
+			Field:  field,
+			Before: auditValue(ch.Before),
+			After:  auditValue(ch.After),
+		})
+	}
+	sort.Slice(row.Changes, func(i, j int) bool { return row.Changes[i].Field < row.Changes[j].Field })
+	return row
+}
+
+// auditValue formats a recorded JSON value for display.
+func auditValue(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case []interface{}:
+		parts := make([]string, 0, len(val))
+		for _, item := range val {
+			parts = append(parts, auditValue(item))
+		}
+		return strings.Join(parts, "; ")
+	case map[string]interface{}:
+		b, _ := json.Marshal(val)
+		return string(b)
+	}
+	return fmt.Sprint(v)
+}
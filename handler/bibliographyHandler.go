@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"Syllybea/bibliography"
+	"Syllybea/repository"
+	"bufio"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"net/http"
+)
+
+// handleBibliographyExport serves the bibliography of a syllabus as BibTeX
+// ("bib"), RIS ("ris") or plain text in the citation style given by the "style"
+// query parameter ("txt"). The required entries come before the recommended ones,
+// under headings in the language given by the "lang" query parameter, else in
+// the language the syllabus is written in.
+func handleBibliographyExport(c echo.Context, repo *repository.Repository, format string) error {
+	syl, err := loadExport(c, repo)
+	if syl == nil {
+		return err
+	}
+	draft := syl.Draft
+	applyLanguage(c, draft, draft.Language)
+	entries := append(append([]bibliography.Entry{}, draft.BibliographyRequired...), draft.BibliographyRecommended...)
+
+	contentType := "text/plain; charset=utf-8"
+	switch format {
+	case "bib":
+		contentType = "application/x-bibtex; charset=utf-8"
+	case "ris":
+		contentType = "application/x-research-info-systems; charset=utf-8"
+	}
+	c.Response().Header().Set(echo.HeaderContentType, contentType)
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="syllabus-%d-bibliography.%s"`, syl.ID, format))
+	c.Response().WriteHeader(http.StatusOK)
+
+	switch format {
+	case "bib":
+		return bibliography.WriteBibTeX(c.Response(), entries)
+	case "ris":
+		return bibliography.WriteRIS(c.Response(), entries)
+	}
+
+	style := c.QueryParam("style")
+	if style == "" {
+		style = draft.CitationStyle
+	}
+	w := bufio.NewWriter(c.Response())
+	for _, section := range []struct {
+		title   string
+		entries []bibliography.Entry
+	}{
+		{"קריאת חובה", draft.BibliographyRequired},
+		{"קריאת רשות", draft.BibliographyRecommended},
+	} {
+		if len(section.entries) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "%s\n\n", draft.Label(section.title))
+		for _, e := range section.entries {
+			if !e.IsEmpty() {
+				fmt.Fprintf(w, "%s\n", e.Format(style))
+			}
+		}
+		fmt.Fprintln(w)
+	}
+	return w.Flush()
+}
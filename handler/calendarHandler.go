@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"Syllybea/UIcomponents"
+	"Syllybea/ical"
+	"Syllybea/repository"
+	"Syllybea/utils"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// calendarUIDDomain qualifies event UIDs so they are unique across calendars.
+const calendarUIDDomain = "syllybea"
+
+// handleSyllabusCalendar serves a syllabus as an iCalendar feed: the office hours
+// as a weekly event and every dated lesson as an all-day event.
+func handleSyllabusCalendar(c echo.Context, repo *repository.Repository) error {
+	syl, err := loadExport(c, repo)
+	if syl == nil {
+		return err
+	}
+	draft := syl.Draft
+
+	// The term bounds the weekly office hours; without one they start from the syllabus creation.
+	from, until := syl.CreatedAt, time.Time{}
+	if syl.TermID != 0 {
+		if term, err := repo.GetTermByID(syl.TermID); err == nil {
+			from = term.StartDate
+			until = endOfDay(term.EndDate)
+		}
+	}
+
+	applyLanguage(c, draft, draft.Language)
+	cal := ical.Calendar{
+		Name:    draft.Label("סילבוס") + ": " + draft.Title(),
+		Updated: syl.UpdatedAt,
+	}
+	if e, ok := officeHoursEvent(syl.ID, draft, from, until); ok {
+		cal.Events = append(cal.Events, e)
+	}
+	cal.Events = append(cal.Events, lessonEvents(syl.ID, draft)...)
+
+	c.Response().Header().Set(echo.HeaderContentType, "text/calendar; charset=utf-8")
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`inline; filename="syllabus-%d.ics"`, syl.ID))
+	c.Response().WriteHeader(http.StatusOK)
+	return cal.Write(c.Response())
+}
+
+// officeHoursEvent builds the weekly office hours event, starting on the first
+// office day on or after from. It reports false when the office hours are incomplete.
+func officeHoursEvent(syllabusID int, d *UIcomponents.Draft, from, until time.Time) (ical.Event, bool) {
+	day, ok := utils.ParseHebrewWeekday(d.OfficeDay)
+	if !ok {
+		return ical.Event{}, false
+	}
+	startClock, err := time.Parse("15:04", d.OfficeStart)
+	if err != nil {
+		return ical.Event{}, false
+	}
+	endClock, err := time.Parse("15:04", d.OfficeEnd)
+	if err != nil || !endClock.After(startClock) {
+		endClock = startClock.Add(time.Hour)
+	}
+
+	first := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	for first.Weekday() != day {
+		first = first.AddDate(0, 0, 1)
+	}
+	at := func(clock time.Time) time.Time {
+		return first.Add(time.Duration(clock.Hour())*time.Hour + time.Duration(clock.Minute())*time.Minute)
+	}
+
+	return ical.Event{
+		UID:         fmt.Sprintf("syllabus-%d-office-hours@%s", syllabusID, calendarUIDDomain),
+		Summary:     d.Label("שעות קבלה") + ": " + d.Title(),
+		Description: strings.TrimSpace(d.LecturerName),
+		Start:       at(startClock),
+		End:         at(endClock),
+		Weekly:      true,
+		Until:       until,
+	}, true
+}
+
+// lessonEvents builds an all-day event for every dated lesson. The UID is keyed
+// by the lesson date, so editing or inserting rows updates the existing events.
+func lessonEvents(syllabusID int, d *UIcomponents.Draft) []ical.Event {
+	var events []ical.Event
+	for _, row := range d.SyllabusRows {
+		date, err := time.Parse("2006-01-02", row.Date)
+		if err != nil {
+			continue
+		}
+
+		summary := d.Title()
+		if n := strings.TrimSpace(row.LessonNumber); n != "" {
+			summary += " – " + d.Label("שיעור") + " " + n
+		}
+		if row.MainTopic != "" {
+			summary += ": " + row.MainTopic
+		}
+
+		var description []string
+		if row.Holiday != "" {
+			description = append(description, d.Label("שימו לב: השיעור חל ב%s", row.Holiday))
+		}
+		for _, part := range []struct{ label, value string }{
+			{"נושאי השיעור", row.LessonTopics},
+			{"פירוט", row.Subtopics},
+			{"לקריאה", row.ReadingMaterial},
+		} {
+			if strings.TrimSpace(part.value) != "" {
+				description = append(description, d.Label(part.label)+": "+part.value)
+			}
+		}
+
+		events = append(events, ical.Event{
+			UID:         fmt.Sprintf("syllabus-%d-lesson-%s@%s", syllabusID, date.Format("20060102"), calendarUIDDomain),
+			Summary:     summary,
+			Description: strings.Join(description, "\n"),
+			Start:       date,
+			End:         date.AddDate(0, 0, 1),
+			AllDay:      true,
+		})
+	}
+	return events
+}
+
+// endOfDay returns the last second of the day of t in Israel time.
+func endOfDay(t time.Time) time.Time {
+	loc, err := time.LoadLocation(ical.TimeZone)
+	if err != nil {
+		loc = time.FixedZone("IST", 2*60*60)
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), 23, 59, 59, 0, loc)
+}
@@ -30,8 +30,8 @@ func handleGetCommentsOfSyllabus(c echo.Context, r *repository.Repository) error
 
 	// Add a default server message as the first comment.
 	serverComment := UIcomponents.Comments{
-		Name:          "מכללת כנרת",
-		Message:       "זו היא מערכת ההודעות של Syllabea",
+		Name:          tr(c, "מכללת כנרת"),
+		Message:       tr(c, "זו היא מערכת ההודעות של Syllabea"),
 		Time:          time.Now().Format("2006-01-02 15:04"),
 		IsCurrentUser: false,
 	}
@@ -76,6 +76,7 @@ func handleAddComment(c echo.Context, r *repository.Repository) error {
 	if err != nil {
 		return c.HTML(http.StatusBadRequest, "<div class='error-message'>Invalid syllabus ID</div>")
 	}
+	mid.LogSyllabus(c, sylID)
 
 	// Get comment content from the form
 	content := c.FormValue("content")
@@ -0,0 +1,227 @@
+package handler
+
+import (
+	"Syllybea/UIcomponents"
+	"Syllybea/mid"
+	"Syllybea/repository"
+	"Syllybea/types"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// catalogPageData is the data rendered by the "catalog-page" template.
+type catalogPageData struct {
+	Header  UIcomponents.HeaderData
+	Term    *types.Term
+	Terms   []UIcomponents.TermOption // Choices of the term picker
+	Courses []UIcomponents.CourseRow
+	Missing int
+}
+
+// coursePageData is the data rendered by the "course-page" template.
+type coursePageData struct {
+	Header     UIcomponents.HeaderData
+	Course     *types.Course
+	Department string
+	Offerings  []UIcomponents.OfferingView
+	Lecturers  []types.User // Choices for a new offering (managers only)
+	Term       *types.Term  // Default term of a new offering
+	Terms      []UIcomponents.TermOption
+	Error      string
+
+	Prerequisites       []types.Course // Courses this one requires
+	Dependents          []types.Course // Courses that require this one
+	PrerequisiteOptions []types.Course // Choices for a new prerequisite (managers only)
+}
+
+// currentUser returns the logged-in user, or writes the login redirect and returns nil.
+func currentUser(c echo.Context, repo *repository.Repository) (*types.User, error) {
+	userID, err := mid.GetUserID(c)
+	if err != nil {
+		c.Response().Header().Set("HX-Redirect", "/login")
+		return nil, c.String(http.StatusOK, "Redirecting to login page...")
+	}
+	user, err := repo.GetUserByID(userID)
+	if err != nil {
+		c.Logger().Error("GetUserByID error:", err)
+		return nil, c.String(http.StatusInternalServerError, "Error retrieving user data")
+	}
+	return user, nil
+}
+
+// termFromQuery reads the term query parameter, defaulting to the current term.
+func termFromQuery(c echo.Context, repo *repository.Repository) (*types.Term, error) {
+	if termID, err := strconv.Atoi(c.QueryParam("term")); err == nil {
+		if term, err := repo.GetTermByID(termID); err == nil {
+			return term, nil
+		}
+	}
+	return repo.CurrentTerm(time.Now())
+}
+
+// handleCatalogPage lists all courses and flags those offered in the selected
+// term that have no syllabus yet.
+func handleCatalogPage(c echo.Context, repo *repository.Repository) error {
+	user, err := currentUser(c, repo)
+	if user == nil {
+		return err
+	}
+
+	term, err := termFromQuery(c, repo)
+	if err != nil {
+		c.Logger().Error("termFromQuery error:", err)
+		return c.String(http.StatusInternalServerError, "Error fetching terms")
+	}
+	terms, err := repo.GetTermOptions()
+	if err != nil {
+		c.Logger().Error("GetTermOptions error:", err)
+		return c.String(http.StatusInternalServerError, "Error fetching terms")
+	}
+	courses, err := repo.GetCourseCatalog(term.ID)
+	if err != nil {
+		c.Logger().Error("GetCourseCatalog error:", err)
+		return c.String(http.StatusInternalServerError, "Error fetching courses")
+	}
+
+	data := catalogPageData{
+		Header:  UIcomponents.HeaderData{Title: "Courses", Name: user.Name, Role: user.Role},
+		Term:    term,
+		Terms:   terms,
+		Courses: courses,
+	}
+	for _, course := range courses {
+		if course.Missing {
+			data.Missing++
+		}
+	}
+	return c.Render(http.StatusOK, "catalog-page", data)
+}
+
+// handleCoursePage shows a course with all its offerings and their syllabi.
+func handleCoursePage(c echo.Context, repo *repository.Repository) error {
+	user, err := currentUser(c, repo)
+	if user == nil {
+		return err
+	}
+	return renderCoursePage(c, repo, user, "")
+}
+
+// handleCreateOffering adds an offering (term and lecturers) to a course (managers only).
+func handleCreateOffering(c echo.Context, repo *repository.Repository) error {
+	user, err := requireManager(c, repo)
+	if user == nil {
+		return err
+	}
+
+	courseID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid course ID")
+	}
+	if err := c.Request().ParseForm(); err != nil {
+		return c.String(http.StatusBadRequest, "Invalid form")
+	}
+
+	termID, err := strconv.Atoi(c.FormValue("term"))
+	if err != nil {
+		return renderCoursePage(c, repo, user, tr(c, "יש לבחור סמסטר"))
+	}
+	if _, err := repo.GetTermByID(termID); err != nil {
+		return renderCoursePage(c, repo, user, tr(c, "הסמסטר שנבחר אינו קיים"))
+	}
+
+	offeringID, err := repo.EnsureOffering(courseID, termID, strings.TrimSpace(c.FormValue("section")), 0)
+	if err != nil {
+		c.Logger().Error("EnsureOffering error:", err)
+		return c.String(http.StatusInternalServerError, "Error creating offering")
+	}
+	for _, idStr := range c.Request().Form["lecturers[]"] {
+		lecturerID, err := strconv.Atoi(idStr)
+		if err != nil {
+			continue
+		}
+		if err := repo.AddOfferingLecturer(offeringID, lecturerID); err != nil {
+			c.Logger().Error("AddOfferingLecturer error:", err)
+			return c.String(http.StatusInternalServerError, "Error creating offering")
+		}
+	}
+
+	return renderCoursePage(c, repo, user, "")
+}
+
+func renderCoursePage(c echo.Context, repo *repository.Repository, user *types.User, errMsg string) error {
+	courseID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid course ID")
+	}
+	course, err := repo.GetCourseByID(courseID)
+	if err != nil {
+		c.Logger().Error("GetCourseByID error:", err)
+		return c.String(http.StatusNotFound, "Course not found")
+	}
+	department, err := repo.GetDepartmentByID(course.DepartmentID)
+	if err != nil {
+		c.Logger().Error("GetDepartmentByID error:", err)
+		return c.String(http.StatusInternalServerError, "Error fetching course")
+	}
+	offerings, err := repo.GetCourseOfferings(courseID)
+	if err != nil {
+		c.Logger().Error("GetCourseOfferings error:", err)
+		return c.String(http.StatusInternalServerError, "Error fetching offerings")
+	}
+
+	term, err := termFromQuery(c, repo)
+	if err != nil {
+		c.Logger().Error("termFromQuery error:", err)
+		return c.String(http.StatusInternalServerError, "Error fetching terms")
+	}
+	terms, err := repo.GetTermOptions()
+	if err != nil {
+		c.Logger().Error("GetTermOptions error:", err)
+		return c.String(http.StatusInternalServerError, "Error fetching terms")
+	}
+	data := coursePageData{
+		Header:     UIcomponents.HeaderData{Title: course.Name, Name: user.Name, Role: user.Role},
+		Course:     course,
+		Department: department.Name,
+		Offerings:  offerings,
+		Term:       term,
+		Terms:      terms,
+		Error:      errMsg,
+	}
+	if data.Prerequisites, err = repo.GetCoursePrerequisites(courseID); err != nil {
+		c.Logger().Error("GetCoursePrerequisites error:", err)
+		return c.String(http.StatusInternalServerError, "Error fetching prerequisites")
+	}
+	if data.Dependents, err = repo.GetDependentCourses(courseID); err != nil {
+		c.Logger().Error("GetDependentCourses error:", err)
+		return c.String(http.StatusInternalServerError, "Error fetching prerequisites")
+	}
+	if user.Role == "Manager" {
+		if data.Lecturers, err = repo.GetAllUsers(); err != nil {
+			c.Logger().Error("GetAllUsers error:", err)
+			return c.String(http.StatusInternalServerError, "Error fetching lecturers")
+		}
+		courses, err := repo.GetAllCourses()
+		if err != nil {
+			c.Logger().Error("GetAllCourses error:", err)
+			return c.String(http.StatusInternalServerError, "Error fetching courses")
+		}
+		required := map[int]bool{courseID: true}
+		for _, p := range data.Prerequisites {
+			required[p.ID] = true
+		}
+		for _, other := range courses {
+			if !required[other.ID] {
+				data.PrerequisiteOptions = append(data.PrerequisiteOptions, other)
+			}
+		}
+		sort.Slice(data.PrerequisiteOptions, func(i, j int) bool {
+			return data.PrerequisiteOptions[i].Name < data.PrerequisiteOptions[j].Name
+		})
+	}
+	return c.Render(http.StatusOK, "course-page", data)
+}
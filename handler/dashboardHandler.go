@@ -2,9 +2,10 @@ package handler
 
 import (
 	"Syllybea/UIcomponents"
+	"Syllybea/i18n"
 	"Syllybea/mid"
 	"Syllybea/repository"
-	"Syllybea/utils"
+	"Syllybea/status"
 	"github.com/labstack/echo/v4"
 	"net/http"
 	"sort"
@@ -16,12 +17,12 @@ func handleHome(c echo.Context) error {
 	_, err := mid.GetUserID(c)
 	if err != nil {
 		// Redirect to login
-		c.Logger().Info("Redirecting to /login")
+		c.Logger().Debug("Redirecting to /login")
 		return c.Redirect(http.StatusSeeOther, "/login")
 	}
 
 	// Redirect to dashboard
-	c.Logger().Info("Redirecting to /dashboard")
+	c.Logger().Debug("Redirecting to /dashboard")
 	return c.Redirect(http.StatusSeeOther, "/dashboard")
 }
 
@@ -43,7 +44,7 @@ func handleDashboard(c echo.Context, repo *repository.Repository) error {
 	}
 
 	// Fetch cards from repository.
-	c.Logger().Info("Fetching cards for lecturer...")
+	c.Logger().Debug("Fetching cards for lecturer...")
 	// Now rawCards is of type []UIcomponents.Card
 	rawCards, err := repo.GetCardsByLecturer(userID)
 	if err != nil {
@@ -51,7 +52,6 @@ func handleDashboard(c echo.Context, repo *repository.Repository) error {
 	}
 
 	// Group cards by month/year.
-	groupStart := time.Now()
 	cardsByMonth := make(map[string][]UIcomponents.Card)
 	monthOrder := make(map[string]time.Time)
 	total, attempts, inReview, approved := 0, 0, 0, 0
@@ -64,8 +64,7 @@ func handleDashboard(c echo.Context, repo *repository.Repository) error {
 			c.Logger().Warn("Skipping card due to date parse error:", err)
 			continue
 		}
-		monthLabel := utils.HebrewMonths[parsedDate.Month()]
-		monthYearKey := monthLabel + " " + strconv.Itoa(parsedDate.Year())
+		monthYearKey := i18n.MonthYear(mid.GetLocale(c), parsedDate)
 
 		// Append the card (which already contains the ID, Title, Lecturer, Field, etc.)
 		cardsByMonth[monthYearKey] = append(cardsByMonth[monthYearKey], card)
@@ -77,19 +76,17 @@ func handleDashboard(c echo.Context, repo *repository.Repository) error {
 
 		total++
 		switch card.Status {
-		case "Draft":
+		case status.Draft:
 			attempts++
-		case "In Review":
+		case status.InReview:
 			inReview++
-		case "Approved":
+		case status.Approved:
 			approved++
 		}
 	}
-	c.Logger().Infof("Grouped cards by month in %s", time.Since(groupStart))
-	c.Logger().Infof("Total cards processed: %d; Draft: %d, In Review: %d, Approved: %d", total, attempts, inReview, approved)
+	c.Logger().Debugf("Total cards processed: %d; Draft: %d, In Review: %d, Approved: %d", total, attempts, inReview, approved)
 
 	// Build sorted date sections.
-	sortStart := time.Now()
 	var dateSections []UIcomponents.DateSection
 	for key, cards := range cardsByMonth {
 		dateSections = append(dateSections, UIcomponents.DateSection{
@@ -100,11 +97,17 @@ func handleDashboard(c echo.Context, repo *repository.Repository) error {
 	sort.Slice(dateSections, func(i, j int) bool {
 		return monthOrder[dateSections[i].DateLabel].After(monthOrder[dateSections[j].DateLabel])
 	})
-	c.Logger().Infof("Sorted date sections in %s", time.Since(sortStart))
+
+	terms, err := repo.GetTermOptions()
+	if err != nil {
+		c.Logger().Error("GetTermOptions error:", err)
+		return c.String(http.StatusInternalServerError, "Error fetching terms")
+	}
 
 	header := UIcomponents.HeaderData{
 		Title: "Dashboard",
 		Name:  user.Name,
+		Role:  user.Role,
 	}
 	content := UIcomponents.CoursesData{
 		Total:        total,
@@ -112,6 +115,7 @@ func handleDashboard(c echo.Context, repo *repository.Repository) error {
 		InReview:     inReview,
 		Approved:     approved,
 		DateSections: dateSections,
+		Terms:        terms,
 	}
 	pageData := UIcomponents.PageData{
 		Header:  header,
@@ -133,23 +137,11 @@ func filterCards(c echo.Context, repo *repository.Repository) error {
 	search := c.FormValue("search")
 	fromDate := c.FormValue("from-date")
 	toDate := c.FormValue("to-date")
-	statuses := c.Request().Form["status"]
-
-	// Map Hebrew statuses to English.
-	englishStatuses := []string{}
-	for _, s := range statuses {
-		switch s {
-		case "סילבוס":
-			englishStatuses = append(englishStatuses, "Draft")
-		case "בבחינה":
-			englishStatuses = append(englishStatuses, "In Review")
-		case "מאושר":
-			englishStatuses = append(englishStatuses, "Approved")
-		}
-	}
+	termID, _ := strconv.Atoi(c.FormValue("term"))
+	statuses := status.ParseList(c.Request().Form["status"])
 
 	// Get filtered cards.
-	rawCards, err := repo.FilterCardsByLecturer(userID, search, fromDate, toDate, englishStatuses)
+	rawCards, err := repo.FilterCardsByLecturer(userID, search, fromDate, toDate, termID, statuses)
 	if err != nil {
 		c.Logger().Error("FilterCardsByLecturer error:", err)
 		return c.String(http.StatusInternalServerError, "Error fetching filtered cards")
@@ -165,25 +157,23 @@ func filterCards(c echo.Context, repo *repository.Repository) error {
 		title, _ := cardMap["title"].(string)
 		lecturer, _ := cardMap["lecturer"].(string)
 		field, _ := cardMap["field"].(string)
-		status, _ := cardMap["status"].(string)
+		st, _ := cardMap["status"].(status.Status)
 
 		parsedDate, err := time.Parse("02/01/2006", dateStr)
 		if err != nil {
 			continue
 		}
 
-		monthLabel := utils.HebrewMonths[parsedDate.Month()]
-		monthYearKey := monthLabel + " " + strconv.Itoa(parsedDate.Year())
+		monthYearKey := i18n.MonthYear(mid.GetLocale(c), parsedDate)
 
 		id, _ := cardMap["id"].(int) // You need this line!
 		card := UIcomponents.Card{
-			ID:          id,
-			Title:       title,
-			Date:        dateStr,
-			Lecturer:    lecturer,
-			Field:       field,
-			Status:      status,
-			StatusLabel: status,
+			ID:       id,
+			Title:    title,
+			Date:     dateStr,
+			Lecturer: lecturer,
+			Field:    field,
+			Status:   st,
 		}
 
 		cardsByMonth[monthYearKey] = append(cardsByMonth[monthYearKey], card)
@@ -193,12 +183,12 @@ func filterCards(c echo.Context, repo *repository.Repository) error {
 		}
 
 		total++
-		switch status {
-		case "Draft":
+		switch st {
+		case status.Draft:
 			attempts++
-		case "In Review":
+		case status.InReview:
 			inReview++
-		case "Approved":
+		case status.Approved:
 			approved++
 		}
 	}
@@ -227,18 +217,10 @@ func handleDeleteSyllabus(c echo.Context, repo *repository.Repository) error {
 		return c.String(http.StatusBadRequest, "Invalid syllabus ID")
 	}
 
-	// Get the syllabus from the database
-	syl, err := repo.GetSyllabusByID(syllabusID)
+	// Move the syllabus to the trash, keeping its status for a later restore
+	err = repo.TrashSyllabus(syllabusID)
 	if err != nil {
-		c.Logger().Error("Error retrieving syllabus:", err)
-		return c.String(http.StatusInternalServerError, "Error retrieving syllabus")
-	}
-
-	// Update the syllabus status to "Deleted"
-	syl.Status = "Deleted"
-	err = repo.UpdateSyllabus(syl)
-	if err != nil {
-		c.Logger().Error("Error updating syllabus status:", err)
+		c.Logger().Error("Error moving syllabus to trash:", err)
 		return c.String(http.StatusInternalServerError, "Error updating syllabus status")
 	}
 
@@ -264,14 +246,13 @@ func handleTrashPage(c echo.Context, repo *repository.Repository) error {
 	}
 
 	// Fetch deleted cards from repository.
-	c.Logger().Info("Fetching deleted cards for lecturer...")
+	c.Logger().Debug("Fetching deleted cards for lecturer...")
 	rawCards, err := repo.GetDeletedCardsByLecturer(userID)
 	if err != nil {
 		return c.String(http.StatusInternalServerError, "Error fetching deleted cards")
 	}
 
 	// Group cards by month/year.
-	groupStart := time.Now()
 	cardsByMonth := make(map[string][]UIcomponents.Card)
 	monthOrder := make(map[string]time.Time)
 	total := len(rawCards)
@@ -283,8 +264,7 @@ func handleTrashPage(c echo.Context, repo *repository.Repository) error {
 			c.Logger().Warn("Skipping card due to date parse error:", err)
 			continue
 		}
-		monthLabel := utils.HebrewMonths[parsedDate.Month()]
-		monthYearKey := monthLabel + " " + strconv.Itoa(parsedDate.Year())
+		monthYearKey := i18n.MonthYear(mid.GetLocale(c), parsedDate)
 
 		// Append the card (which already contains the ID, Title, Lecturer, Field, etc.)
 		cardsByMonth[monthYearKey] = append(cardsByMonth[monthYearKey], card)
@@ -294,11 +274,9 @@ func handleTrashPage(c echo.Context, repo *repository.Repository) error {
 			monthOrder[monthYearKey] = time.Date(parsedDate.Year(), parsedDate.Month(), 1, 0, 0, 0, 0, time.UTC)
 		}
 	}
-	c.Logger().Infof("Grouped deleted cards by month in %s", time.Since(groupStart))
-	c.Logger().Infof("Total deleted cards processed: %d", total)
+	c.Logger().Debugf("Total deleted cards processed: %d", total)
 
 	// Build sorted date sections.
-	sortStart := time.Now()
 	var dateSections []UIcomponents.DateSection
 	for key, cards := range cardsByMonth {
 		dateSections = append(dateSections, UIcomponents.DateSection{
@@ -309,11 +287,11 @@ func handleTrashPage(c echo.Context, repo *repository.Repository) error {
 	sort.Slice(dateSections, func(i, j int) bool {
 		return monthOrder[dateSections[i].DateLabel].After(monthOrder[dateSections[j].DateLabel])
 	})
-	c.Logger().Infof("Sorted date sections in %s", time.Since(sortStart))
 
 	header := UIcomponents.HeaderData{
 		Title: "Trash",
 		Name:  user.Name,
+		Role:  user.Role,
 	}
 	content := UIcomponents.CoursesData{
 		Total:        total,
@@ -330,8 +308,15 @@ func handleTrashPage(c echo.Context, repo *repository.Repository) error {
 	return c.Render(http.StatusOK, "trash-page", pageData)
 }
 
-// handlePermanentDeleteSyllabus permanently deletes a syllabus from the database
+// handlePermanentDeleteSyllabus permanently deletes a syllabus of the user
+// from the database. Only syllabi in the trash can be deleted for good.
 func handlePermanentDeleteSyllabus(c echo.Context, repo *repository.Repository) error {
+	userID, err := mid.GetUserID(c)
+	if err != nil {
+		c.Response().Header().Set("HX-Redirect", "/login")
+		return c.String(http.StatusOK, "Redirecting to login page...")
+	}
+
 	// Get the syllabus ID from the URL parameter
 	syllabusID, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
@@ -339,6 +324,18 @@ func handlePermanentDeleteSyllabus(c echo.Context, repo *repository.Repository)
 		return c.String(http.StatusBadRequest, "Invalid syllabus ID")
 	}
 
+	syl, err := repo.GetSyllabusByID(syllabusID)
+	if err != nil {
+		c.Logger().Error("Error retrieving syllabus:", err)
+		return c.String(http.StatusNotFound, "Syllabus not found")
+	}
+	if syl.LecturerID != userID {
+		return c.String(http.StatusForbidden, "Not your syllabus")
+	}
+	if syl.Status != status.Deleted {
+		return c.String(http.StatusConflict, "Only syllabi in the trash can be deleted permanently")
+	}
+
 	// Delete the syllabus from the database
 	err = repo.DeleteSyllabus(syllabusID)
 	if err != nil {
@@ -349,3 +346,56 @@ func handlePermanentDeleteSyllabus(c echo.Context, repo *repository.Repository)
 	// Return an empty response to indicate success (the card will be removed from the UI)
 	return c.NoContent(http.StatusOK)
 }
+
+// handleRestoreSyllabus takes a syllabus out of the trash with its previous status
+func handleRestoreSyllabus(c echo.Context, repo *repository.Repository) error {
+	userID, err := mid.GetUserID(c)
+	if err != nil {
+		c.Response().Header().Set("HX-Redirect", "/login")
+		return c.String(http.StatusOK, "Redirecting to login page...")
+	}
+
+	syllabusID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Logger().Error("Invalid syllabus ID:", err)
+		return c.String(http.StatusBadRequest, "Invalid syllabus ID")
+	}
+
+	syl, err := repo.GetSyllabusByID(syllabusID)
+	if err != nil {
+		c.Logger().Error("Error retrieving syllabus:", err)
+		return c.String(http.StatusNotFound, "Syllabus not found")
+	}
+	if syl.LecturerID != userID {
+		return c.String(http.StatusForbidden, "Not your syllabus")
+	}
+	if syl.Status != status.Deleted {
+		return c.String(http.StatusConflict, "Only syllabi in the trash can be restored")
+	}
+
+	if _, err := repo.RestoreSyllabus(syllabusID); err != nil {
+		c.Logger().Error("Error restoring syllabus:", err)
+		return c.String(http.StatusInternalServerError, "Error restoring syllabus")
+	}
+
+	// Return an empty response so the card is removed from the trash
+	return c.NoContent(http.StatusOK)
+}
+
+// handleEmptyTrash permanently deletes everything in the user's trash and re-renders the trash page
+func handleEmptyTrash(c echo.Context, repo *repository.Repository) error {
+	userID, err := mid.GetUserID(c)
+	if err != nil {
+		c.Response().Header().Set("HX-Redirect", "/login")
+		return c.String(http.StatusOK, "Redirecting to login page...")
+	}
+
+	n, err := repo.EmptyTrash(userID)
+	if err != nil {
+		c.Logger().Error("Error emptying trash:", err)
+		return c.String(http.StatusInternalServerError, "Error emptying trash")
+	}
+	c.Logger().Infof("Emptied trash of user %d (%d syllabi)", userID, n)
+
+	return handleTrashPage(c, repo)
+}
@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"Syllybea/repository"
+	"bufio"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"net/http"
+)
+
+// handleGradingExport serves the grade composition and grading policy of a
+// syllabus as plain text: a line per component, the total and the summary of
+// the policy, in the language given by the "lang" query parameter, else in the
+// language the syllabus is written in.
+func handleGradingExport(c echo.Context, repo *repository.Repository) error {
+	syl, err := loadExport(c, repo)
+	if syl == nil {
+		return err
+	}
+	draft := syl.Draft
+
+	c.Response().Header().Set(echo.HeaderContentType, "text/plain; charset=utf-8")
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="syllabus-%d-grading.txt"`, syl.ID))
+	c.Response().WriteHeader(http.StatusOK)
+
+	applyLanguage(c, draft, draft.Language)
+	w := bufio.NewWriter(c.Response())
+	fmt.Fprintf(w, "%s: %s\n\n", draft.Label("מדיניות הציון"), draft.Title())
+	for _, comp := range draft.GradeComponents {
+		if comp.IsEmpty() {
+			continue
+		}
+		line := fmt.Sprintf("%s\t%s\t%s%%", comp.PartName, comp.TypeLabel(draft.Shown().Code), comp.WeightText())
+		if rules := comp.Rules(draft.Shown().Code); rules != "" {
+			line += "\t" + rules
+		}
+		fmt.Fprintln(w, line)
+	}
+	fmt.Fprintf(w, "%s\t\t%s%%\n\n", draft.Label("סה״כ"), draft.GradeTotal())
+	for _, line := range draft.GradeSummary() {
+		fmt.Fprintln(w, line)
+	}
+	return w.Flush()
+}
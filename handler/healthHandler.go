@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"context"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ReadinessCheck is one condition the server needs before it can serve
+// requests, such as a reachable database.
+type ReadinessCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+// readinessTimeout bounds each readiness check, so a hung database fails the
+// probe instead of stalling it.
+const readinessTimeout = 2 * time.Second
+
+// RegisterHealth registers the probes of the server: /healthz answers while
+// the process is alive, and /readyz only once every check passes.
+func RegisterHealth(e *echo.Echo, checks ...ReadinessCheck) {
+	e.GET("/healthz", func(c echo.Context) error {
+		return c.String(http.StatusOK, "ok")
+	})
+	e.GET("/readyz", func(c echo.Context) error {
+		return handleReadyz(c, checks)
+	})
+}
+
+// handleReadyz runs the readiness checks and lists the result of each, with
+// 503 Service Unavailable when any of them fails.
+func handleReadyz(c echo.Context, checks []ReadinessCheck) error {
+	code := http.StatusOK
+	var report strings.Builder
+	for _, check := range checks {
+		ctx, cancel := context.WithTimeout(c.Request().Context(), readinessTimeout)
+		err := check.Check(ctx)
+		cancel()
+
+		if err != nil {
+			code = http.StatusServiceUnavailable
+			report.WriteString(check.Name + ": " + err.Error() + "\n")
+		} else {
+			report.WriteString(check.Name + ": ok\n")
+		}
+	}
+	return c.String(code, report.String())
+}
@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"Syllybea/i18n"
+	"Syllybea/mid"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// handleSetLocale keeps the locale the user chose for the user interface in a
+// cookie and shows the page the user came from again, in that locale.
+func handleSetLocale(c echo.Context) error {
+	locale := i18n.Get(c.FormValue("locale"))
+	http.SetCookie(c.Response(), &http.Cookie{
+		Name:     mid.LocaleCookie,
+		Value:    locale.Tag,
+		Path:     "/",
+		HttpOnly: true,
+		Expires:  time.Now().AddDate(1, 0, 0),
+	})
+
+	if c.Request().Header.Get("HX-Request") != "" {
+		c.Response().Header().Set("HX-Refresh", "true")
+		return c.String(http.StatusOK, "")
+	}
+	back := "/"
+	if ref, err := url.Parse(c.Request().Referer()); err == nil && sitePath(ref.Path) {
+		back = ref.Path
+		if ref.RawQuery != "" {
+			back += "?" + ref.RawQuery
+		}
+	}
+	return c.Redirect(http.StatusSeeOther, back)
+}
+
+// sitePath reports whether path is a path on this site. Only the path of the
+// referring page is redirected to, and "//host" or "/\host" would be taken by
+// browsers as another site.
+func sitePath(path string) bool {
+	return strings.HasPrefix(path, "/") && !strings.HasPrefix(path, "//") && !strings.HasPrefix(path, "/\\")
+}
+
+// tr translates a message to the locale of the request.
+func tr(c echo.Context, message string, args ...interface{}) string {
+	return i18n.T(mid.GetLocale(c), message, args...)
+}
@@ -0,0 +1,160 @@
+package handler
+
+import (
+	"Syllybea/UIcomponents"
+	"Syllybea/repository"
+	"Syllybea/status"
+	"Syllybea/types"
+	"errors"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// managerPageData is the data rendered by the "manager-page" template.
+type managerPageData struct {
+	Header      UIcomponents.HeaderData
+	Filter      repository.SyllabusFilter
+	Departments []types.Department
+	Lecturers   []types.User
+	Terms       []UIcomponents.TermOption
+	Lecturer    *types.User // Set when drilling down into a single lecturer
+	Stats       []UIcomponents.DepartmentStats
+	Cards       []managerRow
+	Content     UIcomponents.CoursesData // Totals of the filtered cards
+}
+
+// managerRow is the data rendered by the "manager-row" template: a syllabus
+// card with the statuses its review actions set.
+type managerRow struct {
+	UIcomponents.Card
+	Approve status.Status
+	Return  status.Status
+}
+
+func newManagerRow(card UIcomponents.Card) managerRow {
+	return managerRow{Card: card, Approve: status.Approved, Return: status.Draft}
+}
+
+// handleManagerDashboard lists the syllabi of all lecturers with filters and per-department counts.
+func handleManagerDashboard(c echo.Context, repo *repository.Repository) error {
+	user, err := requireManager(c, repo)
+	if user == nil {
+		return err
+	}
+
+	filter := repository.SyllabusFilter{
+		Year:     c.QueryParam("year"),
+		Statuses: status.ParseList(c.QueryParams()["status"]),
+		Search:   strings.TrimSpace(c.QueryParam("search")),
+	}
+	filter.DepartmentID, _ = strconv.Atoi(c.QueryParam("department"))
+	filter.LecturerID, _ = strconv.Atoi(c.QueryParam("lecturer"))
+	filter.TermID, _ = strconv.Atoi(c.QueryParam("term"))
+
+	cards, err := repo.FilterAllCards(filter)
+	if err != nil {
+		c.Logger().Error("FilterAllCards error:", err)
+		return c.String(http.StatusInternalServerError, "Error fetching syllabi")
+	}
+
+	stats, err := repo.CountSyllabiByDepartment()
+	if err != nil {
+		c.Logger().Error("CountSyllabiByDepartment error:", err)
+		return c.String(http.StatusInternalServerError, "Error fetching statistics")
+	}
+
+	departments, err := repo.GetAllDepartments()
+	if err != nil {
+		c.Logger().Error("GetAllDepartments error:", err)
+		return c.String(http.StatusInternalServerError, "Error fetching departments")
+	}
+
+	users, err := repo.GetAllUsers()
+	if err != nil {
+		c.Logger().Error("GetAllUsers error:", err)
+		return c.String(http.StatusInternalServerError, "Error fetching lecturers")
+	}
+
+	terms, err := repo.GetTermOptions()
+	if err != nil {
+		c.Logger().Error("GetTermOptions error:", err)
+		return c.String(http.StatusInternalServerError, "Error fetching terms")
+	}
+
+	data := managerPageData{
+		Header:      UIcomponents.HeaderData{Title: "Manager", Name: user.Name, Role: user.Role},
+		Filter:      filter,
+		Departments: departments,
+		Lecturers:   users,
+		Terms:       terms,
+		Stats:       stats,
+	}
+	for _, card := range cards {
+		data.Cards = append(data.Cards, newManagerRow(card))
+	}
+	for i := range users {
+		if users[i].ID == filter.LecturerID {
+			data.Lecturer = &users[i]
+		}
+	}
+
+	data.Content.Total = len(cards)
+	for _, card := range cards {
+		switch card.Status {
+		case status.Draft:
+			data.Content.Attempts++
+		case status.InReview:
+			data.Content.InReview++
+		case status.Approved:
+			data.Content.Approved++
+		}
+	}
+
+	return c.Render(http.StatusOK, "manager-page", data)
+}
+
+// handleManagerSetStatus approves a syllabus in review or returns it to the lecturer as a draft.
+func handleManagerSetStatus(c echo.Context, repo *repository.Repository) error {
+	user, err := requireManager(c, repo)
+	if user == nil {
+		return err
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid syllabus ID")
+	}
+
+	to, err := status.Parse(c.FormValue("status"))
+	if err != nil || (to != status.Approved && to != status.Draft) {
+		return c.String(http.StatusBadRequest, "Invalid status")
+	}
+
+	syl, err := repo.GetSyllabusByID(id)
+	if err != nil {
+		c.Logger().Error("Error retrieving syllabus:", err)
+		return c.String(http.StatusNotFound, "Syllabus not found")
+	}
+	if !syl.Status.AwaitsReview() {
+		return c.String(http.StatusConflict, "Only syllabi in review can be approved or returned")
+	}
+
+	err = repo.UpdateSyllabusStatus(id, to)
+	if errors.Is(err, status.ErrTransition) {
+		return c.String(http.StatusConflict, "Only syllabi in review can be approved or returned")
+	}
+	if err != nil {
+		c.Logger().Error("Error updating syllabus status:", err)
+		return c.String(http.StatusInternalServerError, "Error updating syllabus status")
+	}
+
+	// Re-render the row with its new status.
+	cards, err := repo.FilterAllCards(repository.SyllabusFilter{SyllabusID: id})
+	if err != nil || len(cards) == 0 {
+		c.Logger().Error("FilterAllCards error:", err)
+		return c.String(http.StatusInternalServerError, "Error fetching syllabus")
+	}
+	return c.Render(http.StatusOK, "manager-row", newManagerRow(cards[0]))
+}
@@ -0,0 +1,175 @@
+package handler
+
+import (
+	"Syllybea/UIcomponents"
+	"Syllybea/repository"
+	"Syllybea/types"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// outcomesPageData is the data rendered by the "program-outcomes-page" template.
+type outcomesPageData struct {
+	Header       UIcomponents.HeaderData
+	Departments  []types.Department
+	DepartmentID int // Department whose outcomes are listed, 0 for all
+	Outcomes     []types.ProgramOutcome
+	Error        string
+}
+
+// handleProgramOutcomesPage lists the program outcomes of the department given
+// by the "department" query parameter, or of all departments (managers only).
+func handleProgramOutcomesPage(c echo.Context, repo *repository.Repository) error {
+	user, err := requireManager(c, repo)
+	if user == nil {
+		return err
+	}
+	return renderProgramOutcomesPage(c, repo, user, "")
+}
+
+// handleSaveProgramOutcome creates a program outcome, or updates its code and
+// description when the form carries an ID (managers only).
+func handleSaveProgramOutcome(c echo.Context, repo *repository.Repository) error {
+	user, err := requireManager(c, repo)
+	if user == nil {
+		return err
+	}
+
+	o := &types.ProgramOutcome{
+		Code:        strings.TrimSpace(c.FormValue("code")),
+		Description: strings.TrimSpace(c.FormValue("description")),
+	}
+	if o.Code == "" || o.Description == "" {
+		return renderProgramOutcomesPage(c, repo, user, tr(c, "יש להזין קוד ותיאור לתוצר"))
+	}
+
+	if id, _ := strconv.Atoi(c.FormValue("id")); id > 0 {
+		o.ID = id
+		if err := repo.UpdateProgramOutcome(o); err != nil {
+			c.Logger().Error("UpdateProgramOutcome error:", err)
+			return renderProgramOutcomesPage(c, repo, user, tr(c, "לא ניתן לשמור את התוצר. ייתכן שהקוד כבר קיים במחלקה"))
+		}
+		return renderProgramOutcomesPage(c, repo, user, "")
+	}
+
+	if o.DepartmentID, err = strconv.Atoi(c.FormValue("department-id")); err != nil || o.DepartmentID <= 0 {
+		return renderProgramOutcomesPage(c, repo, user, tr(c, "יש לבחור מחלקה"))
+	}
+	if err := repo.CreateProgramOutcome(o); err != nil {
+		c.Logger().Error("CreateProgramOutcome error:", err)
+		return renderProgramOutcomesPage(c, repo, user, tr(c, "לא ניתן לשמור את התוצר. ייתכן שהקוד כבר קיים במחלקה"))
+	}
+	return renderProgramOutcomesPage(c, repo, user, "")
+}
+
+// handleDeleteProgramOutcome removes a program outcome (managers only).
+func handleDeleteProgramOutcome(c echo.Context, repo *repository.Repository) error {
+	user, err := requireManager(c, repo)
+	if user == nil {
+		return err
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid program outcome ID")
+	}
+	if err := repo.DeleteProgramOutcome(id); err != nil {
+		c.Logger().Error("DeleteProgramOutcome error:", err)
+		return c.String(http.StatusInternalServerError, "Error deleting program outcome")
+	}
+	return renderProgramOutcomesPage(c, repo, user, "")
+}
+
+func renderProgramOutcomesPage(c echo.Context, repo *repository.Repository, user *types.User, errMsg string) error {
+	data := outcomesPageData{
+		Header: UIcomponents.HeaderData{Title: "Program Outcomes", Name: user.Name, Role: user.Role},
+		Error:  errMsg,
+	}
+	var err error
+	if data.Departments, err = repo.GetAllDepartments(); err != nil {
+		c.Logger().Error("GetAllDepartments error:", err)
+		return c.String(http.StatusInternalServerError, "Error fetching departments")
+	}
+	if id, err := strconv.Atoi(c.QueryParam("department")); err == nil {
+		data.DepartmentID = id
+	} else if id, err := strconv.Atoi(c.FormValue("department")); err == nil {
+		data.DepartmentID = id
+	}
+	if data.Outcomes, err = repo.GetProgramOutcomes(data.DepartmentID); err != nil {
+		c.Logger().Error("GetProgramOutcomes error:", err)
+		return c.String(http.StatusInternalServerError, "Error fetching program outcomes")
+	}
+	return c.Render(http.StatusOK, "program-outcomes-page", data)
+}
+
+// outcomeMatrixInputs are the form actions and fields that change what the
+// learning outcome matrix shows. Responses to them tell the matrix to refresh.
+var outcomeMatrixInputs = map[string]bool{
+	"addLearningOutcome":    true,
+	"removeLearningOutcome": true,
+	"learningOutcomes":      true,
+	"addGradeComponent":     true,
+	"removeGradeComponent":  true,
+	"gradeComponents":       true,
+	"updateSyllabusRow":     true,
+	"insertSyllabusRow":     true,
+	"removeSyllabusRow":     true,
+	"generateSchedule":      true,
+	"syllabus-department":   true,
+}
+
+// populateProgramOutcomes fills the program outcomes of the draft's department,
+// which its learning outcomes are mapped to.
+func populateProgramOutcomes(draft *UIcomponents.Draft, repo *repository.Repository) {
+	draft.ProgramOutcomes = nil
+	departments, _ := repo.GetAllDepartments()
+	for _, dept := range departments {
+		if dept.Name != draft.SyllabusDepartment {
+			continue
+		}
+		outcomes, _ := repo.GetProgramOutcomes(dept.ID)
+		for _, o := range outcomes {
+			draft.ProgramOutcomes = append(draft.ProgramOutcomes, UIcomponents.ProgramOutcomeOption{
+				ID:          o.ID,
+				Code:        o.Code,
+				Description: o.Description,
+			})
+		}
+		return
+	}
+}
+
+// renderOutcomeMatrix renders the learning outcome matrix of the draft.
+func renderOutcomeMatrix(c echo.Context, repo *repository.Repository, draft *UIcomponents.Draft) error {
+	populateProgramOutcomes(draft, repo)
+	return c.Render(http.StatusOK, "outcomeMatrix", draft)
+}
+
+// updateOutcomeMatrix reads the learning outcome matrix posted with the form.
+// Each outcome posts its links as "outcome-{index}-program[]", "-assessment[]"
+// and "-lesson[]". A matrix rendered for other outcomes than the draft's
+// current ones is ignored and rendered afresh.
+func updateOutcomeMatrix(c echo.Context, repo *repository.Repository, draft *UIcomponents.Draft) error {
+	if err := c.Request().ParseForm(); err != nil {
+		return err
+	}
+	form := c.Request().Form
+	if count, err := strconv.Atoi(c.FormValue("outcome-count")); err == nil && count == len(draft.LearningOutcomes) {
+		links := make([]UIcomponents.OutcomeLinks, count)
+		for i := range links {
+			prefix := fmt.Sprintf("outcome-%d-", i)
+			for _, v := range form[prefix+"program[]"] {
+				if id, err := strconv.Atoi(v); err == nil {
+					links[i].ProgramOutcomes = append(links[i].ProgramOutcomes, id)
+				}
+			}
+			links[i].Assessments = form[prefix+"assessment[]"]
+			links[i].Lessons = form[prefix+"lesson[]"]
+		}
+		draft.OutcomeLinks = links
+	}
+	return renderOutcomeMatrix(c, repo, draft)
+}
@@ -0,0 +1,465 @@
+package handler
+
+import (
+	"Syllybea/UIcomponents"
+	"Syllybea/mid"
+	"Syllybea/prerequisites"
+	"Syllybea/repository"
+	"Syllybea/types"
+	"bytes"
+	"errors"
+	"github.com/labstack/echo/v4"
+	"html/template"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// prerequisitesPageData is the data rendered by the "prerequisites-page" template.
+type prerequisitesPageData struct {
+	Header       UIcomponents.HeaderData
+	Departments  []types.Department
+	DepartmentID int
+	Graph        template.HTML // Inline SVG drawing of the department's prerequisites
+	Courses      []prerequisiteRow
+	Cycles       []string // Prerequisite chains that lead back to their course
+}
+
+// prerequisiteRow is a course of the department with its prerequisites.
+type prerequisiteRow struct {
+	ID            int
+	Name          string
+	Prerequisites []string
+	Approved      bool
+}
+
+// prerequisiteInputs are the form actions and fields that change which course
+// the syllabus is for. Responses to them tell the prerequisites to refresh.
+var prerequisiteInputs = map[string]bool{
+	"course-dropdown":     true,
+	"syllabus-department": true,
+}
+
+// draftCourse finds the catalog course a draft is for, preferring a course of
+// the draft's department when several share its name.
+func draftCourse(courses []types.Course, departments []types.Department, draft *UIcomponents.Draft) *types.Course {
+	deptID := 0
+	for _, dept := range departments {
+		if dept.Name == draft.SyllabusDepartment {
+			deptID = dept.ID
+		}
+	}
+	var found *types.Course
+	for i := range courses {
+		if courses[i].Name != draft.SelectedCourse {
+			continue
+		}
+		if found == nil || courses[i].DepartmentID == deptID {
+			found = &courses[i]
+		}
+	}
+	return found
+}
+
+// populatePrerequisites refreshes the prerequisite courses of the draft from
+// the catalog, fills the courses that can be added and notes the listed
+// courses that no longer exist or have no approved syllabus. A draft starts
+// from the prerequisites its course has in the catalog, and starts over when
+// its course changes; after that they are the draft's own, and reach the
+// catalog only when the syllabus is approved.
+func populatePrerequisites(draft *UIcomponents.Draft, repo *repository.Repository) {
+	draft.PrerequisiteOptions, draft.PrerequisiteNotes = nil, nil
+	courses, _ := repo.GetAllCourses()
+	departments, _ := repo.GetAllDepartments()
+	approved, _ := repo.GetApprovedCourseIDs()
+
+	names := map[int]string{}
+	for _, c := range courses {
+		names[c.ID] = c.Name
+	}
+	own := draftCourse(courses, departments, draft)
+	if own != nil && draft.PrerequisitesFor != own.ID {
+		if live, err := repo.GetCoursePrerequisites(own.ID); err == nil {
+			var listed []UIcomponents.PrerequisiteCourse
+			for _, c := range live {
+				listed = append(listed, UIcomponents.PrerequisiteCourse{ID: c.ID, Name: c.Name})
+			}
+			// Courses removed from the catalog stay listed until the lecturer removes them.
+			for _, p := range draft.PrerequisiteCourses {
+				if _, exists := names[p.ID]; !exists {
+					listed = append(listed, p)
+				}
+			}
+			draft.PrerequisiteCourses, draft.PrerequisitesFor = listed, own.ID
+		}
+	}
+
+	for i, p := range draft.PrerequisiteCourses {
+		name, exists := names[p.ID]
+		if exists {
+			p.Name = name
+		}
+		p.Missing, p.Approved = !exists, approved[p.ID]
+		draft.PrerequisiteCourses[i] = p
+	}
+	listed := draft.PrerequisiteCourses
+
+	for _, p := range listed {
+		switch {
+		case p.Missing:
+			draft.PrerequisiteNotes = append(draft.PrerequisiteNotes, draft.Label("הקורס \"%s\" שצוין כדרישת קדם אינו קיים עוד בקטלוג הקורסים", p.Name))
+		case !p.Approved:
+			draft.PrerequisiteNotes = append(draft.PrerequisiteNotes, draft.Label("לקורס דרישת הקדם \"%s\" אין עדיין סילבוס מאושר", p.Name))
+		}
+	}
+
+	if own == nil {
+		return
+	}
+	deptNames := map[int]string{}
+	for _, dept := range departments {
+		deptNames[dept.ID] = dept.Name
+	}
+	for _, c := range courses {
+		if c.ID == own.ID || containsPrerequisite(listed, c.ID) {
+			continue
+		}
+		draft.PrerequisiteOptions = append(draft.PrerequisiteOptions, UIcomponents.CourseOption{
+			ID: c.ID, Name: c.Name, Department: deptNames[c.DepartmentID],
+		})
+	}
+	sort.Slice(draft.PrerequisiteOptions, func(i, j int) bool {
+		a, b := draft.PrerequisiteOptions[i], draft.PrerequisiteOptions[j]
+		if a.Department != b.Department {
+			return a.Department < b.Department
+		}
+		return a.Name < b.Name
+	})
+}
+
+func containsPrerequisite(list []UIcomponents.PrerequisiteCourse, id int) bool {
+	for _, p := range list {
+		if p.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// renderPrerequisites renders the prerequisites section of the form.
+func renderPrerequisites(c echo.Context, repo *repository.Repository, draft *UIcomponents.Draft) error {
+	populatePrerequisites(draft, repo)
+	return c.Render(http.StatusOK, "prerequisites", draft)
+}
+
+// addPrerequisite adds the course chosen in the form to the prerequisites of
+// the draft, unless that would close a cycle.
+func addPrerequisite(c echo.Context, repo *repository.Repository, draft *UIcomponents.Draft) error {
+	prerequisiteID, err := strconv.Atoi(c.FormValue("prerequisite-id"))
+	if err != nil {
+		return renderPrerequisites(c, repo, draft)
+	}
+	note, err := addDraftPrerequisite(repo, draft, prerequisiteID)
+	if err != nil {
+		c.Logger().Error("Error adding prerequisite: ", err)
+		return c.String(http.StatusInternalServerError, "Error adding prerequisite")
+	}
+	populatePrerequisites(draft, repo)
+	if note != "" {
+		draft.PrerequisiteNotes = append([]string{note}, draft.PrerequisiteNotes...)
+	}
+	return c.Render(http.StatusOK, "prerequisites", draft)
+}
+
+// addDraftPrerequisite adds a catalog course to the prerequisites of the
+// draft. It returns a note for the lecturer instead when it cannot be added:
+// the draft has no catalog course, or the course requires, directly or
+// through other courses, the draft's course.
+func addDraftPrerequisite(repo *repository.Repository, draft *UIcomponents.Draft, prerequisiteID int) (string, error) {
+	courses, err := repo.GetAllCourses()
+	if err != nil {
+		return "", err
+	}
+	departments, err := repo.GetAllDepartments()
+	if err != nil {
+		return "", err
+	}
+	own := draftCourse(courses, departments, draft)
+	if own == nil {
+		return draft.Label("יש לבחור קורס מהקטלוג לפני הוספת דרישות קדם"), nil
+	}
+	names := map[int]string{}
+	for _, c := range courses {
+		names[c.ID] = c.Name
+	}
+	if _, ok := names[prerequisiteID]; !ok || containsPrerequisite(draft.PrerequisiteCourses, prerequisiteID) {
+		return "", nil
+	}
+	if prerequisiteID == own.ID {
+		return draft.Label("קורס אינו יכול להיות דרישת קדם של עצמו"), nil
+	}
+
+	// The course is checked against the catalog with the draft's own
+	// prerequisites in place of those of its course.
+	g, err := repo.GetPrerequisiteGraph()
+	if err != nil {
+		return "", err
+	}
+	g[own.ID] = nil
+	for _, p := range draft.PrerequisiteCourses {
+		g.Add(own.ID, p.ID)
+	}
+	if cycle := g.Cycle(own.ID, prerequisiteID); cycle != nil {
+		var chain []string
+		for _, id := range cycle {
+			chain = append(chain, names[id])
+		}
+		return draft.Label("לא ניתן להוסיף את \"%s\" כדרישת קדם, כי הוא עצמו דורש את הקורס (%s)",
+			names[prerequisiteID], strings.Join(chain, " ← ")), nil
+	}
+	draft.PrerequisiteCourses = append(draft.PrerequisiteCourses, UIcomponents.PrerequisiteCourse{ID: prerequisiteID, Name: names[prerequisiteID]})
+	return "", nil
+}
+
+// removePrerequisite removes the prerequisite at the given index from the draft.
+func removePrerequisite(c echo.Context, repo *repository.Repository, draft *UIcomponents.Draft) error {
+	populatePrerequisites(draft, repo)
+	index, err := strconv.Atoi(c.FormValue("index"))
+	if err == nil && index >= 0 && index < len(draft.PrerequisiteCourses) {
+		draft.PrerequisiteCourses = append(draft.PrerequisiteCourses[:index], draft.PrerequisiteCourses[index+1:]...)
+	}
+	return renderPrerequisites(c, repo, draft)
+}
+
+// updatePrerequisiteText reads the free-text prerequisites into the draft.
+func updatePrerequisiteText(c echo.Context, repo *repository.Repository, draft *UIcomponents.Draft) error {
+	notes, err := resolvePrerequisiteText(repo, draft, c.FormValue("prerequisites"))
+	if err != nil {
+		c.Logger().Error("Error reading prerequisites: ", err)
+		return c.String(http.StatusInternalServerError, "Error reading prerequisites")
+	}
+	populatePrerequisites(draft, repo)
+	draft.PrerequisiteNotes = append(notes, draft.PrerequisiteNotes...)
+	return c.Render(http.StatusOK, "prerequisites", draft)
+}
+
+// resolvePrerequisiteText sets the free-text prerequisites of the draft.
+// Entries, separated by commas, semicolons or lines, that name a catalog
+// course are added to its prerequisite courses instead; the rest stays as
+// text. It returns notes on the courses that could not be added. Both the
+// updates of the form and its save read the text through it.
+func resolvePrerequisiteText(repo *repository.Repository, draft *UIcomponents.Draft, text string) ([]string, error) {
+	// The courses are added to the list of the draft's current course.
+	populatePrerequisites(draft, repo)
+	courses, err := repo.GetAllCourses()
+	if err != nil {
+		return nil, err
+	}
+	byName := map[string]int{}
+	for _, course := range courses {
+		byName[strings.TrimSpace(course.Name)] = course.ID
+	}
+
+	var rest, notes []string
+	entries := strings.FieldsFunc(text, func(r rune) bool {
+		return r == ',' || r == ';' || r == '\n'
+	})
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, ok := byName[entry]
+		if !ok {
+			rest = append(rest, entry)
+			continue
+		}
+		note, err := addDraftPrerequisite(repo, draft, id)
+		if err != nil {
+			return nil, err
+		}
+		if note != "" {
+			rest = append(rest, entry)
+			notes = append(notes, note)
+		}
+	}
+	draft.Prerequisites = strings.Join(rest, ", ")
+	return notes, nil
+}
+
+// handleAddCoursePrerequisite adds a prerequisite on the course page (managers only).
+func handleAddCoursePrerequisite(c echo.Context, repo *repository.Repository) error {
+	user, err := requireManager(c, repo)
+	if user == nil {
+		return err
+	}
+	courseID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid course ID")
+	}
+	prerequisiteID, err := strconv.Atoi(c.FormValue("prerequisite-id"))
+	if err != nil {
+		return renderCoursePage(c, repo, user, tr(c, "יש לבחור קורס"))
+	}
+
+	err = repo.AddCoursePrerequisite(courseID, prerequisiteID)
+	if errors.Is(err, prerequisites.ErrCycle) {
+		return renderCoursePage(c, repo, user, tr(c, "לא ניתן להוסיף את דרישת הקדם: הקורס שנבחר דורש, ישירות או דרך קורסים אחרים, את הקורס הזה"))
+	}
+	if err != nil {
+		c.Logger().Error("AddCoursePrerequisite error:", err)
+		return c.String(http.StatusInternalServerError, "Error adding prerequisite")
+	}
+	return renderCoursePage(c, repo, user, "")
+}
+
+// handleRemoveCoursePrerequisite removes a prerequisite on the course page (managers only).
+func handleRemoveCoursePrerequisite(c echo.Context, repo *repository.Repository) error {
+	user, err := requireManager(c, repo)
+	if user == nil {
+		return err
+	}
+	courseID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid course ID")
+	}
+	prerequisiteID, err := strconv.Atoi(c.Param("prerequisite"))
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid prerequisite ID")
+	}
+	if err := repo.RemoveCoursePrerequisite(courseID, prerequisiteID); err != nil {
+		c.Logger().Error("RemoveCoursePrerequisite error:", err)
+		return c.String(http.StatusInternalServerError, "Error removing prerequisite")
+	}
+	return renderCoursePage(c, repo, user, "")
+}
+
+// prerequisiteGraph collects the courses of a department, and the courses of
+// other departments they require, as nodes of the prerequisite graph.
+func prerequisiteGraph(repo *repository.Repository, departmentID int) ([]prerequisites.Node, prerequisites.Graph, error) {
+	courses, err := repo.GetAllCourses()
+	if err != nil {
+		return nil, nil, err
+	}
+	g, err := repo.GetPrerequisiteGraph()
+	if err != nil {
+		return nil, nil, err
+	}
+	approved, err := repo.GetApprovedCourseIDs()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	inGraph := map[int]bool{}
+	for _, c := range courses {
+		if c.DepartmentID == departmentID {
+			inGraph[c.ID] = true
+			for _, p := range g[c.ID] {
+				inGraph[p] = true
+			}
+		}
+	}
+	var nodes []prerequisites.Node
+	for _, c := range courses {
+		if inGraph[c.ID] {
+			nodes = append(nodes, prerequisites.Node{
+				ID:       c.ID,
+				Name:     c.Name,
+				Approved: approved[c.ID],
+				External: c.DepartmentID != departmentID,
+			})
+		}
+	}
+	return nodes, g, nil
+}
+
+// departmentFromQuery reads the "department" query parameter, defaulting to the first department.
+func departmentFromQuery(c echo.Context, departments []types.Department) int {
+	if id, err := strconv.Atoi(c.QueryParam("department")); err == nil {
+		return id
+	}
+	if len(departments) > 0 {
+		return departments[0].ID
+	}
+	return 0
+}
+
+// handlePrerequisitesPage shows the prerequisite graph of a department.
+func handlePrerequisitesPage(c echo.Context, repo *repository.Repository) error {
+	user, err := currentUser(c, repo)
+	if user == nil {
+		return err
+	}
+
+	data := prerequisitesPageData{
+		Header: UIcomponents.HeaderData{Title: "Prerequisites", Name: user.Name, Role: user.Role},
+	}
+	if data.Departments, err = repo.GetAllDepartments(); err != nil {
+		c.Logger().Error("GetAllDepartments error:", err)
+		return c.String(http.StatusInternalServerError, "Error fetching departments")
+	}
+	data.DepartmentID = departmentFromQuery(c, data.Departments)
+
+	nodes, g, err := prerequisiteGraph(repo, data.DepartmentID)
+	if err != nil {
+		c.Logger().Error("Error loading prerequisites:", err)
+		return c.String(http.StatusInternalServerError, "Error fetching prerequisites")
+	}
+	var svg bytes.Buffer
+	if err := prerequisites.WriteSVG(&svg, nodes, g, mid.GetLocale(c)); err != nil {
+		return err
+	}
+	data.Graph = template.HTML(svg.String())
+
+	names := map[int]string{}
+	for _, n := range nodes {
+		names[n.ID] = n.Name
+	}
+	for _, n := range nodes {
+		if n.External {
+			continue
+		}
+		row := prerequisiteRow{ID: n.ID, Name: n.Name, Approved: n.Approved}
+		for _, p := range g[n.ID] {
+			row.Prerequisites = append(row.Prerequisites, names[p])
+		}
+		sort.Strings(row.Prerequisites)
+		data.Courses = append(data.Courses, row)
+	}
+	sort.Slice(data.Courses, func(i, j int) bool { return data.Courses[i].Name < data.Courses[j].Name })
+
+	_, cyclic := g.Levels()
+	for _, e := range cyclic {
+		if names[e.Course] == "" {
+			continue
+		}
+		var chain []string
+		for _, id := range g.Cycle(e.Course, e.Prerequisite) {
+			chain = append(chain, names[id])
+		}
+		data.Cycles = append(data.Cycles, strings.Join(chain, " ← "))
+	}
+	return c.Render(http.StatusOK, "prerequisites-page", data)
+}
+
+// handlePrerequisitesSVG serves the prerequisite graph of a department as an SVG image.
+func handlePrerequisitesSVG(c echo.Context, repo *repository.Repository) error {
+	user, err := currentUser(c, repo)
+	if user == nil {
+		return err
+	}
+	departments, err := repo.GetAllDepartments()
+	if err != nil {
+		c.Logger().Error("GetAllDepartments error:", err)
+		return c.String(http.StatusInternalServerError, "Error fetching departments")
+	}
+	nodes, g, err := prerequisiteGraph(repo, departmentFromQuery(c, departments))
+	if err != nil {
+		c.Logger().Error("Error loading prerequisites:", err)
+		return c.String(http.StatusInternalServerError, "Error fetching prerequisites")
+	}
+	c.Response().Header().Set(echo.HeaderContentType, "image/svg+xml; charset=utf-8")
+	c.Response().WriteHeader(http.StatusOK)
+	return prerequisites.WriteSVG(c.Response(), nodes, g, mid.GetLocale(c))
+}
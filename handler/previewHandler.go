@@ -1,6 +1,8 @@
 package handler
 
 import (
+	"Syllybea/UIcomponents"
+	"Syllybea/bibliography"
 	"Syllybea/repository"
 	"github.com/labstack/echo/v4"
 	"net/http"
@@ -28,6 +30,11 @@ func HandleSyllabusPreview(c echo.Context, repo *repository.Repository) error {
 	}
 
 	// Render the preview template with the draft data
+	applyLanguage(c, draft, draft.Language)
+	applyCitationStyle(c, draft)
+	populateProgramOutcomes(draft, repo)
+	populateWorkloadNorm(draft, repo)
+	populatePrerequisites(draft, repo)
 	return c.Render(http.StatusOK, "syllabus-preview.html", draft)
 }
 
@@ -52,5 +59,30 @@ func HandleSyllabusPreviewFromForm(c echo.Context, repo *repository.Repository)
 	}
 
 	// Render the preview template with the draft data
+	applyLanguage(c, draft, draft.Language)
+	applyCitationStyle(c, draft)
+	populateProgramOutcomes(draft, repo)
+	populateWorkloadNorm(draft, repo)
+	populatePrerequisites(draft, repo)
 	return c.Render(http.StatusOK, "syllabus-preview.html", draft)
 }
+
+// applyLanguage shows a draft in the language asked for in the "lang" query
+// parameter, else in the fallback language.
+func applyLanguage(c echo.Context, draft *UIcomponents.Draft, fallback string) {
+	lang := c.QueryParam("lang")
+	if lang == "" {
+		lang = fallback
+	}
+	draft.ShowLanguage(lang)
+}
+
+// applyCitationStyle sets the style the bibliography is shown in: the "style"
+// query parameter when given, else the style chosen in the syllabus.
+func applyCitationStyle(c echo.Context, draft *UIcomponents.Draft) {
+	style := c.QueryParam("style")
+	if style == "" {
+		style = draft.CitationStyle
+	}
+	draft.CitationStyle = bibliography.ParseStyle(style)
+}
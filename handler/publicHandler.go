@@ -0,0 +1,196 @@
+package handler
+
+import (
+	"Syllybea/UIcomponents"
+	"Syllybea/feed"
+	"Syllybea/mid"
+	"Syllybea/repository"
+	"Syllybea/types"
+	"Syllybea/workload"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// publicFeedSize is the number of publications listed in the public feeds.
+const publicFeedSize = 50
+
+// handlePublicCatalog lists the published syllabi grouped by department,
+// optionally limited to the term given by the "term" query parameter. Like the
+// other public pages it needs no session.
+func handlePublicCatalog(c echo.Context, repo *repository.Repository) error {
+	termID, _ := strconv.Atoi(c.QueryParam("term"))
+	published, err := repo.GetPublishedSyllabi(termID)
+	if err != nil {
+		c.Logger().Error("GetPublishedSyllabi error:", err)
+		return c.String(http.StatusInternalServerError, "Error fetching syllabi")
+	}
+	terms, err := repo.GetPublishedTermOptions()
+	if err != nil {
+		c.Logger().Error("GetPublishedTermOptions error:", err)
+		return c.String(http.StatusInternalServerError, "Error fetching terms")
+	}
+
+	data := UIcomponents.PublicCatalogData{Terms: terms, TermID: termID}
+	for _, p := range published {
+		n := len(data.Departments)
+		if n == 0 || data.Departments[n-1].Name != p.Department {
+			data.Departments = append(data.Departments, UIcomponents.PublicDepartment{Name: p.Department})
+			n++
+		}
+		data.Departments[n-1].Syllabi = append(data.Departments[n-1].Syllabi, UIcomponents.PublicSyllabus{
+			Slug:      p.Slug,
+			Course:    p.CourseName,
+			Term:      p.TermLabel,
+			Section:   p.Section,
+			Lecturer:  publishedDraft(&p).LecturerName,
+			Published: p.PublishedAt.Format("02/01/2006"),
+		})
+	}
+	return c.Render(http.StatusOK, "public-page", data)
+}
+
+// handlePublicSyllabus shows the published copy of a syllabus.
+func handlePublicSyllabus(c echo.Context, repo *repository.Repository) error {
+	p, err := repo.GetPublishedBySlug(c.Param("slug"))
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.String(http.StatusNotFound, "Syllabus not found")
+	}
+	if err != nil {
+		c.Logger().Error("GetPublishedBySlug error:", err)
+		return c.String(http.StatusInternalServerError, "Error fetching syllabus")
+	}
+
+	draft := publishedDraft(p)
+	// The published copy has no live calendar; the ID would link to the current version.
+	draft.ID = 0
+	draft.PublishedAt = p.PublishedAt.Format("02/01/2006")
+	applyLanguage(c, draft, draft.Language)
+	applyCitationStyle(c, draft)
+	if draft.WorkloadNorm == (workload.Norm{}) {
+		// Published before the department's outcomes and norm were kept with it.
+		populateProgramOutcomes(draft, repo)
+		populateWorkloadNorm(draft, repo)
+	}
+	return c.Render(http.StatusOK, "syllabus-preview.html", draft)
+}
+
+// handlePublicFeed serves the latest publications as a JSON Feed, or as RSS
+// when format is "rss".
+func handlePublicFeed(c echo.Context, repo *repository.Repository, format string) error {
+	published, err := repo.GetRecentlyPublished(publicFeedSize)
+	if err != nil {
+		c.Logger().Error("GetRecentlyPublished error:", err)
+		return c.String(http.StatusInternalServerError, "Error fetching syllabi")
+	}
+
+	base := c.Scheme() + "://" + c.Request().Host
+	f := feed.Feed{
+		Title:       tr(c, "סילבוסים שפורסמו"),
+		Description: tr(c, "סילבוסים שאושרו ופורסמו לאחרונה"),
+		HomeURL:     base + "/public",
+		FeedURL:     base + "/public/feed.json",
+		Language:    mid.GetLocale(c),
+	}
+	for _, p := range published {
+		url := base + "/public/" + p.Slug
+		title := p.CourseName
+		if p.TermLabel != "" {
+			title += " – " + p.TermLabel
+		}
+		f.Items = append(f.Items, feed.Item{
+			// A re-approval is a new entry at the same URL.
+			ID:        fmt.Sprintf("%s#%d", url, p.PublishedAt.Unix()),
+			URL:       url,
+			Title:     title,
+			Summary:   p.Department,
+			Author:    publishedDraft(&p).LecturerName,
+			Published: p.PublishedAt,
+		})
+	}
+
+	if format == "rss" {
+		c.Response().Header().Set(echo.HeaderContentType, "application/rss+xml; charset=utf-8")
+		c.Response().WriteHeader(http.StatusOK)
+		return f.WriteRSS(c.Response())
+	}
+	c.Response().Header().Set(echo.HeaderContentType, "application/feed+json; charset=utf-8")
+	c.Response().WriteHeader(http.StatusOK)
+	return f.WriteJSON(c.Response())
+}
+
+// publishedDraft reads the frozen draft of a publication, with the program
+// outcomes and workload norm of its department as they were published. A copy
+// that cannot be read is shown with the course name only.
+func publishedDraft(p *types.PublishedSyllabus) *UIcomponents.Draft {
+	var published UIcomponents.PublishedDraft
+	if err := json.Unmarshal(p.Data, &published); err != nil {
+		published = UIcomponents.PublishedDraft{}
+	}
+	draft := &published.Draft
+	if draft.SelectedCourse == "" {
+		draft.SelectedCourse = p.CourseName
+	}
+	draft.ProgramOutcomes = published.DepartmentOutcomes
+	if published.DepartmentNorm != nil {
+		draft.WorkloadNorm = *published.DepartmentNorm
+	}
+	return draft
+}
+
+// exportedSyllabus is a syllabus as its exports show it.
+type exportedSyllabus struct {
+	ID        int
+	Draft     *UIcomponents.Draft
+	TermID    int // 0 when the syllabus has no term
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// loadExport loads the syllabus whose export is requested by the "id" path
+// parameter. Staff with a session export any listed syllabus as it is now;
+// without a session only published syllabi are exported, as they were
+// published, so shared links never show text that was not approved. Otherwise
+// it writes the error response and returns a nil syllabus together with the
+// result of writing that response.
+func loadExport(c echo.Context, repo *repository.Repository) (*exportedSyllabus, error) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return nil, c.String(http.StatusBadRequest, "Invalid syllabus ID")
+	}
+
+	if _, err := mid.SessionUserID(c); err != nil {
+		p, err := repo.GetPublishedBySyllabus(id)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, c.String(http.StatusNotFound, "Syllabus not found")
+		}
+		if err != nil {
+			c.Logger().Error("GetPublishedBySyllabus error:", err)
+			return nil, c.String(http.StatusInternalServerError, "Error reading syllabus")
+		}
+		return &exportedSyllabus{ID: id, Draft: publishedDraft(p), TermID: p.TermID, CreatedAt: p.PublishedAt, UpdatedAt: p.PublishedAt}, nil
+	}
+
+	syl, err := repo.GetSyllabusByID(id)
+	if err != nil || !syl.Status.IsListed() {
+		return nil, c.String(http.StatusNotFound, "Syllabus not found")
+	}
+	var draft UIcomponents.Draft
+	if len(syl.Data) > 0 {
+		if err := json.Unmarshal(syl.Data, &draft); err != nil {
+			c.Logger().Error("Error reading syllabus data:", err)
+			return nil, c.String(http.StatusInternalServerError, "Error reading syllabus")
+		}
+	}
+	termID, err := repo.OfferingTerm(syl.OfferingID)
+	if err != nil {
+		c.Logger().Error("OfferingTerm error:", err)
+		return nil, c.String(http.StatusInternalServerError, "Error reading syllabus")
+	}
+	return &exportedSyllabus{ID: syl.ID, Draft: &draft, TermID: termID, CreatedAt: syl.CreatedAt, UpdatedAt: syl.UpdatedAt}, nil
+}
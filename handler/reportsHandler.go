@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"Syllybea/UIcomponents"
+	"Syllybea/mid"
+	"Syllybea/reports"
+	"Syllybea/repository"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"strings"
+)
+
+// loadReportInput gathers the data the reports are computed from, for the locale of the request.
+func loadReportInput(c echo.Context, repo *repository.Repository) (reports.Input, error) {
+	in := reports.Input{Locale: mid.GetLocale(c)}
+	var err error
+	if in.Syllabi, err = repo.GetAllSyllabi(); err != nil {
+		return in, err
+	}
+	if in.Courses, err = repo.GetAllCourses(); err != nil {
+		return in, err
+	}
+	if in.Departments, err = repo.GetAllDepartments(); err != nil {
+		return in, err
+	}
+	if in.History, err = repo.GetStatusHistory(); err != nil {
+		return in, err
+	}
+	if in.Terms, err = repo.GetAllTerms(); err != nil {
+		return in, err
+	}
+	if in.Offerings, err = repo.GetAllOfferings(); err != nil {
+		return in, err
+	}
+	if in.ProgramOutcomes, err = repo.GetProgramOutcomes(0); err != nil {
+		return in, err
+	}
+	if in.WorkloadNorms, err = repo.GetWorkloadNorms(); err != nil {
+		return in, err
+	}
+	return in, nil
+}
+
+// handleReportsPage renders all analytics reports as tables (managers only).
+func handleReportsPage(c echo.Context, repo *repository.Repository) error {
+	user, err := requireManager(c, repo)
+	if user == nil {
+		return err
+	}
+
+	in, err := loadReportInput(c, repo)
+	if err != nil {
+		c.Logger().Error("Error loading report data:", err)
+		return c.String(http.StatusInternalServerError, "Error computing reports")
+	}
+
+	data := struct {
+		Header UIcomponents.HeaderData
+		Tables []reports.Table
+	}{
+		Header: UIcomponents.HeaderData{Title: "Reports", Name: user.Name, Role: user.Role},
+		Tables: reports.All(in),
+	}
+	return c.Render(http.StatusOK, "reports-page", data)
+}
+
+// handleReportCSV downloads a single report, e.g. /reports/missing-syllabi.csv.
+func handleReportCSV(c echo.Context, repo *repository.Repository) error {
+	user, err := requireManager(c, repo)
+	if user == nil {
+		return err
+	}
+
+	name := strings.TrimSuffix(c.Param("name"), ".csv")
+	in, err := loadReportInput(c, repo)
+	if err != nil {
+		c.Logger().Error("Error loading report data:", err)
+		return c.String(http.StatusInternalServerError, "Error computing reports")
+	}
+	table, ok := reports.Find(in, name)
+	if !ok {
+		return c.String(http.StatusNotFound, "Unknown report")
+	}
+
+	c.Response().Header().Set(echo.HeaderContentType, "text/csv; charset=utf-8")
+	c.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="`+table.Name+`.csv"`)
+	c.Response().WriteHeader(http.StatusOK)
+	return table.WriteCSV(c.Response())
+}
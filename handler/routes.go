@@ -1,8 +1,11 @@
 package handler
 
 import (
+	"Syllybea/bibliography"
+	"Syllybea/config"
 	"Syllybea/mid"
 	"Syllybea/repository"
+	"Syllybea/types"
 	"github.com/labstack/echo/v4"
 	"net/http"
 	"time"
@@ -10,6 +13,12 @@ import (
 
 var r repository.Repository
 
+// resolver looks up bibliography entries in the library catalog.
+var resolver bibliography.Resolver = bibliography.Resolvers{}
+
+// sessions configures the session cookie set at login.
+var sessions config.Auth
+
 // handleLogout removes the JWT cookie and redirects to the login page
 func handleLogout(c echo.Context) error {
 	cookie := &http.Cookie{
@@ -18,6 +27,7 @@ func handleLogout(c echo.Context) error {
 		Path:     "/",
 		Expires:  time.Unix(0, 0),
 		HttpOnly: true,
+		Secure:   sessions.SecureCookies,
 	}
 	http.SetCookie(c.Response(), cookie)
 
@@ -25,9 +35,43 @@ func handleLogout(c echo.Context) error {
 	return c.String(http.StatusOK, "Redirecting to login page...")
 }
 
-// RegisterRoutes registers all endpoints.
-func RegisterRoutes(e *echo.Echo, repo *repository.Repository) {
+// requireManager returns the logged-in user if they have the Manager role.
+// Otherwise it writes the redirect/forbidden response and returns a nil user
+// together with the result of writing that response.
+func requireManager(c echo.Context, repo *repository.Repository) (*types.User, error) {
+	userID, err := mid.GetUserID(c)
+	if err != nil {
+		c.Response().Header().Set("HX-Redirect", "/login")
+		return nil, c.String(http.StatusOK, "Redirecting to login page...")
+	}
+	user, err := repo.GetUserByID(userID)
+	if err != nil {
+		c.Logger().Error("GetUserByID error:", err)
+		return nil, c.String(http.StatusInternalServerError, "Error retrieving user data")
+	}
+	if user.Role != "Manager" {
+		c.Logger().Warn("Non-manager access attempt by user:", userID)
+		return nil, c.String(http.StatusForbidden, "Managers only")
+	}
+	return user, nil
+}
+
+// audited scopes repo to the logged-in user and client IP of the request,
+// so the mutations made through it are attributed in the audit log, and to
+// its context, so its queries are traced as part of the request.
+func audited(c echo.Context, repo *repository.Repository) *repository.Repository {
+	userID, _ := mid.GetUserID(c)
+	return repo.WithActor(repository.Actor{UserID: userID, IP: c.RealIP()}).WithContext(c.Request().Context())
+}
+
+// RegisterRoutes registers all endpoints. Bibliography entries in the form are
+// completed from their ISBN or DOI through res, and login sessions follow auth.
+func RegisterRoutes(e *echo.Echo, repo *repository.Repository, res bibliography.Resolver, auth config.Auth) {
 	r = *repo
+	if res != nil {
+		resolver = res
+	}
+	sessions = auth
 	// Login page.
 	e.GET("/login", func(c echo.Context) error {
 		return c.Render(http.StatusOK, "login.html", nil)
@@ -35,19 +79,22 @@ func RegisterRoutes(e *echo.Echo, repo *repository.Repository) {
 
 	e.GET("/", handleHome)
 
+	// Locale of the user interface.
+	e.POST("/locale", handleSetLocale)
+
 	// Login submission.
 	e.POST("/login", func(c echo.Context) error {
 		email := c.FormValue("email")
 		if email == "" {
 			c.Logger().Warn("Empty email provided")
-			return c.String(http.StatusBadRequest, "אנא ספק אימייל")
+			return c.String(http.StatusBadRequest, tr(c, "אנא ספק אימייל"))
 		}
 
 		// Check if the user exists.
 		user, err := repo.GetUserByEmail(email)
 		if err != nil {
-			c.Logger().Warn("User not found for email:", email)
-			return c.String(http.StatusUnauthorized, "אימייל לא קיים")
+			c.Logger().Warn("No user with the login email: ", err)
+			return c.String(http.StatusUnauthorized, tr(c, "אימייל לא קיים"))
 		}
 
 		token, err := mid.GenerateToken(user.ID)
@@ -61,37 +108,43 @@ func RegisterRoutes(e *echo.Echo, repo *repository.Repository) {
 			Value:    token,
 			Path:     "/",
 			HttpOnly: true,
-			Expires:  time.Now().Add(24 * time.Hour),
+			Secure:   sessions.SecureCookies,
+			Expires:  time.Now().Add(sessions.SessionLifetime),
 		}
 		http.SetCookie(c.Response(), cookie)
-		c.Logger().Info("JWT cookie set for user:", user.Email)
+		c.Logger().Infof("User %d logged in", user.ID)
 
 		// Set the HX-Redirect header for HTMX and perform redirect.
 		c.Response().Header().Set("HX-Redirect", "/dashboard")
-		c.Logger().Info("Redirecting to /dashboard")
+		c.Logger().Debug("Redirecting to /dashboard")
 		return c.String(http.StatusOK, "Redirecting...")
 	})
 
 	// Dashboard.
 	e.GET("/dashboard", func(c echo.Context) error {
-		return handleDashboard(c, repo)
+		return handleDashboard(c, audited(c, repo))
 	})
 
 	// Filter endpoint.
 	e.POST("/filter", func(c echo.Context) error {
-		return filterCards(c, repo)
+		return filterCards(c, audited(c, repo))
 	})
 
 	e.GET("/syllabus/create", func(c echo.Context) error {
-		return HandleCreateSyllabus(c, repo)
+		return HandleCreateSyllabus(c, audited(c, repo))
+	})
+
+	// Duplicate an existing syllabus as a new draft.
+	e.POST("/syllabus/duplicate/:id", func(c echo.Context) error {
+		return handleDuplicateSyllabus(c, audited(c, repo))
 	})
 
 	e.POST("/syllabus/submit", func(c echo.Context) error {
-		return handleSubmitSyllabus(c, repo)
+		return handleSubmitSyllabus(c, audited(c, repo))
 	})
 
 	e.POST("/syllabus/save", func(c echo.Context) error {
-		return handleSaveSyllabus(c, repo)
+		return handleSaveSyllabus(c, audited(c, repo))
 	})
 
 	e.POST("/syllabus/update", updateSyllabusHandler)
@@ -99,25 +152,25 @@ func RegisterRoutes(e *echo.Echo, repo *repository.Repository) {
 
 	//does not match HTMX request
 	e.GET("/edit-syllabus/:id", func(c echo.Context) error {
-		return HandleEditSyllabus(c, repo)
+		return HandleEditSyllabus(c, audited(c, repo))
 	})
 
 	// New POST route for fetching comments.
 	e.GET("/syllabus/comments", func(c echo.Context) error {
-		return handleGetCommentsOfSyllabus(c, repo)
+		return handleGetCommentsOfSyllabus(c, audited(c, repo))
 	})
 
 	e.POST("/add-comment", func(c echo.Context) error {
-		return handleAddComment(c, repo)
+		return handleAddComment(c, audited(c, repo))
 	})
 
 	// Preview syllabus routes
 	e.GET("/syllabus/preview/:id", func(c echo.Context) error {
-		return HandleSyllabusPreview(c, repo)
+		return HandleSyllabusPreview(c, audited(c, repo))
 	})
 
 	e.POST("/syllabus/preview", func(c echo.Context) error {
-		return HandleSyllabusPreviewFromForm(c, repo)
+		return HandleSyllabusPreviewFromForm(c, audited(c, repo))
 	})
 
 	// Logout endpoint
@@ -127,16 +180,189 @@ func RegisterRoutes(e *echo.Echo, repo *repository.Repository) {
 
 	// Delete syllabus endpoint
 	e.DELETE("/delete-syllabus/:id", func(c echo.Context) error {
-		return handleDeleteSyllabus(c, repo)
+		return handleDeleteSyllabus(c, audited(c, repo))
 	})
 
 	// Trash page endpoint
 	e.GET("/trash", func(c echo.Context) error {
-		return handleTrashPage(c, repo)
+		return handleTrashPage(c, audited(c, repo))
+	})
+
+	// Exports of a syllabus. Without a session they serve the published copy
+	// only, so the links of published syllabi can be shared with students and
+	// subscribed to by calendar clients and reference managers; see loadExport.
+
+	// iCalendar feed of a syllabus (office hours and dated lessons).
+	e.GET("/syllabus/:id/calendar.ics", func(c echo.Context) error {
+		return handleSyllabusCalendar(c, repo.WithContext(c.Request().Context()))
+	})
+
+	// Bibliography export of a syllabus.
+	e.GET("/syllabus/:id/bibliography.bib", func(c echo.Context) error {
+		return handleBibliographyExport(c, repo, "bib")
+	})
+
+	e.GET("/syllabus/:id/bibliography.ris", func(c echo.Context) error {
+		return handleBibliographyExport(c, repo, "ris")
+	})
+
+	e.GET("/syllabus/:id/bibliography.txt", func(c echo.Context) error {
+		return handleBibliographyExport(c, repo, "txt")
+	})
+
+	// Grading policy export of a syllabus.
+	e.GET("/syllabus/:id/grading.txt", func(c echo.Context) error {
+		return handleGradingExport(c, repo.WithContext(c.Request().Context()))
+	})
+
+	// Public pages of published syllabi, for students.
+	e.GET("/public", func(c echo.Context) error {
+		return handlePublicCatalog(c, repo.WithContext(c.Request().Context()))
+	})
+
+	e.GET("/public/feed.json", func(c echo.Context) error {
+		return handlePublicFeed(c, repo, "json")
+	})
+
+	e.GET("/public/feed.rss", func(c echo.Context) error {
+		return handlePublicFeed(c, repo, "rss")
+	})
+
+	e.GET("/public/:slug", func(c echo.Context) error {
+		return handlePublicSyllabus(c, repo.WithContext(c.Request().Context()))
+	})
+
+	// Course catalog and course offerings.
+	e.GET("/courses", func(c echo.Context) error {
+		return handleCatalogPage(c, audited(c, repo))
+	})
+
+	e.GET("/courses/:id", func(c echo.Context) error {
+		return handleCoursePage(c, audited(c, repo))
+	})
+
+	e.POST("/courses/:id/offerings", func(c echo.Context) error {
+		return handleCreateOffering(c, audited(c, repo))
+	})
+
+	e.POST("/courses/:id/prerequisites", func(c echo.Context) error {
+		return handleAddCoursePrerequisite(c, audited(c, repo))
+	})
+
+	e.DELETE("/courses/:id/prerequisites/:prerequisite", func(c echo.Context) error {
+		return handleRemoveCoursePrerequisite(c, audited(c, repo))
+	})
+
+	// Prerequisite graph of each department.
+	e.GET("/prerequisites", func(c echo.Context) error {
+		return handlePrerequisitesPage(c, audited(c, repo))
+	})
+
+	e.GET("/prerequisites.svg", func(c echo.Context) error {
+		return handlePrerequisitesSVG(c, audited(c, repo))
+	})
+
+	// Academic terms (managers).
+	e.GET("/terms", func(c echo.Context) error {
+		return handleTermsPage(c, audited(c, repo))
+	})
+
+	e.POST("/terms", func(c echo.Context) error {
+		return handleSaveTerm(c, audited(c, repo))
+	})
+
+	e.POST("/holidays", func(c echo.Context) error {
+		return handleSaveHoliday(c, audited(c, repo))
+	})
+
+	e.DELETE("/holidays/:id", func(c echo.Context) error {
+		return handleDeleteHoliday(c, audited(c, repo))
+	})
+
+	// Program outcomes of each department (managers).
+	e.GET("/program-outcomes", func(c echo.Context) error {
+		return handleProgramOutcomesPage(c, audited(c, repo))
+	})
+
+	e.POST("/program-outcomes", func(c echo.Context) error {
+		return handleSaveProgramOutcome(c, audited(c, repo))
+	})
+
+	e.DELETE("/program-outcomes/:id", func(c echo.Context) error {
+		return handleDeleteProgramOutcome(c, audited(c, repo))
+	})
+
+	// Credit-hour workload norm of each department (managers).
+	e.GET("/workload-norms", func(c echo.Context) error {
+		return handleWorkloadNormsPage(c, audited(c, repo))
+	})
+
+	e.POST("/workload-norms", func(c echo.Context) error {
+		return handleSaveWorkloadNorm(c, audited(c, repo))
+	})
+
+	// Manager view of all syllabi.
+	e.GET("/manager", func(c echo.Context) error {
+		return handleManagerDashboard(c, audited(c, repo))
+	})
+
+	e.POST("/manager/syllabus/:id/status", func(c echo.Context) error {
+		return handleManagerSetStatus(c, audited(c, repo))
+	})
+
+	// Analytics reports (managers).
+	e.GET("/reports", func(c echo.Context) error {
+		return handleReportsPage(c, audited(c, repo))
+	})
+
+	e.GET("/reports/:name", func(c echo.Context) error {
+		return handleReportCSV(c, audited(c, repo))
+	})
+
+	// Audit log (managers).
+	e.GET("/audit", func(c echo.Context) error {
+		return handleAuditPage(c, audited(c, repo))
+	})
+
+	e.GET("/audit/export.csv", func(c echo.Context) error {
+		return handleAuditCSV(c, audited(c, repo))
+	})
+
+	// Department syllabus templates (managers).
+	e.GET("/templates", func(c echo.Context) error {
+		return handleTemplatesPage(c, audited(c, repo))
+	})
+
+	e.POST("/templates", func(c echo.Context) error {
+		return handleSaveTemplate(c, audited(c, repo))
+	})
+
+	e.DELETE("/templates/:id", func(c echo.Context) error {
+		return handleDeleteTemplate(c, audited(c, repo))
+	})
+
+	// Template picker for starting a new syllabus.
+	e.GET("/templates/pick", func(c echo.Context) error {
+		return handleTemplatePicker(c, audited(c, repo))
+	})
+
+	// Full-text search inside syllabus contents (managers).
+	e.GET("/search", func(c echo.Context) error {
+		return handleSearchPage(c, audited(c, repo))
+	})
+
+	// Restore a syllabus from the trash
+	e.POST("/restore-syllabus/:id", func(c echo.Context) error {
+		return handleRestoreSyllabus(c, audited(c, repo))
+	})
+
+	// Empty the trash of the logged-in user
+	e.DELETE("/trash", func(c echo.Context) error {
+		return handleEmptyTrash(c, audited(c, repo))
 	})
 
 	// Permanent delete syllabus endpoint
 	e.DELETE("/permanent-delete-syllabus/:id", func(c echo.Context) error {
-		return handlePermanentDeleteSyllabus(c, repo)
+		return handlePermanentDeleteSyllabus(c, audited(c, repo))
 	})
 }
@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"Syllybea/UIcomponents"
+	"Syllybea/repository"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"strings"
+)
+
+// searchResultLimit caps the number of hits shown on the search page.
+const searchResultLimit = 50
+
+// handleSearchPage searches inside the contents of all syllabi (managers only).
+func handleSearchPage(c echo.Context, repo *repository.Repository) error {
+	user, err := requireManager(c, repo)
+	if user == nil {
+		return err
+	}
+
+	query := strings.TrimSpace(c.QueryParam("q"))
+	var results []UIcomponents.SearchResult
+	if query != "" {
+		results, err = repo.SearchSyllabi(query, searchResultLimit)
+		if err != nil {
+			c.Logger().Error("SearchSyllabi error:", err)
+			return c.String(http.StatusInternalServerError, "Error searching syllabi")
+		}
+	}
+
+	data := struct {
+		Header  UIcomponents.HeaderData
+		Query   string
+		Results []UIcomponents.SearchResult
+	}{
+		Header:  UIcomponents.HeaderData{Title: "Search", Name: user.Name, Role: user.Role},
+		Query:   query,
+		Results: results,
+	}
+	return c.Render(http.StatusOK, "search-page", data)
+}
@@ -2,13 +2,21 @@ package handler
 
 import (
 	"Syllybea/UIcomponents"
+	"Syllybea/bibliography"
+	"Syllybea/grading"
 	"Syllybea/mid"
 	"Syllybea/repository"
+	"Syllybea/status"
 	"Syllybea/types"
-	"encoding/json"
+	"Syllybea/utils"
+	"Syllybea/workload"
+	"context"
+	"errors"
+	"fmt"
 	"github.com/labstack/echo/v4"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -33,18 +41,49 @@ func HandleEditSyllabus(c echo.Context, repo *repository.Repository) error {
 		return c.String(http.StatusNotFound, "Syllabus not found")
 	}
 
+	populateDraftOptions(draft, repo)
+	draft.ShowLanguage(draft.Language)
 	return c.Render(http.StatusOK, "create-syllabus", draft)
 }
 
 func HandleCreateSyllabus(c echo.Context, repo *repository.Repository) error {
 	userID, _ := mid.GetUserID(c)
-	draft, err := repo.CreateNewUserDraft(userID)
+
+	var draft *UIcomponents.Draft
+	var err error
+	if templateIDStr := c.QueryParam("template_id"); templateIDStr != "" {
+		// Start from a department template.
+		templateID, convErr := strconv.Atoi(templateIDStr)
+		if convErr != nil {
+			return c.String(http.StatusBadRequest, "Invalid template ID")
+		}
+		draft, err = repo.CreateDraftFromTemplate(templateID, userID)
+	} else {
+		draft, err = repo.CreateNewUserDraft(userID)
+	}
 	if err != nil {
 		c.Logger().Error("Error creating new user draft: ", err)
 		return c.String(http.StatusInternalServerError, "Error creating new user draft")
 	}
+	mid.LogSyllabus(c, draft.ID)
+
+	populateDraftOptions(draft, repo)
+
+	// Save the updated draft
+	err = repo.SaveUserDraft(userID, draft)
+	if err != nil {
+		c.Logger().Error("Error saving user draft: ", err)
+		return c.String(http.StatusInternalServerError, "Error saving user draft")
+	}
+
+	return c.Render(http.StatusOK, "create-syllabus", draft)
+}
 
-	departments, _ := r.GetAllDepartments()
+// populateDraftOptions fills the department, course and term dropdown lists and the
+// program outcomes of a draft, and picks the first entry (the current term) when
+// nothing has been selected yet.
+func populateDraftOptions(draft *UIcomponents.Draft, repo *repository.Repository) {
+	departments, _ := repo.GetAllDepartments()
 	deptNames := make([]string, 0, len(departments))
 	for _, dept := range departments {
 		deptNames = append(deptNames, dept.Name)
@@ -54,7 +93,7 @@ func HandleCreateSyllabus(c echo.Context, repo *repository.Repository) error {
 		draft.SyllabusDepartment = deptNames[0]
 	}
 
-	courses, _ := r.GetAllCourses()
+	courses, _ := repo.GetAllCourses()
 	courseNames := make([]string, 0, len(courses))
 	for _, course := range courses {
 		courseNames = append(courseNames, course.Name)
@@ -64,13 +103,95 @@ func HandleCreateSyllabus(c echo.Context, repo *repository.Repository) error {
 		draft.SelectedCourse = courseNames[0]
 	}
 
-	// Save the updated draft
-	err = repo.SaveUserDraft(userID, draft)
+	populateProgramOutcomes(draft, repo)
+	populateWorkloadNorm(draft, repo)
+	populatePrerequisites(draft, repo)
+
+	draft.Terms, _ = repo.GetTermOptions()
+	if draft.TermID == 0 && draft.Semester == "" {
+		if term, err := repo.CurrentTerm(time.Now()); err == nil {
+			draft.TermID = term.ID
+			draft.Semester = term.Semester
+		}
+	}
+}
+
+// setDraftTerm sets the term of a draft from the ID chosen in the term dropdown,
+// keeping the draft semester in step with it. An empty ID clears the term.
+func setDraftTerm(draft *UIcomponents.Draft, repo *repository.Repository, termIDStr string) error {
+	if termIDStr == "" {
+		draft.TermID = 0
+		draft.Semester = ""
+		return nil
+	}
+	termID, err := strconv.Atoi(termIDStr)
+	if err != nil {
+		return fmt.Errorf("invalid term ID %q", termIDStr)
+	}
+	term, err := repo.GetTermByID(termID)
 	if err != nil {
+		return err
+	}
+	draft.TermID = term.ID
+	draft.Semester = term.Semester
+	return nil
+}
+
+// handleDuplicateSyllabus clones an existing syllabus into a new draft for the
+// current user and opens it in the form. An optional lesson offset (sent as the
+// HX-Prompt answer or as the "lesson-offset" form value) shifts lesson numbering.
+func handleDuplicateSyllabus(c echo.Context, repo *repository.Repository) error {
+	userID, err := mid.GetUserID(c)
+	if err != nil {
+		c.Response().Header().Set("HX-Redirect", "/login")
+		return c.String(http.StatusOK, "Redirecting to login page...")
+	}
+
+	sourceID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Logger().Error("Invalid syllabus ID: ", err)
+		return c.String(http.StatusBadRequest, "Invalid syllabus ID")
+	}
+
+	source, err := repo.GetSyllabusByID(sourceID)
+	if err != nil {
+		c.Logger().Error("Error retrieving syllabus: ", err)
+		return c.String(http.StatusNotFound, "Syllabus not found")
+	}
+
+	// Lecturers may only duplicate their own syllabi; managers may duplicate any.
+	if source.LecturerID != userID {
+		user, err := repo.GetUserByID(userID)
+		if err != nil || user.Role != "Manager" {
+			return c.String(http.StatusForbidden, "Not allowed to duplicate this syllabus")
+		}
+	}
+
+	offsetStr := c.Request().Header.Get("HX-Prompt")
+	if offsetStr == "" {
+		offsetStr = c.FormValue("lesson-offset")
+	}
+	lessonOffset := 0
+	if offsetStr != "" {
+		lessonOffset, err = strconv.Atoi(offsetStr)
+		if err != nil {
+			return c.String(http.StatusBadRequest, "Invalid lesson offset")
+		}
+	}
+
+	draft, err := repo.DuplicateSyllabus(sourceID, userID, lessonOffset)
+	if err != nil {
+		c.Logger().Error("Error duplicating syllabus: ", err)
+		return c.String(http.StatusInternalServerError, "Error duplicating syllabus")
+	}
+
+	populateDraftOptions(draft, repo)
+	if err := repo.SaveUserDraft(userID, draft); err != nil {
 		c.Logger().Error("Error saving user draft: ", err)
 		return c.String(http.StatusInternalServerError, "Error saving user draft")
 	}
 
+	draft.ShowLanguage(draft.Language)
 	return c.Render(http.StatusOK, "create-syllabus", draft)
 }
 
@@ -83,8 +204,10 @@ func handleSaveSyllabus(c echo.Context, repo *repository.Repository) error {
 		c.Logger().Error("Error getting user draft: ", err)
 		return c.String(http.StatusInternalServerError, "Error getting user draft")
 	}
+	mid.LogSyllabus(c, draft.ID)
 
-	// Update the draft with form data
+	// Update the draft with form data, whose text is in the language the form edits
+	draft.ShowLanguage(draft.Language)
 	if err := c.Request().ParseForm(); err == nil {
 		// Update lecturer details
 		if lecturerName := c.FormValue("LecturerName"); lecturerName != "" {
@@ -119,29 +242,44 @@ func handleSaveSyllabus(c echo.Context, repo *repository.Repository) error {
 		if year := c.FormValue("year"); year != "" {
 			draft.Year = year
 		}
-		if semester := c.FormValue("semester"); semester != "" {
-			draft.Semester = semester
+		if termID := c.FormValue("term-id"); termID != "" {
+			if err := setDraftTerm(draft, repo, termID); err != nil {
+				c.Logger().Error("Error setting syllabus term: ", err)
+				return c.String(http.StatusBadRequest, "Invalid term")
+			}
+		}
+		if section := c.FormValue("section"); section != "" {
+			draft.Section = strings.TrimSpace(section)
 		}
 		if prerequisites := c.FormValue("prerequisites"); prerequisites != "" {
-			draft.Prerequisites = prerequisites
+			if _, err := resolvePrerequisiteText(repo, draft, prerequisites); err != nil {
+				c.Logger().Error("Error reading prerequisites: ", err)
+				return c.String(http.StatusInternalServerError, "Error reading prerequisites")
+			}
 		}
 
-		// Update arrays
-		draft.CourseRequirements = c.Request().Form["course-requirements[]"]
-		draft.LearningOutcomes = c.Request().Form["learning-outcomes[]"]
-		draft.CourseObjectives = c.Request().Form["course-objectives[]"]
-		draft.BibliographyRequired = c.Request().Form["bibliography-required[]"]
-		draft.BibliographyRecommended = c.Request().Form["bibliography-recommended[]"]
+		// Update arrays (sections locked by a department template keep their content)
+		if !draft.IsLocked("courseRequirements") {
+			draft.CourseRequirements = c.Request().Form["course-requirements[]"]
+		}
+		if !draft.IsLocked("learningOutcomes") {
+			draft.LearningOutcomes = c.Request().Form["learning-outcomes[]"]
+		}
+		if !draft.IsLocked("courseObjectives") {
+			draft.CourseObjectives = c.Request().Form["course-objectives[]"]
+		}
+		if !draft.IsLocked("bibliographyRequired") {
+			draft.BibliographyRequired = bibliographyFromForm(c, UIcomponents.BibliographyRequired)
+		}
+		if !draft.IsLocked("bibliographyRecommended") {
+			draft.BibliographyRecommended = bibliographyFromForm(c, UIcomponents.BibliographyRecommended)
+		}
+		if style := c.FormValue("citation-style"); style != "" {
+			draft.CitationStyle = bibliography.ParseStyle(style)
+		}
 	}
 
 	// Save the draft as a syllabus with "Draft" status
-	// First, marshal the draft to JSON
-	jsonData, err := json.Marshal(draft)
-	if err != nil {
-		c.Logger().Error("Error marshaling draft: ", err)
-		return c.String(http.StatusInternalServerError, "Error processing syllabus data")
-	}
-
 	// Check if this syllabus already exists in the database
 	var existingSyllabus *types.Syllabus
 	if draft.ID > 0 {
@@ -170,11 +308,27 @@ func handleSaveSyllabus(c echo.Context, repo *repository.Repository) error {
 
 	now := time.Now()
 
+	// Attach the syllabus to the offering of its course in the chosen term
+	offeringID, err := repo.OfferingForDraft(courseID, userID, draft)
+	if err != nil {
+		c.Logger().Error("Error resolving course offering: ", err)
+		return c.String(http.StatusInternalServerError, "Error saving syllabus")
+	}
+	draft.KeepTerm(offeringID)
+
+	// Marshal the draft to JSON
+	jsonData, err := draft.Marshal()
+	if err != nil {
+		c.Logger().Error("Error marshaling draft: ", err)
+		return c.String(http.StatusInternalServerError, "Error processing syllabus data")
+	}
+
 	if existingSyllabus != nil {
 		// Update the existing syllabus
 		existingSyllabus.CourseID = courseID
+		existingSyllabus.OfferingID = offeringID
 		existingSyllabus.LecturerID = userID // Set the lecturer ID to the current user's ID
-		existingSyllabus.Status = "Draft"
+		existingSyllabus.Status = status.Draft
 		existingSyllabus.UpdatedAt = now
 		existingSyllabus.Data = jsonData
 
@@ -188,11 +342,12 @@ func handleSaveSyllabus(c echo.Context, repo *repository.Repository) error {
 		syl := types.Syllabus{
 			CourseID:       courseID,
 			LecturerID:     userID,
-			Status:         "Draft",
+			Status:         status.Draft,
 			SubmissionDate: now,
 			CreatedAt:      now,
 			UpdatedAt:      now,
 			Data:           jsonData,
+			OfferingID:     offeringID,
 		}
 
 		// Create the syllabus in the database
@@ -269,19 +424,32 @@ func handleSubmitSyllabus(c echo.Context, repo *repository.Repository) error {
 		c.Logger().Error("Error getting user draft: ", err)
 		return c.String(http.StatusInternalServerError, "Error getting user draft")
 	}
+	mid.LogSyllabus(c, draft.ID)
+
+	// Problems are shown in the language the form edits.
+	draft.ShowLanguage(draft.Language)
+
+	// The grade composition must be valid before the syllabus goes to review.
+	if problems := draft.GradeProblems(); len(problems) > 0 {
+		draft.GradeNote = draft.Label("לא ניתן לשלוח לפני תיקון הרכב הציון")
+		c.Response().Header().Set("HX-Retarget", "#form-grade-components-list")
+		c.Response().Header().Set("HX-Reswap", "outerHTML")
+		return c.Render(http.StatusOK, "gradeComponents", draft)
+	}
+
+	// Every learning outcome must be assessed before the syllabus goes to review.
+	if unassessed := draft.UnassessedOutcomes(); len(unassessed) > 0 {
+		draft.OutcomeNote = draft.Label("לא ניתן לשלוח: יש לקשר כל תוצר למידה לרכיב ציון או לשיעור שבו הוא נבדק (%s)", strings.Join(unassessed, "; "))
+		c.Response().Header().Set("HX-Retarget", "#outcome-matrix")
+		c.Response().Header().Set("HX-Reswap", "outerHTML")
+		return renderOutcomeMatrix(c, repo, draft)
+	}
 
 	//// Validate that all required fields are filled
 	//if hasEmptyFields(draft) {
 	//	return c.String(http.StatusBadRequest, "some fields are empty, can't send")
 	//}
 
-	// Marshal the draft to JSON
-	jsonData, err := json.Marshal(draft)
-	if err != nil {
-		c.Logger().Error("Error marshaling draft: ", err)
-		return c.String(http.StatusInternalServerError, "Error processing syllabus data")
-	}
-
 	// Look up the CourseID from the available courses
 	courseID := 0
 	courses, err := repo.GetAllCourses()
@@ -297,20 +465,39 @@ func handleSubmitSyllabus(c echo.Context, repo *repository.Repository) error {
 		}
 	}
 
-	// Update the existing draft syllabus to "In Review" status
+	// Attach the syllabus to the offering of its course in the chosen term
 	now := time.Now()
+	offeringID, err := repo.OfferingForDraft(courseID, userID, draft)
+	if err != nil {
+		c.Logger().Error("Error resolving course offering: ", err)
+		return c.String(http.StatusInternalServerError, "Error updating syllabus")
+	}
+	draft.KeepTerm(offeringID)
+
+	// Marshal the draft to JSON
+	jsonData, err := draft.Marshal()
+	if err != nil {
+		c.Logger().Error("Error marshaling draft: ", err)
+		return c.String(http.StatusInternalServerError, "Error processing syllabus data")
+	}
+
+	// Update the existing draft syllabus to "In Review" status
 	syl := types.Syllabus{
 		ID:             draft.ID,
 		CourseID:       courseID,
 		LecturerID:     userID,
-		Status:         "In Review", // Change status from "Draft" to "In Review"
+		Status:         status.InReview,
 		SubmissionDate: now,
 		UpdatedAt:      now,
 		Data:           jsonData,
+		OfferingID:     offeringID,
 	}
 
 	// Update the syllabus in the database
 	err = repo.UpdateSyllabus(&syl)
+	if errors.Is(err, status.ErrTransition) {
+		return c.String(http.StatusConflict, "This syllabus cannot be submitted for review")
+	}
 	if err != nil {
 		c.Logger().Error("Error updating syllabus: ", err)
 		return c.String(http.StatusInternalServerError, "Error updating syllabus")
@@ -320,47 +507,136 @@ func handleSubmitSyllabus(c echo.Context, repo *repository.Repository) error {
 	return c.String(http.StatusOK, "Redirecting...")
 }
 
-func removeBibliographyRecommended(c echo.Context, draft *UIcomponents.Draft) error {
-	indexStr := c.FormValue("index")
-	if index, err := strconv.Atoi(indexStr); err == nil && index >= 0 && index < len(draft.BibliographyRecommended) {
-		draft.BibliographyRecommended = append(draft.BibliographyRecommended[:index], draft.BibliographyRecommended[index+1:]...)
+// draftBibliography returns the required or recommended bibliography list of a
+// draft together with the partial template that renders it.
+func draftBibliography(draft *UIcomponents.Draft, kind string) (*[]bibliography.Entry, string) {
+	if kind == UIcomponents.BibliographyRecommended {
+		return &draft.BibliographyRecommended, "bibliographyRecommended"
 	}
-	return c.Render(http.StatusOK, "bibliographyRecommended", draft)
+	return &draft.BibliographyRequired, "bibliographyRequired"
 }
 
-func addBibliographyRecommended(c echo.Context, draft *UIcomponents.Draft) error {
+// bibliographyFromForm reads a bibliography list posted with the form. Every
+// entry posts all its inputs, named after the list ("bib-required-title[]"),
+// so the values of an entry share an index.
+func bibliographyFromForm(c echo.Context, kind string) []bibliography.Entry {
+	form := c.Request().Form
+	field := func(name string, i int) string {
+		values := form["bib-"+kind+"-"+name+"[]"]
+		if i < len(values) {
+			return strings.TrimSpace(values[i])
+		}
+		return ""
+	}
+
+	titles := form["bib-"+kind+"-title[]"]
+	if titles == nil {
+		return nil
+	}
+	entries := make([]bibliography.Entry, 0, len(titles))
+	for i := range titles {
+		entries = append(entries, bibliography.Entry{
+			Type:      field("type", i),
+			Authors:   bibliography.SplitAuthors(field("authors", i)),
+			Title:     field("title", i),
+			Year:      field("year", i),
+			Publisher: field("publisher", i),
+			ISBN:      field("isbn", i),
+			DOI:       bibliography.NormalizeDOI(field("doi", i)),
+			URL:       field("url", i),
+			Language:  field("language", i),
+		})
+	}
+	return entries
+}
+
+func addBibliographyEntry(c echo.Context, draft *UIcomponents.Draft, kind string) error {
 	if err := c.Request().ParseForm(); err != nil {
 		return err
 	}
-	draft.BibliographyRecommended = c.Request().Form["bibliography-recommended[]"]
-	draft.BibliographyRecommended = append(draft.BibliographyRecommended, "")
-	return c.Render(http.StatusOK, "bibliographyRecommended", draft)
+	list, partial := draftBibliography(draft, kind)
+	*list = append(bibliographyFromForm(c, kind), bibliography.Entry{Type: bibliography.TypeBook})
+	return c.Render(http.StatusOK, partial, draft)
 }
 
-func removeBibliographyRequired(c echo.Context, draft *UIcomponents.Draft) error {
-	indexStr := c.FormValue("index")
-	if index, err := strconv.Atoi(indexStr); err == nil && index >= 0 && index < len(draft.BibliographyRequired) {
-		draft.BibliographyRequired = append(draft.BibliographyRequired[:index], draft.BibliographyRequired[index+1:]...)
+func removeBibliographyEntry(c echo.Context, draft *UIcomponents.Draft, kind string) error {
+	list, partial := draftBibliography(draft, kind)
+	if index, err := strconv.Atoi(c.FormValue("index")); err == nil && index >= 0 && index < len(*list) {
+		*list = append((*list)[:index], (*list)[index+1:]...)
 	}
-	return c.Render(http.StatusOK, "bibliographyRequired", draft)
+	return c.Render(http.StatusOK, partial, draft)
 }
 
-func addBibliographyRequired(c echo.Context, draft *UIcomponents.Draft) error {
+// importBibliography appends the entries of the BibTeX or RIS pasted under a
+// bibliography list to it, dropping the list's blank entries.
+func importBibliography(c echo.Context, draft *UIcomponents.Draft, kind string) error {
 	if err := c.Request().ParseForm(); err != nil {
 		return err
 	}
-	// Update the slice based on current form values
-	draft.BibliographyRequired = c.Request().Form["bibliography-required[]"]
-	// Add a new empty entry
-	draft.BibliographyRequired = append(draft.BibliographyRequired, "")
-	// Render only the partial template for required bibliography
-	return c.Render(http.StatusOK, "bibliographyRequired", draft)
+	list, partial := draftBibliography(draft, kind)
+	*list = bibliographyFromForm(c, kind)
+
+	imported, err := bibliography.Parse(c.FormValue("bib-" + kind + "-import"))
+	switch {
+	case err != nil:
+		c.Logger().Warn("Bibliography import failed: ", err)
+		draft.BibliographyNote = draft.Label("לא ניתן לקרוא את הרשומות. יש להדביק רשומות BibTeX או RIS.")
+	case len(imported) == 0:
+		draft.BibliographyNote = draft.Label("לא נמצאו רשומות לייבוא.")
+	default:
+		entries := make([]bibliography.Entry, 0, len(*list)+len(imported))
+		for _, e := range *list {
+			if !e.IsEmpty() {
+				entries = append(entries, e)
+			}
+		}
+		*list = append(entries, imported...)
+	}
+	return c.Render(http.StatusOK, partial, draft)
+}
+
+// lookupBibliographyEntry completes the entry at "index" of a bibliography
+// list with the details the library catalog holds for its ISBN or DOI.
+func lookupBibliographyEntry(c echo.Context, draft *UIcomponents.Draft, kind string) error {
+	if err := c.Request().ParseForm(); err != nil {
+		return err
+	}
+	list, partial := draftBibliography(draft, kind)
+	*list = bibliographyFromForm(c, kind)
+
+	index, err := strconv.Atoi(c.FormValue("index"))
+	if err != nil || index < 0 || index >= len(*list) {
+		return c.Render(http.StatusOK, partial, draft)
+	}
+	entry := (*list)[index]
+	if entry.Identifier() == "" {
+		draft.BibliographyNote = draft.Label("יש להזין ISBN או DOI כדי לחפש בקטלוג.")
+		return c.Render(http.StatusOK, partial, draft)
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 10*time.Second)
+	defer cancel()
+	resolved, err := resolver.Resolve(ctx, entry.Identifier())
+	switch {
+	case errors.Is(err, bibliography.ErrNotFound):
+		draft.BibliographyNote = draft.Label("לא נמצא פריט עם מזהה זה בקטלוג הספרייה.")
+	case err != nil:
+		c.Logger().Warn("Bibliography lookup failed: ", err)
+		draft.BibliographyNote = draft.Label("קטלוג הספרייה אינו זמין כעת. יש לנסות שוב מאוחר יותר.")
+	default:
+		(*list)[index] = entry.Merge(resolved)
+	}
+	return c.Render(http.StatusOK, partial, draft)
 }
 
 func removeAssignmentStructure(c echo.Context, draft *UIcomponents.Draft) error {
 	indexStr := c.FormValue("index")
 	if index, err := strconv.Atoi(indexStr); err == nil && index >= 0 && index < len(draft.AssignmentsStructure) {
 		draft.AssignmentsStructure = append(draft.AssignmentsStructure[:index], draft.AssignmentsStructure[index+1:]...)
+		draft.RemoveTranslated(UIcomponents.ListAssignmentsStructure, index)
+		if index < len(draft.AssignmentHours) {
+			draft.AssignmentHours = append(draft.AssignmentHours[:index], draft.AssignmentHours[index+1:]...)
+		}
 	}
 	return c.Render(http.StatusOK, "assignmentsStructure", draft)
 }
@@ -369,12 +645,26 @@ func addAssignmentStructure(c echo.Context, draft *UIcomponents.Draft) error {
 	if err := c.Request().ParseForm(); err != nil {
 		return err
 	}
-	draft.AssignmentsStructure = c.Request().Form["assignments-structure[]"]
+	assignmentsFromForm(c, draft)
 	// Append an empty entry for a new assignment.
 	draft.AssignmentsStructure = append(draft.AssignmentsStructure, "")
+	draft.AssignmentHours = append(draft.AssignmentHours, 0)
 	return c.Render(http.StatusOK, "assignmentsStructure", draft)
 }
 
+// assignmentsFromForm reads the assignments posted with the form together with
+// their estimated hours, which share their index.
+func assignmentsFromForm(c echo.Context, draft *UIcomponents.Draft) {
+	draft.AssignmentsStructure = c.Request().Form["assignments-structure[]"]
+	hours := c.Request().Form["assignment-hours[]"]
+	draft.AssignmentHours = make([]float64, len(draft.AssignmentsStructure))
+	for i := range draft.AssignmentHours {
+		if i < len(hours) {
+			draft.AssignmentHours[i], _ = workload.ParseHours(hours[i])
+		}
+	}
+}
+
 func removeGradeComponent(c echo.Context, draft *UIcomponents.Draft) error {
 	indexStr := c.FormValue("index")
 	if index, err := strconv.Atoi(indexStr); err == nil && index >= 0 && index < len(draft.GradeComponents) {
@@ -387,26 +677,46 @@ func addGradeComponent(c echo.Context, draft *UIcomponents.Draft) error {
 	if err := c.Request().ParseForm(); err != nil {
 		return err
 	}
-	names := c.Request().Form["grade-component-name[]"]
-	percents := c.Request().Form["grade-component-percentage[]"]
-	var comps []UIcomponents.GradeComponent
-	count := len(names)
-	for i := 0; i < count; i++ {
-		comps = append(comps, UIcomponents.GradeComponent{
-			PartName:   names[i],
-			Percentage: percents[i],
-		})
-	}
-	draft.GradeComponents = comps
 	// Append an extra empty row.
-	draft.GradeComponents = append(draft.GradeComponents, UIcomponents.GradeComponent{})
+	components := gradeComponentsFromForm(c)
+	draft.RelinkAssessments(draft.GradeComponents, components)
+	draft.GradeComponents = append(components, grading.Component{})
 	return c.Render(http.StatusOK, "gradeComponents", draft)
 }
 
+// gradeComponentsFromForm reads the grade components posted with the form. Every
+// row posts all its inputs, so the values of a component share an index.
+func gradeComponentsFromForm(c echo.Context) []grading.Component {
+	form := c.Request().Form
+	field := func(name string, i int) string {
+		values := form["grade-component-"+name+"[]"]
+		if i < len(values) {
+			return strings.TrimSpace(values[i])
+		}
+		return ""
+	}
+
+	names := form["grade-component-name[]"]
+	comps := make([]grading.Component, 0, len(names))
+	for i := range names {
+		comp := grading.Component{
+			PartName: field("name", i),
+			Type:     field("type", i),
+		}
+		comp.Weight, _ = grading.ParseWeight(field("weight", i))
+		comp.MinPass, _ = grading.ParseWeight(field("min-pass", i))
+		comp.BestOf, _ = strconv.Atoi(field("best-of", i))
+		comp.OutOf, _ = strconv.Atoi(field("out-of", i))
+		comps = append(comps, comp)
+	}
+	return comps
+}
+
 func removeCourseObjective(c echo.Context, draft *UIcomponents.Draft) error {
 	indexStr := c.FormValue("index")
 	if index, err := strconv.Atoi(indexStr); err == nil && index >= 0 && index < len(draft.CourseObjectives) {
 		draft.CourseObjectives = append(draft.CourseObjectives[:index], draft.CourseObjectives[index+1:]...)
+		draft.RemoveTranslated(UIcomponents.ListCourseObjectives, index)
 	}
 	return c.Render(http.StatusOK, "courseObjectives", draft)
 
@@ -425,6 +735,8 @@ func removeLearningOutcome(c echo.Context, draft *UIcomponents.Draft) error {
 	indexStr := c.FormValue("index")
 	if index, err := strconv.Atoi(indexStr); err == nil && index >= 0 && index < len(draft.LearningOutcomes) {
 		draft.LearningOutcomes = append(draft.LearningOutcomes[:index], draft.LearningOutcomes[index+1:]...)
+		draft.RemoveOutcomeLinks(index)
+		draft.RemoveTranslated(UIcomponents.ListLearningOutcomes, index)
 	}
 	return c.Render(http.StatusOK, "learningOutcomes", draft)
 }
@@ -438,7 +750,7 @@ func addLearningOutcome(c echo.Context, draft *UIcomponents.Draft) error {
 	return c.Render(http.StatusOK, "learningOutcomes", draft)
 }
 
-func insertSyllabusRow(c echo.Context, draft *UIcomponents.Draft) error {
+func insertSyllabusRow(c echo.Context, repo *repository.Repository, draft *UIcomponents.Draft) error {
 	// Get the insertion index from the form values.
 	indexStr := c.FormValue("index")
 	insertIndex, err := strconv.Atoi(indexStr)
@@ -446,23 +758,8 @@ func insertSyllabusRow(c echo.Context, draft *UIcomponents.Draft) error {
 		return err
 	}
 	// Update the current rows from the form data.
-	lessonNumbers := c.Request().Form["lesson-number[]"]
-	mainTopics := c.Request().Form["main-topic[]"]
-	lessonTopics := c.Request().Form["lesson-topics[]"]
-	subtopics := c.Request().Form["subtopics[]"]
-	readingMaterials := c.Request().Form["reading-material[]"]
-
-	var rows []UIcomponents.SyllabusRow
-	count := len(lessonNumbers)
-	for i := 0; i < count; i++ {
-		rows = append(rows, UIcomponents.SyllabusRow{
-			LessonNumber:    lessonNumbers[i],
-			MainTopic:       mainTopics[i],
-			LessonTopics:    lessonTopics[i],
-			Subtopics:       subtopics[i],
-			ReadingMaterial: readingMaterials[i],
-		})
-	}
+	rows := syllabusRowsFromForm(c)
+	draft.RelinkLessons(draft.SyllabusRows, rows)
 	// Create a new empty row.
 	newRow := UIcomponents.SyllabusRow{}
 	// Insert the new row after the specified index.
@@ -472,10 +769,133 @@ func insertSyllabusRow(c echo.Context, draft *UIcomponents.Draft) error {
 		rows = append(rows[:insertIndex+1], append([]UIcomponents.SyllabusRow{newRow}, rows[insertIndex+1:]...)...)
 	}
 	draft.SyllabusRows = rows
+	draft.InsertTranslated(UIcomponents.ListSyllabusRows, insertIndex+1)
+	syncLessonDates(c, repo, draft)
+	return c.Render(http.StatusOK, "syllabusRows", draft)
+}
+
+// syllabusRowsFromForm reads the syllabus table rows posted with the form.
+// Lesson dates are not part of the form; syncLessonDates restores them.
+func syllabusRowsFromForm(c echo.Context) []UIcomponents.SyllabusRow {
+	form := c.Request().Form
+	// A row missing from one of the lists, as a tampered form may post, is
+	// read as empty.
+	field := func(name string, i int) string {
+		values := form[name+"[]"]
+		if i < len(values) {
+			return values[i]
+		}
+		return ""
+	}
+
+	var rows []UIcomponents.SyllabusRow
+	for i := range form["lesson-number[]"] {
+		row := UIcomponents.SyllabusRow{
+			LessonNumber:    field("lesson-number", i),
+			MainTopic:       field("main-topic", i),
+			LessonTopics:    field("lesson-topics", i),
+			Subtopics:       field("subtopics", i),
+			ReadingMaterial: field("reading-material", i),
+		}
+		row.StudyHours, _ = workload.ParseHours(field("study-hours", i))
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// draftLessons computes the dated lessons of a draft from its term and meeting
+// days. It returns a note for the lecturer when the schedule cannot be computed.
+func draftLessons(repo *repository.Repository, draft *UIcomponents.Draft) ([]utils.Lesson, string, error) {
+	if draft.TermID == 0 {
+		return nil, draft.Label("יש לבחור סמסטר כדי ליצור לוח שיעורים"), nil
+	}
+	var days []time.Weekday
+	for _, letter := range draft.MeetingDays {
+		if day, ok := utils.ParseHebrewWeekday(letter); ok {
+			days = append(days, day)
+		}
+	}
+	if len(days) == 0 {
+		return nil, draft.Label("יש לבחור ימי מפגש כדי ליצור לוח שיעורים"), nil
+	}
+
+	term, err := repo.GetTermByID(draft.TermID)
+	if err != nil {
+		return nil, "", err
+	}
+	holidays, err := repo.GetHolidays(term.StartDate, term.EndDate)
+	if err != nil {
+		return nil, "", err
+	}
+	return utils.LessonSchedule(term.StartDate, term.EndDate, days, holidays), "", nil
+}
+
+// applyLessonDates gives the rows the dates of the lessons in order; rows
+// beyond the last lesson are left without a date.
+func applyLessonDates(rows []UIcomponents.SyllabusRow, lessons []utils.Lesson) {
+	for i := range rows {
+		rows[i].Date, rows[i].Holiday = "", ""
+		if i < len(lessons) {
+			rows[i].Date = lessons[i].Date.Format("2006-01-02")
+			rows[i].Holiday = lessons[i].Holiday
+		}
+	}
+}
+
+// syncLessonDates re-dates the rows of a draft whose schedule was generated,
+// after rows were inserted or removed.
+func syncLessonDates(c echo.Context, repo *repository.Repository, draft *UIcomponents.Draft) {
+	if len(draft.MeetingDays) == 0 {
+		return
+	}
+	lessons, note, err := draftLessons(repo, draft)
+	if err != nil {
+		c.Logger().Error("Error computing lesson schedule: ", err)
+		return
+	}
+	if note != "" {
+		return
+	}
+	applyLessonDates(draft.SyllabusRows, lessons)
+}
+
+// generateSchedule creates a dated row for every lesson of the term on the
+// chosen meeting days, keeping the content already entered in the table.
+func generateSchedule(c echo.Context, repo *repository.Repository, draft *UIcomponents.Draft) error {
+	if rows := syllabusRowsFromForm(c); len(rows) > 0 {
+		draft.RelinkLessons(draft.SyllabusRows, rows)
+		draft.SyllabusRows = rows
+	}
+	draft.MeetingDays = c.Request().Form["meeting-days"]
+
+	lessons, note, err := draftLessons(repo, draft)
+	if err != nil {
+		c.Logger().Error("Error computing lesson schedule: ", err)
+		return c.String(http.StatusInternalServerError, "Error generating schedule")
+	}
+	draft.ScheduleNote = note
+	if note != "" {
+		return c.Render(http.StatusOK, "syllabusRows", draft)
+	}
+
+	rows := draft.SyllabusRows
+	if len(rows) == 1 && rows[0] == (UIcomponents.SyllabusRow{}) {
+		rows = nil
+	}
+	for len(rows) < len(lessons) {
+		rows = append(rows, UIcomponents.SyllabusRow{})
+	}
+	for i := range rows {
+		if strings.TrimSpace(rows[i].LessonNumber) == "" {
+			rows[i].LessonNumber = strconv.Itoa(i + 1)
+		}
+	}
+	applyLessonDates(rows, lessons)
+	draft.SyllabusRows = rows
 	return c.Render(http.StatusOK, "syllabusRows", draft)
 }
 
-func removeSyllabusRow(c echo.Context, draft *UIcomponents.Draft) error {
+func removeSyllabusRow(c echo.Context, repo *repository.Repository, draft *UIcomponents.Draft) error {
 	// Prevent deletion if there's only one row left
 	if len(draft.SyllabusRows) <= 1 {
 		return c.Render(http.StatusOK, "syllabusRows", draft) // No changes, just re-render
@@ -484,33 +904,20 @@ func removeSyllabusRow(c echo.Context, draft *UIcomponents.Draft) error {
 	indexStr := c.FormValue("index")
 	if index, err := strconv.Atoi(indexStr); err == nil && index >= 0 && index < len(draft.SyllabusRows) {
 		draft.SyllabusRows = append(draft.SyllabusRows[:index], draft.SyllabusRows[index+1:]...)
+		draft.RemoveTranslated(UIcomponents.ListSyllabusRows, index)
 	}
+	syncLessonDates(c, repo, draft)
 	return c.Render(http.StatusOK, "syllabusRows", draft)
 }
 
-func updateSyllabusRow(c echo.Context, draft *UIcomponents.Draft) error {
+func updateSyllabusRow(c echo.Context, repo *repository.Repository, draft *UIcomponents.Draft) error {
 	if err := c.Request().ParseForm(); err != nil {
 		return err
 	}
-	lessonNumbers := c.Request().Form["lesson-number[]"]
-	mainTopics := c.Request().Form["main-topic[]"]
-	lessonTopics := c.Request().Form["lesson-topics[]"]
-	subtopics := c.Request().Form["subtopics[]"]
-	readingMaterials := c.Request().Form["reading-material[]"]
-
-	var rows []UIcomponents.SyllabusRow
-	count := len(lessonNumbers)
-	for i := 0; i < count; i++ {
-		var rows []UIcomponents.SyllabusRow
-		rows = append(rows, UIcomponents.SyllabusRow{
-			LessonNumber:    lessonNumbers[i],
-			MainTopic:       mainTopics[i],
-			LessonTopics:    lessonTopics[i],
-			Subtopics:       subtopics[i],
-			ReadingMaterial: readingMaterials[i],
-		})
-	}
+	rows := syllabusRowsFromForm(c)
+	draft.RelinkLessons(draft.SyllabusRows, rows)
 	draft.SyllabusRows = rows
+	syncLessonDates(c, repo, draft)
 	return c.Render(http.StatusOK, "syllabusRows", draft)
 }
 
@@ -518,6 +925,7 @@ func removeCourseRequirement(c echo.Context, draft *UIcomponents.Draft) error {
 	indexStr := c.FormValue("index")
 	if index, err := strconv.Atoi(indexStr); err == nil && index >= 0 && index < len(draft.CourseRequirements) {
 		draft.CourseRequirements = append(draft.CourseRequirements[:index], draft.CourseRequirements[index+1:]...)
+		draft.RemoveTranslated(UIcomponents.ListCourseRequirements, index)
 	}
 	return c.Render(http.StatusOK, "courseRequirements", draft)
 }
@@ -531,15 +939,69 @@ func addCourseRequirement(c echo.Context, draft *UIcomponents.Draft) error {
 	return c.Render(http.StatusOK, "courseRequirements", draft)
 }
 
+// actionSections maps list actions to the form section (and partial template) they modify.
+var actionSections = map[string]string{
+	"addCourseRequirement":          "courseRequirements",
+	"removeCourseRequirement":       "courseRequirements",
+	"addLearningOutcome":            "learningOutcomes",
+	"removeLearningOutcome":         "learningOutcomes",
+	"addCourseObjective":            "courseObjectives",
+	"removeCourseObjective":         "courseObjectives",
+	"addGradeComponent":             "gradeComponents",
+	"removeGradeComponent":          "gradeComponents",
+	"addAssignmentStructure":        "assignmentsStructure",
+	"removeAssignmentStructure":     "assignmentsStructure",
+	"addBibliographyRequired":       "bibliographyRequired",
+	"removeBibliographyRequired":    "bibliographyRequired",
+	"importBibliographyRequired":    "bibliographyRequired",
+	"lookupBibliographyRequired":    "bibliographyRequired",
+	"addBibliographyRecommended":    "bibliographyRecommended",
+	"removeBibliographyRecommended": "bibliographyRecommended",
+	"importBibliographyRecommended": "bibliographyRecommended",
+	"lookupBibliographyRecommended": "bibliographyRecommended",
+}
+
+// refreshEvents are the events that tell form sections computed from other
+// sections to refresh, with the actions and fields that change them.
+var refreshEvents = []struct {
+	name   string
+	inputs map[string]bool
+}{
+	{"outcomesChanged", outcomeMatrixInputs},
+	{"workloadChanged", workloadInputs},
+	{"prerequisitesChanged", prerequisiteInputs},
+}
+
 func updateSyllabusHandler(c echo.Context) error {
 	userID, _ := mid.GetUserID(c)
+	repo := audited(c, &r)
 
 	// Get the user's draft from the database
-	draft, err := r.GetUserDraft(userID)
+	draft, err := repo.GetUserDraft(userID)
 	if err != nil {
 		c.Logger().Error("Error getting user draft: ", err)
 		return c.String(http.StatusInternalServerError, "Error getting user draft")
 	}
+	mid.LogSyllabus(c, draft.ID)
+
+	// The form edits the text in the language chosen in it.
+	draft.ShowLanguage(draft.Language)
+
+	// Sections locked by a department template are re-rendered unchanged.
+	if section, ok := actionSections[c.FormValue("action")]; ok && draft.IsLocked(section) {
+		return c.Render(http.StatusOK, section, draft)
+	}
+
+	// Tell the sections computed from other sections to refresh.
+	var events []string
+	for _, e := range refreshEvents {
+		if e.inputs[c.FormValue("action")] || e.inputs[c.FormValue("updateField")] {
+			events = append(events, e.name)
+		}
+	}
+	if len(events) > 0 {
+		c.Response().Header().Set("HX-Trigger", strings.Join(events, ", "))
+	}
 
 	var result error
 
@@ -549,11 +1011,17 @@ func updateSyllabusHandler(c echo.Context) error {
 	case "removeCourseRequirement":
 		result = removeCourseRequirement(c, draft)
 	case "updateSyllabusRow":
-		result = updateSyllabusRow(c, draft)
+		result = updateSyllabusRow(c, repo, draft)
 	case "removeSyllabusRow":
-		result = removeSyllabusRow(c, draft)
+		result = removeSyllabusRow(c, repo, draft)
 	case "insertSyllabusRow":
-		result = insertSyllabusRow(c, draft)
+		result = insertSyllabusRow(c, repo, draft)
+	case "generateSchedule":
+		result = generateSchedule(c, repo, draft)
+	case "addPrerequisite":
+		result = addPrerequisite(c, repo, draft)
+	case "removePrerequisite":
+		result = removePrerequisite(c, repo, draft)
 	case "addLearningOutcome":
 		result = addLearningOutcome(c, draft)
 	case "removeLearningOutcome":
@@ -571,19 +1039,27 @@ func updateSyllabusHandler(c echo.Context) error {
 	case "removeAssignmentStructure":
 		result = removeAssignmentStructure(c, draft)
 	case "addBibliographyRequired":
-		result = addBibliographyRequired(c, draft)
+		result = addBibliographyEntry(c, draft, UIcomponents.BibliographyRequired)
 	case "removeBibliographyRequired":
-		result = removeBibliographyRequired(c, draft)
+		result = removeBibliographyEntry(c, draft, UIcomponents.BibliographyRequired)
+	case "importBibliographyRequired":
+		result = importBibliography(c, draft, UIcomponents.BibliographyRequired)
+	case "lookupBibliographyRequired":
+		result = lookupBibliographyEntry(c, draft, UIcomponents.BibliographyRequired)
 	case "addBibliographyRecommended":
-		result = addBibliographyRecommended(c, draft)
+		result = addBibliographyEntry(c, draft, UIcomponents.BibliographyRecommended)
 	case "removeBibliographyRecommended":
-		result = removeBibliographyRecommended(c, draft)
+		result = removeBibliographyEntry(c, draft, UIcomponents.BibliographyRecommended)
+	case "importBibliographyRecommended":
+		result = importBibliography(c, draft, UIcomponents.BibliographyRecommended)
+	case "lookupBibliographyRecommended":
+		result = lookupBibliographyEntry(c, draft, UIcomponents.BibliographyRecommended)
 	default:
-		result = handleGeneralUpdate(c, draft)
+		result = handleGeneralUpdate(c, repo, draft)
 	}
 
 	// Save the updated draft to the database
-	if err := r.SaveUserDraft(userID, draft); err != nil {
+	if err := repo.SaveUserDraft(userID, draft); err != nil {
 		c.Logger().Error("Error saving user draft: ", err)
 		return c.String(http.StatusInternalServerError, "Error saving user draft")
 	}
@@ -591,28 +1067,50 @@ func updateSyllabusHandler(c echo.Context) error {
 	return result
 }
 
-func handleGeneralUpdate(c echo.Context, draft *UIcomponents.Draft) error {
-	c.Logger().Print(draft)
+func handleGeneralUpdate(c echo.Context, repo *repository.Repository, draft *UIcomponents.Draft) error {
 	updateField := c.FormValue("updateField")
+	c.Logger().Debug("Updating draft field ", updateField)
+
+	// Locked template sections share their name with the partial that renders them.
+	if draft.IsLocked(updateField) {
+		return c.Render(http.StatusOK, updateField, draft)
+	}
+
 	switch updateField {
+	case "language":
+		draft.Language = UIcomponents.ParseLanguage(c.FormValue("language")).Code
+		draft.ShowLanguage(draft.Language)
+		populateDraftOptions(draft, repo)
+		return c.Render(http.StatusOK, "create-syllabus", draft)
 	case "syllabus-department":
-		c.Logger().Print(c.FormValue("syllabus-department"))
 		draft.SyllabusDepartment = c.FormValue("syllabus-department")
 		return c.Render(http.StatusOK, "syllabusDepartment", draft)
 
 	case "bibliographyRequired":
 		if err := c.Request().ParseForm(); err == nil {
-			draft.BibliographyRequired = c.Request().Form["bibliography-required[]"]
+			draft.BibliographyRequired = bibliographyFromForm(c, UIcomponents.BibliographyRequired)
 		}
 		return c.Render(http.StatusOK, "bibliographyRequired", draft)
 	case "bibliographyRecommended":
 		if err := c.Request().ParseForm(); err == nil {
-			draft.BibliographyRecommended = c.Request().Form["bibliography-recommended[]"]
+			draft.BibliographyRecommended = bibliographyFromForm(c, UIcomponents.BibliographyRecommended)
 		}
 		return c.Render(http.StatusOK, "bibliographyRecommended", draft)
+	case "outcomeMatrix":
+		return updateOutcomeMatrix(c, repo, draft)
+	case "outcomeMatrixRefresh":
+		return renderOutcomeMatrix(c, repo, draft)
+	case "workloadRefresh":
+		return renderWorkload(c, repo, draft)
+	case "prerequisitesRefresh":
+		return renderPrerequisites(c, repo, draft)
+	case "citationStyle":
+		draft.CitationStyle = bibliography.ParseStyle(c.FormValue("citation-style"))
 	case "course-dropdown":
 		draft.SelectedCourse = c.FormValue("course-dropdown")
 		return c.Render(http.StatusOK, "coursesDropdown", draft)
+	case "courseName":
+		draft.CourseName = strings.TrimSpace(c.FormValue("course-name"))
 	case "LecturerName":
 		draft.LecturerName = c.FormValue("LecturerName")
 	case "LecturerEmail":
@@ -629,9 +1127,19 @@ func handleGeneralUpdate(c echo.Context, draft *UIcomponents.Draft) error {
 		draft.WeeklyHours = c.FormValue("weekly-hours")
 	case "year":
 		draft.Year = c.FormValue("year")
-	case "semester":
-		draft.Semester = c.FormValue("semester")
+	case "term":
+		if err := setDraftTerm(draft, repo, c.FormValue("term-id")); err != nil {
+			c.Logger().Error("Error setting syllabus term: ", err)
+			return c.String(http.StatusBadRequest, "Invalid term")
+		}
+	case "section":
+		draft.Section = strings.TrimSpace(c.FormValue("section"))
+	case "meetingDays":
+		if err := c.Request().ParseForm(); err == nil {
+			draft.MeetingDays = c.Request().Form["meeting-days"]
+		}
 	case "prerequisites":
+		return updatePrerequisiteText(c, repo, draft)
 	case "learningOutcomes":
 		if err := c.Request().ParseForm(); err == nil {
 			draft.LearningOutcomes = c.Request().Form["learning-outcomes[]"]
@@ -666,21 +1174,17 @@ func handleGeneralUpdate(c echo.Context, draft *UIcomponents.Draft) error {
 		return c.Render(http.StatusOK, "otherCourseInput", draft)
 
 	case "gradeComponents":
-		// New case for grade composition update.
 		if err := c.Request().ParseForm(); err == nil {
-			names := c.Request().Form["grade-component-name[]"]
-			percents := c.Request().Form["grade-component-percentage[]"]
-			var comps []UIcomponents.GradeComponent
-			count := len(names)
-			for i := 0; i < count; i++ {
-				comps = append(comps, UIcomponents.GradeComponent{
-					PartName:   names[i],
-					Percentage: percents[i],
-				})
-			}
-			draft.GradeComponents = comps
+			components := gradeComponentsFromForm(c)
+			draft.RelinkAssessments(draft.GradeComponents, components)
+			draft.GradeComponents = components
 		}
 		return c.Render(http.StatusOK, "gradeComponents", draft)
+	case "assignmentsStructure":
+		if err := c.Request().ParseForm(); err == nil {
+			assignmentsFromForm(c, draft)
+		}
+		return c.Render(http.StatusOK, "assignmentsStructure", draft)
 
 	}
 
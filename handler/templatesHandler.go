@@ -0,0 +1,322 @@
+package handler
+
+import (
+	"Syllybea/UIcomponents"
+	"Syllybea/bibliography"
+	"Syllybea/grading"
+	"Syllybea/mid"
+	"Syllybea/repository"
+	"Syllybea/types"
+	"encoding/json"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// templatesPageData is the data rendered by the "templates-page" template.
+type templatesPageData struct {
+	Header      UIcomponents.HeaderData
+	Templates   []types.SyllabusTemplate
+	Departments []types.Department
+	Form        UIcomponents.TemplateForm
+	Error       string
+}
+
+// handleTemplatesPage lists the department templates and shows the editor,
+// pre-filled with the template given by the "id" query parameter if any.
+func handleTemplatesPage(c echo.Context, repo *repository.Repository) error {
+	user, err := requireManager(c, repo)
+	if user == nil {
+		return err
+	}
+
+	form := UIcomponents.TemplateForm{}
+	if idStr := c.QueryParam("id"); idStr != "" {
+		id, err := strconv.Atoi(idStr)
+		if err != nil {
+			return c.String(http.StatusBadRequest, "Invalid template ID")
+		}
+		t, err := repo.GetTemplateByID(id)
+		if err != nil {
+			c.Logger().Error("GetTemplateByID error:", err)
+			return c.String(http.StatusNotFound, "Template not found")
+		}
+		form, err = templateToForm(t)
+		if err != nil {
+			c.Logger().Error("Error reading template content:", err)
+			return c.String(http.StatusInternalServerError, "Error reading template")
+		}
+	} else {
+		form.Sections = buildTemplateSections(&UIcomponents.Draft{}, nil)
+	}
+
+	return renderTemplatesPage(c, repo, user, form, "")
+}
+
+// handleSaveTemplate creates a template, or updates it when the form carries an ID.
+func handleSaveTemplate(c echo.Context, repo *repository.Repository) error {
+	user, err := requireManager(c, repo)
+	if user == nil {
+		return err
+	}
+
+	if err := c.Request().ParseForm(); err != nil {
+		return c.String(http.StatusBadRequest, "Invalid form")
+	}
+
+	id, _ := strconv.Atoi(c.FormValue("id"))
+	departmentID, _ := strconv.Atoi(c.FormValue("department-id"))
+	name := strings.TrimSpace(c.FormValue("name"))
+	locked := c.Request().Form["locked[]"]
+
+	var draft UIcomponents.Draft
+	for _, key := range UIcomponents.TemplateSectionKeys {
+		setDraftSection(&draft, key, splitLines(c.FormValue("section-"+key)))
+	}
+
+	form := UIcomponents.TemplateForm{
+		ID:           id,
+		Name:         name,
+		DepartmentID: departmentID,
+		Sections:     buildTemplateSections(&draft, locked),
+	}
+	if name == "" || departmentID == 0 {
+		return renderTemplatesPage(c, repo, user, form, tr(c, "יש להזין שם תבנית ולבחור מחלקה"))
+	}
+
+	data, err := draft.Marshal()
+	if err != nil {
+		c.Logger().Error("Error marshaling template content:", err)
+		return c.String(http.StatusInternalServerError, "Error processing template data")
+	}
+
+	t := &types.SyllabusTemplate{
+		ID:             id,
+		DepartmentID:   departmentID,
+		Name:           name,
+		Data:           data,
+		LockedSections: validSections(locked),
+		CreatedBy:      user.ID,
+	}
+	if id > 0 {
+		err = repo.UpdateTemplate(t)
+	} else {
+		err = repo.CreateTemplate(t)
+	}
+	if err != nil {
+		c.Logger().Error("Error saving template:", err)
+		return c.String(http.StatusInternalServerError, "Error saving template")
+	}
+
+	// Start over with an empty editor once the template is saved.
+	empty := UIcomponents.TemplateForm{Sections: buildTemplateSections(&UIcomponents.Draft{}, nil)}
+	return renderTemplatesPage(c, repo, user, empty, "")
+}
+
+// handleDeleteTemplate removes a template.
+func handleDeleteTemplate(c echo.Context, repo *repository.Repository) error {
+	user, err := requireManager(c, repo)
+	if user == nil {
+		return err
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid template ID")
+	}
+	if err := repo.DeleteTemplate(id); err != nil {
+		c.Logger().Error("Error deleting template:", err)
+		return c.String(http.StatusInternalServerError, "Error deleting template")
+	}
+
+	// Return an empty response so the row is removed from the list.
+	return c.NoContent(http.StatusOK)
+}
+
+// handleTemplatePicker lets a lecturer start a new syllabus from a department template.
+func handleTemplatePicker(c echo.Context, repo *repository.Repository) error {
+	userID, err := mid.GetUserID(c)
+	if err != nil {
+		c.Response().Header().Set("HX-Redirect", "/login")
+		return c.String(http.StatusOK, "Redirecting to login page...")
+	}
+	user, err := repo.GetUserByID(userID)
+	if err != nil {
+		c.Logger().Error("GetUserByID error:", err)
+		return c.String(http.StatusInternalServerError, "Error retrieving user data")
+	}
+
+	templates, err := repo.GetAllTemplates()
+	if err != nil {
+		c.Logger().Error("GetAllTemplates error:", err)
+		return c.String(http.StatusInternalServerError, "Error fetching templates")
+	}
+
+	data := struct {
+		Header    UIcomponents.HeaderData
+		Templates []types.SyllabusTemplate
+	}{
+		Header:    UIcomponents.HeaderData{Title: "Templates", Name: user.Name, Role: user.Role},
+		Templates: templates,
+	}
+	return c.Render(http.StatusOK, "template-picker", data)
+}
+
+func renderTemplatesPage(c echo.Context, repo *repository.Repository, user *types.User, form UIcomponents.TemplateForm, errMsg string) error {
+	templates, err := repo.GetAllTemplates()
+	if err != nil {
+		c.Logger().Error("GetAllTemplates error:", err)
+		return c.String(http.StatusInternalServerError, "Error fetching templates")
+	}
+	departments, err := repo.GetAllDepartments()
+	if err != nil {
+		c.Logger().Error("GetAllDepartments error:", err)
+		return c.String(http.StatusInternalServerError, "Error fetching departments")
+	}
+
+	data := templatesPageData{
+		Header:      UIcomponents.HeaderData{Title: "Templates", Name: user.Name, Role: user.Role},
+		Templates:   templates,
+		Departments: departments,
+		Form:        form,
+		Error:       errMsg,
+	}
+	return c.Render(http.StatusOK, "templates-page", data)
+}
+
+// templateToForm converts a stored template into the editor representation.
+func templateToForm(t *types.SyllabusTemplate) (UIcomponents.TemplateForm, error) {
+	var draft UIcomponents.Draft
+	if err := json.Unmarshal(t.Data, &draft); err != nil {
+		return UIcomponents.TemplateForm{}, err
+	}
+	return UIcomponents.TemplateForm{
+		ID:           t.ID,
+		Name:         t.Name,
+		DepartmentID: t.DepartmentID,
+		Sections:     buildTemplateSections(&draft, t.LockedSections),
+	}, nil
+}
+
+func buildTemplateSections(draft *UIcomponents.Draft, locked []string) []UIcomponents.TemplateSection {
+	sections := make([]UIcomponents.TemplateSection, 0, len(UIcomponents.TemplateSectionKeys))
+	for _, key := range UIcomponents.TemplateSectionKeys {
+		isLocked := false
+		for _, l := range locked {
+			if l == key {
+				isLocked = true
+				break
+			}
+		}
+		sections = append(sections, UIcomponents.TemplateSection{
+			Key:    key,
+			Label:  UIcomponents.TemplateSectionLabels[key],
+			Text:   strings.Join(draftSection(draft, key), "\n"),
+			Locked: isLocked,
+		})
+	}
+	return sections
+}
+
+// draftSection returns the items of a template section, one string per item.
+// Grade components are written as "name | percentage"; bibliography lists as
+// BibTeX once they hold structured entries.
+func draftSection(draft *UIcomponents.Draft, key string) []string {
+	switch key {
+	case "courseRequirements":
+		return draft.CourseRequirements
+	case "learningOutcomes":
+		return draft.LearningOutcomes
+	case "courseObjectives":
+		return draft.CourseObjectives
+	case "gradeComponents":
+		lines := make([]string, 0, len(draft.GradeComponents))
+		for _, comp := range draft.GradeComponents {
+			lines = append(lines, comp.Line())
+		}
+		return lines
+	case "assignmentsStructure":
+		return draft.AssignmentsStructure
+	case "bibliographyRequired":
+		return bibliographyLines(draft.BibliographyRequired)
+	case "bibliographyRecommended":
+		return bibliographyLines(draft.BibliographyRecommended)
+	}
+	return nil
+}
+
+// setDraftSection is the inverse of draftSection.
+func setDraftSection(draft *UIcomponents.Draft, key string, lines []string) {
+	switch key {
+	case "courseRequirements":
+		draft.CourseRequirements = lines
+	case "learningOutcomes":
+		draft.LearningOutcomes = lines
+	case "courseObjectives":
+		draft.CourseObjectives = lines
+	case "gradeComponents":
+		comps := make([]grading.Component, 0, len(lines))
+		for _, line := range lines {
+			comps = append(comps, grading.ParseLine(line))
+		}
+		draft.GradeComponents = comps
+	case "assignmentsStructure":
+		draft.AssignmentsStructure = lines
+	case "bibliographyRequired":
+		draft.BibliographyRequired = bibliographyFromLines(lines)
+	case "bibliographyRecommended":
+		draft.BibliographyRecommended = bibliographyFromLines(lines)
+	}
+}
+
+// bibliographyLines writes a bibliography list for the template textarea:
+// free-text entries one per line, or the whole list as BibTeX when it has
+// structured entries.
+func bibliographyLines(entries []bibliography.Entry) []string {
+	lines := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if !e.IsFreeText() {
+			var b strings.Builder
+			bibliography.WriteBibTeX(&b, entries)
+			return splitLines(b.String())
+		}
+		lines = append(lines, e.Title)
+	}
+	return lines
+}
+
+// bibliographyFromLines is the inverse of bibliographyLines. Lines that are not
+// BibTeX or RIS become free-text entries.
+func bibliographyFromLines(lines []string) []bibliography.Entry {
+	if entries, err := bibliography.Parse(strings.Join(lines, "\n")); err == nil {
+		return entries
+	}
+	entries := make([]bibliography.Entry, 0, len(lines))
+	for _, line := range lines {
+		entries = append(entries, bibliography.Entry{Title: line})
+	}
+	return entries
+}
+
+// splitLines splits textarea content into trimmed, non-empty lines.
+func splitLines(text string) []string {
+	lines := []string{}
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// validSections drops any submitted section key that is not a template section.
+func validSections(keys []string) []string {
+	valid := []string{}
+	for _, key := range keys {
+		if _, ok := UIcomponents.TemplateSectionLabels[key]; ok {
+			valid = append(valid, key)
+		}
+	}
+	return valid
+}
@@ -0,0 +1,164 @@
+package handler
+
+import (
+	"Syllybea/UIcomponents"
+	"Syllybea/repository"
+	"Syllybea/types"
+	"Syllybea/utils"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// termsPageData is the data rendered by the "terms-page" template.
+type termsPageData struct {
+	Header       UIcomponents.HeaderData
+	Terms        []types.Term
+	NextYear     int // Default academic year of a new term
+	HolidayYear  int // Academic year whose holidays are listed
+	HolidayYears []int
+	Holidays     []types.Holiday
+	Error        string
+}
+
+// handleTermsPage lists the academic terms with their dates, and the holidays of
+// the academic year given by the "year" query parameter (managers only).
+func handleTermsPage(c echo.Context, repo *repository.Repository) error {
+	user, err := requireManager(c, repo)
+	if user == nil {
+		return err
+	}
+	return renderTermsPage(c, repo, user, "")
+}
+
+// handleSaveTerm creates a term, or updates its dates and label when the form carries an ID.
+func handleSaveTerm(c echo.Context, repo *repository.Repository) error {
+	user, err := requireManager(c, repo)
+	if user == nil {
+		return err
+	}
+
+	start, startErr := time.Parse("2006-01-02", c.FormValue("start-date"))
+	end, endErr := time.Parse("2006-01-02", c.FormValue("end-date"))
+	if startErr != nil || endErr != nil || !end.After(start) {
+		return renderTermsPage(c, repo, user, tr(c, "יש להזין תאריך התחלה ותאריך סיום מאוחר ממנו"))
+	}
+	label := strings.TrimSpace(c.FormValue("hebrew-label"))
+
+	if id, _ := strconv.Atoi(c.FormValue("id")); id > 0 {
+		t := &types.Term{ID: id, StartDate: start, EndDate: end, HebrewLabel: label}
+		if t.HebrewLabel == "" {
+			return renderTermsPage(c, repo, user, tr(c, "יש להזין שם לסמסטר"))
+		}
+		if err := repo.UpdateTerm(t); err != nil {
+			c.Logger().Error("UpdateTerm error:", err)
+			return c.String(http.StatusInternalServerError, "Error saving term")
+		}
+		return renderTermsPage(c, repo, user, "")
+	}
+
+	year, err := strconv.Atoi(c.FormValue("academic-year"))
+	semester := utils.NormalizeSemester(c.FormValue("semester"))
+	if err != nil || year < 2000 || semester == "" {
+		return renderTermsPage(c, repo, user, tr(c, "יש לבחור שנה אקדמית וסמסטר"))
+	}
+	terms, err := repo.GetAllTerms()
+	if err != nil {
+		c.Logger().Error("GetAllTerms error:", err)
+		return c.String(http.StatusInternalServerError, "Error fetching terms")
+	}
+	for _, t := range terms {
+		if t.AcademicYear == year && t.Semester == semester {
+			return renderTermsPage(c, repo, user, tr(c, "הסמסטר כבר קיים"))
+		}
+	}
+
+	t := &types.Term{AcademicYear: year, Semester: semester, StartDate: start, EndDate: end, HebrewLabel: label}
+	if err := repo.CreateTerm(t); err != nil {
+		c.Logger().Error("CreateTerm error:", err)
+		return c.String(http.StatusInternalServerError, "Error saving term")
+	}
+	return renderTermsPage(c, repo, user, "")
+}
+
+// handleSaveHoliday adds a holiday or academic break (managers only).
+func handleSaveHoliday(c echo.Context, repo *repository.Repository) error {
+	user, err := requireManager(c, repo)
+	if user == nil {
+		return err
+	}
+
+	name := strings.TrimSpace(c.FormValue("name"))
+	start, startErr := time.Parse("2006-01-02", c.FormValue("start-date"))
+	end, endErr := time.Parse("2006-01-02", c.FormValue("end-date"))
+	if endErr != nil && startErr == nil {
+		// A single-day holiday.
+		end, endErr = start, nil
+	}
+	if name == "" || startErr != nil || endErr != nil || end.Before(start) {
+		return renderTermsPage(c, repo, user, tr(c, "יש להזין שם חג ותאריכים תקינים"))
+	}
+
+	if err := repo.CreateHoliday(&types.Holiday{Name: name, StartDate: start, EndDate: end}); err != nil {
+		c.Logger().Error("CreateHoliday error:", err)
+		return c.String(http.StatusInternalServerError, "Error saving holiday")
+	}
+	return renderTermsPage(c, repo, user, "")
+}
+
+// handleDeleteHoliday removes a holiday (managers only).
+func handleDeleteHoliday(c echo.Context, repo *repository.Repository) error {
+	user, err := requireManager(c, repo)
+	if user == nil {
+		return err
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid holiday ID")
+	}
+	if err := repo.DeleteHoliday(id); err != nil {
+		c.Logger().Error("DeleteHoliday error:", err)
+		return c.String(http.StatusInternalServerError, "Error deleting holiday")
+	}
+	return renderTermsPage(c, repo, user, "")
+}
+
+func renderTermsPage(c echo.Context, repo *repository.Repository, user *types.User, errMsg string) error {
+	terms, err := repo.GetAllTerms()
+	if err != nil {
+		c.Logger().Error("GetAllTerms error:", err)
+		return c.String(http.StatusInternalServerError, "Error fetching terms")
+	}
+
+	data := termsPageData{
+		Header:      UIcomponents.HeaderData{Title: "Terms", Name: user.Name, Role: user.Role},
+		Terms:       terms,
+		NextYear:    utils.AcademicYear(time.Now()) + 1,
+		HolidayYear: utils.AcademicYear(time.Now()),
+		Error:       errMsg,
+	}
+	if len(terms) > 0 && terms[0].AcademicYear >= data.NextYear {
+		data.NextYear = terms[0].AcademicYear + 1
+	}
+	for _, t := range terms {
+		if n := len(data.HolidayYears); n == 0 || data.HolidayYears[n-1] != t.AcademicYear {
+			data.HolidayYears = append(data.HolidayYears, t.AcademicYear)
+		}
+	}
+	if year, err := strconv.Atoi(c.QueryParam("year")); err == nil {
+		data.HolidayYear = year
+	} else if year, err := strconv.Atoi(c.FormValue("holiday-year")); err == nil {
+		data.HolidayYear = year
+	}
+
+	// Holidays are listed from August to July, the same cutoff as utils.AcademicYear.
+	from := time.Date(data.HolidayYear, time.August, 1, 0, 0, 0, 0, time.UTC)
+	if data.Holidays, err = repo.GetHolidays(from, from.AddDate(1, 0, -1)); err != nil {
+		c.Logger().Error("GetHolidays error:", err)
+		return c.String(http.StatusInternalServerError, "Error fetching holidays")
+	}
+	return c.Render(http.StatusOK, "terms-page", data)
+}
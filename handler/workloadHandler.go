@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"Syllybea/UIcomponents"
+	"Syllybea/repository"
+	"Syllybea/types"
+	"Syllybea/workload"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"strconv"
+)
+
+// workloadNormsPageData is the data rendered by the "workload-norms-page" template.
+type workloadNormsPageData struct {
+	Header UIcomponents.HeaderData
+	Norms  []types.WorkloadNorm
+	Error  string
+}
+
+// handleWorkloadNormsPage lists the credit-hour norm of every department (managers only).
+func handleWorkloadNormsPage(c echo.Context, repo *repository.Repository) error {
+	user, err := requireManager(c, repo)
+	if user == nil {
+		return err
+	}
+	return renderWorkloadNormsPage(c, repo, user, "")
+}
+
+// handleSaveWorkloadNorm sets the credit-hour norm of a department (managers only).
+func handleSaveWorkloadNorm(c echo.Context, repo *repository.Repository) error {
+	user, err := requireManager(c, repo)
+	if user == nil {
+		return err
+	}
+
+	n := &types.WorkloadNorm{}
+	if n.DepartmentID, err = strconv.Atoi(c.FormValue("department-id")); err != nil || n.DepartmentID <= 0 {
+		return c.String(http.StatusBadRequest, "Invalid department ID")
+	}
+	n.HoursPerCredit, err = workload.ParseHours(c.FormValue("hours-per-credit"))
+	if err != nil || n.HoursPerCredit <= 0 {
+		return renderWorkloadNormsPage(c, repo, user, tr(c, "יש להזין מספר שעות חיובי לנקודת זכות"))
+	}
+	n.TolerancePercent, err = workload.ParseHours(c.FormValue("tolerance-percent"))
+	if err != nil || n.TolerancePercent > 100 {
+		return renderWorkloadNormsPage(c, repo, user, tr(c, "הסטייה המותרת חייבת להיות בין 0 ל-100 אחוז"))
+	}
+
+	if err := repo.SaveWorkloadNorm(n); err != nil {
+		c.Logger().Error("SaveWorkloadNorm error:", err)
+		return c.String(http.StatusInternalServerError, "Error saving workload norm")
+	}
+	return renderWorkloadNormsPage(c, repo, user, "")
+}
+
+func renderWorkloadNormsPage(c echo.Context, repo *repository.Repository, user *types.User, errMsg string) error {
+	data := workloadNormsPageData{
+		Header: UIcomponents.HeaderData{Title: "Workload Norms", Name: user.Name, Role: user.Role},
+		Error:  errMsg,
+	}
+	var err error
+	if data.Norms, err = repo.GetWorkloadNorms(); err != nil {
+		c.Logger().Error("GetWorkloadNorms error:", err)
+		return c.String(http.StatusInternalServerError, "Error fetching workload norms")
+	}
+	return c.Render(http.StatusOK, "workload-norms-page", data)
+}
+
+// workloadInputs are the form actions and fields that change the estimated
+// workload. Responses to them tell the workload summary to refresh.
+var workloadInputs = map[string]bool{
+	"credits":                   true,
+	"weeklyHours":               true,
+	"meetingDays":               true,
+	"updateSyllabusRow":         true,
+	"insertSyllabusRow":         true,
+	"removeSyllabusRow":         true,
+	"generateSchedule":          true,
+	"assignmentsStructure":      true,
+	"addAssignmentStructure":    true,
+	"removeAssignmentStructure": true,
+	"syllabus-department":       true,
+}
+
+// populateWorkloadNorm fills the credit-hour norm of the draft's department,
+// which its workload is checked against.
+func populateWorkloadNorm(draft *UIcomponents.Draft, repo *repository.Repository) {
+	draft.WorkloadNorm = workload.DefaultNorm
+	departments, _ := repo.GetAllDepartments()
+	for _, dept := range departments {
+		if dept.Name != draft.SyllabusDepartment {
+			continue
+		}
+		if n, err := repo.GetWorkloadNorm(dept.ID); err == nil {
+			draft.WorkloadNorm = workload.Norm{HoursPerCredit: n.HoursPerCredit, TolerancePercent: n.TolerancePercent}
+		}
+		return
+	}
+}
+
+// renderWorkload renders the workload summary of the draft.
+func renderWorkload(c echo.Context, repo *repository.Repository, draft *UIcomponents.Draft) error {
+	populateWorkloadNorm(draft, repo)
+	return c.Render(http.StatusOK, "workload", draft)
+}
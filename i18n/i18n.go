@@ -0,0 +1,157 @@
+// Package i18n translates the user interface. Messages are written in Hebrew,
+// the language the system was built in, and looked up by their Hebrew text in
+// the message catalog of the locale, one JSON file per locale under catalogs/.
+// A message without a translation is shown in Hebrew.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Locale tags.
+const (
+	Hebrew  = "he"
+	English = "en"
+)
+
+// Default is the locale of users who did not ask for another.
+const Default = Hebrew
+
+// Locale is a language the user interface is shown in.
+type Locale struct {
+	Tag    string
+	Name   string // Name of the language in itself
+	Dir    string // Text direction, "rtl" or "ltr"
+	months [12]string
+}
+
+// Locales lists the locales of the user interface, the default first.
+var Locales = []Locale{
+	{Tag: Hebrew, Name: "עברית", Dir: "rtl", months: [12]string{
+		"ינואר", "פברואר", "מרץ", "אפריל", "מאי", "יוני",
+		"יולי", "אוגוסט", "ספטמבר", "אוקטובר", "נובמבר", "דצמבר",
+	}},
+	{Tag: English, Name: "English", Dir: "ltr", months: [12]string{
+		"January", "February", "March", "April", "May", "June",
+		"July", "August", "September", "October", "November", "December",
+	}},
+}
+
+//go:embed catalogs/*.json
+var catalogFiles embed.FS
+
+// catalogs maps a locale tag to its messages, by their Hebrew text.
+var catalogs = loadCatalogs()
+
+func loadCatalogs() map[string]map[string]string {
+	catalogs := map[string]map[string]string{}
+	entries, err := catalogFiles.ReadDir("catalogs")
+	if err != nil {
+		panic(err)
+	}
+	for _, e := range entries {
+		data, err := catalogFiles.ReadFile(path.Join("catalogs", e.Name()))
+		if err != nil {
+			panic(err)
+		}
+		messages := map[string]string{}
+		if err := json.Unmarshal(data, &messages); err != nil {
+			panic(fmt.Sprintf("i18n: catalog %s: %v", e.Name(), err))
+		}
+		catalogs[strings.TrimSuffix(e.Name(), ".json")] = messages
+	}
+	return catalogs
+}
+
+// Get returns the locale with the given tag, the default locale when there is none.
+func Get(tag string) Locale {
+	if l, ok := lookup(tag); ok {
+		return l
+	}
+	return Locales[0]
+}
+
+func lookup(tag string) (Locale, bool) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if i := strings.IndexAny(tag, "-_"); i >= 0 {
+		tag = tag[:i] // "en-US" is shown in English
+	}
+	for _, l := range Locales {
+		if l.Tag == tag {
+			return l, true
+		}
+	}
+	return Locale{}, false
+}
+
+// Negotiate picks the locale of a request: the one the user chose when it is
+// supported, else the first supported language of the Accept-Language header
+// by preference, else the default.
+func Negotiate(chosen, acceptLanguage string) string {
+	if l, ok := lookup(chosen); ok {
+		return l.Tag
+	}
+
+	type weighted struct {
+		tag string
+		q   float64
+	}
+	var langs []weighted
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+		if tag != "" && q > 0 {
+			langs = append(langs, weighted{tag, q})
+		}
+	}
+	sort.SliceStable(langs, func(i, j int) bool { return langs[i].q > langs[j].q })
+	for _, lang := range langs {
+		if l, ok := lookup(lang.tag); ok {
+			return l.Tag
+		}
+	}
+	return Default
+}
+
+// T translates a message to a locale. With arguments, the translation is a
+// format for them, as in fmt.Sprintf.
+func T(tag, message string, args ...interface{}) string {
+	translated, ok := catalogs[Get(tag).Tag][message]
+	if !ok || translated == "" {
+		translated = message
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(translated, args...)
+	}
+	return translated
+}
+
+// Month returns the name of a month in a locale.
+func Month(tag string, m time.Month) string {
+	return Get(tag).months[m-1]
+}
+
+// MonthYear formats the month of a date, e.g. "מרץ 2025" or "March 2025".
+func MonthYear(tag string, t time.Time) string {
+	return Month(tag, t.Month()) + " " + strconv.Itoa(t.Year())
+}
+
+// Date formats a date in full, e.g. "5 במרץ 2025" or "March 5, 2025".
+func Date(tag string, t time.Time) string {
+	if Get(tag).Tag == English {
+		return fmt.Sprintf("%s %d, %d", Month(tag, t.Month()), t.Day(), t.Year())
+	}
+	return fmt.Sprintf("%d ב%s %d", t.Day(), Month(tag, t.Month()), t.Year())
+}
@@ -0,0 +1,132 @@
+// Package ical writes iCalendar (RFC 5545) feeds that calendar clients can subscribe to.
+package ical
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// TimeZone is the zone event times are given in. Its VTIMEZONE definition is
+// written into every calendar so clients do not need to know the zone.
+const TimeZone = "Asia/Jerusalem"
+
+// Event is a single VEVENT. AllDay events use only the date part of Start and
+// End; End is exclusive, as the standard requires.
+type Event struct {
+	UID         string // Must stay the same across feeds so clients update instead of duplicating
+	Summary     string
+	Description string
+	Location    string
+	Start       time.Time
+	End         time.Time
+	AllDay      bool
+	Weekly      bool      // Repeats every week on the weekday of Start
+	Until       time.Time // Last repetition of a weekly event; zero for no end
+}
+
+// Calendar is a VCALENDAR with its events.
+type Calendar struct {
+	Name    string
+	Updated time.Time // Written as the DTSTAMP of every event
+	Events  []Event
+}
+
+// Write writes the calendar in iCalendar format.
+func (cal Calendar) Write(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	line := func(s string) {
+		writeFolded(bw, s)
+	}
+
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//Syllybea//Syllabus Calendar//HE")
+	line("CALSCALE:GREGORIAN")
+	line("METHOD:PUBLISH")
+	line("X-WR-CALNAME:" + escape(cal.Name))
+	line("X-WR-TIMEZONE:" + TimeZone)
+	for _, l := range jerusalemTimeZone {
+		line(l)
+	}
+
+	stamp := cal.Updated.UTC().Format("20060102T150405Z")
+	for _, e := range cal.Events {
+		line("BEGIN:VEVENT")
+		line("UID:" + e.UID)
+		line("DTSTAMP:" + stamp)
+		if e.AllDay {
+			line("DTSTART;VALUE=DATE:" + e.Start.Format("20060102"))
+			line("DTEND;VALUE=DATE:" + e.End.Format("20060102"))
+		} else {
+			line("DTSTART;TZID=" + TimeZone + ":" + e.Start.Format("20060102T150405"))
+			line("DTEND;TZID=" + TimeZone + ":" + e.End.Format("20060102T150405"))
+		}
+		if e.Weekly {
+			rule := "RRULE:FREQ=WEEKLY"
+			if !e.Until.IsZero() {
+				rule += ";UNTIL=" + e.Until.UTC().Format("20060102T150405Z")
+			}
+			line(rule)
+		}
+		line("SUMMARY:" + escape(e.Summary))
+		if e.Description != "" {
+			line("DESCRIPTION:" + escape(e.Description))
+		}
+		if e.Location != "" {
+			line("LOCATION:" + escape(e.Location))
+		}
+		line("END:VEVENT")
+	}
+
+	line("END:VCALENDAR")
+	return bw.Flush()
+}
+
+// jerusalemTimeZone defines Israel time: daylight saving time starts on the
+// Friday before the last Sunday of March and ends on the last Sunday of October.
+var jerusalemTimeZone = []string{
+	"BEGIN:VTIMEZONE",
+	"TZID:" + TimeZone,
+	"BEGIN:DAYLIGHT",
+	"TZOFFSETFROM:+0200",
+	"TZOFFSETTO:+0300",
+	"TZNAME:IDT",
+	"DTSTART:19700327T020000",
+	"RRULE:FREQ=YEARLY;BYMONTH=3;BYMONTHDAY=23,24,25,26,27,28,29;BYDAY=FR",
+	"END:DAYLIGHT",
+	"BEGIN:STANDARD",
+	"TZOFFSETFROM:+0300",
+	"TZOFFSETTO:+0200",
+	"TZNAME:IST",
+	"DTSTART:19701025T020000",
+	"RRULE:FREQ=YEARLY;BYMONTH=10;BYDAY=-1SU",
+	"END:STANDARD",
+	"END:VTIMEZONE",
+}
+
+// escape escapes a TEXT value.
+var escape = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace
+
+// writeFolded writes a content line, folding it at 75 octets without
+// splitting UTF-8 characters, and terminates it with CRLF.
+func writeFolded(w *bufio.Writer, s string) {
+	const limit = 75
+	width := 0
+	for len(s) > 0 {
+		r, size := utf8.DecodeRuneInString(s)
+		if r == utf8.RuneError && size <= 1 {
+			size = 1
+		}
+		if width+size > limit {
+			w.WriteString("\r\n ")
+			width = 1
+		}
+		w.WriteString(s[:size])
+		width += size
+		s = s[size:]
+	}
+	w.WriteString("\r\n")
+}
@@ -0,0 +1,31 @@
+// Package jobs holds the background workers started by main.
+package jobs
+
+import (
+	"Syllybea/repository"
+	"context"
+	"log/slog"
+	"time"
+)
+
+// PurgeTrash permanently deletes syllabi that have been in the trash longer
+// than retention, once at startup and then every interval, until ctx is done.
+func PurgeTrash(ctx context.Context, repo *repository.Repository, retention, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		n, err := repo.PurgeTrash(time.Now().Add(-retention))
+		if err != nil {
+			slog.ErrorContext(ctx, "Trash purge failed", "err", err)
+		} else if n > 0 {
+			slog.InfoContext(ctx, "Purged syllabi from the trash", "count", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/log"
+)
+
+// EchoLogger adapts a slog logger to the logger of echo, for the lines
+// echo logs outside of any request.
+func EchoLogger(logger *slog.Logger) echo.Logger {
+	return &echoLogger{logger: logger, context: context.Background}
+}
+
+// RequestLogger adapts a slog logger to the logger of echo for a request, so
+// c.Logger() in a handler logs with the attributes of the request's context.
+// The context is read as each line is logged, so the attributes attached to
+// it later are included.
+func RequestLogger(c echo.Context, logger *slog.Logger) echo.Logger {
+	return &echoLogger{logger: logger, context: func() context.Context { return c.Request().Context() }}
+}
+
+type echoLogger struct {
+	logger  *slog.Logger
+	context func() context.Context
+}
+
+// log writes a line built like fmt.Sprintln, without the newline. An error
+// among the values is logged as the "err" attribute instead of in the message.
+func (l *echoLogger) log(level slog.Level, values ...interface{}) {
+	if !l.logger.Enabled(l.context(), level) {
+		return
+	}
+	var attrs []slog.Attr
+	parts := make([]interface{}, 0, len(values))
+	for _, v := range values {
+		if err, ok := v.(error); ok {
+			attrs = append(attrs, slog.String("err", err.Error()))
+			continue
+		}
+		parts = append(parts, v)
+	}
+	msg := strings.TrimSuffix(fmt.Sprintln(parts...), "\n")
+	msg = strings.TrimRight(msg, " :")
+	l.logger.LogAttrs(l.context(), level, msg, attrs...)
+}
+
+func (l *echoLogger) logf(level slog.Level, format string, args ...interface{}) {
+	if l.logger.Enabled(l.context(), level) {
+		l.logger.Log(l.context(), level, fmt.Sprintf(format, args...))
+	}
+}
+
+func (l *echoLogger) logj(level slog.Level, j log.JSON) {
+	attrs := make([]slog.Attr, 0, len(j))
+	for k, v := range j {
+		attrs = append(attrs, slog.Any(k, v))
+	}
+	l.logger.LogAttrs(l.context(), level, "", attrs...)
+}
+
+func (l *echoLogger) Print(i ...interface{}) {
+	l.log(slog.LevelInfo, i...)
+}
+
+func (l *echoLogger) Printf(format string, args ...interface{}) {
+	l.logf(slog.LevelInfo, format, args...)
+}
+
+func (l *echoLogger) Printj(j log.JSON) {
+	l.logj(slog.LevelInfo, j)
+}
+
+func (l *echoLogger) Debug(i ...interface{}) {
+	l.log(slog.LevelDebug, i...)
+}
+
+func (l *echoLogger) Debugf(format string, args ...interface{}) {
+	l.logf(slog.LevelDebug, format, args...)
+}
+
+func (l *echoLogger) Debugj(j log.JSON) {
+	l.logj(slog.LevelDebug, j)
+}
+
+func (l *echoLogger) Info(i ...interface{}) {
+	l.log(slog.LevelInfo, i...)
+}
+
+func (l *echoLogger) Infof(format string, args ...interface{}) {
+	l.logf(slog.LevelInfo, format, args...)
+}
+
+func (l *echoLogger) Infoj(j log.JSON) {
+	l.logj(slog.LevelInfo, j)
+}
+
+func (l *echoLogger) Warn(i ...interface{}) {
+	l.log(slog.LevelWarn, i...)
+}
+
+func (l *echoLogger) Warnf(format string, args ...interface{}) {
+	l.logf(slog.LevelWarn, format, args...)
+}
+
+func (l *echoLogger) Warnj(j log.JSON) {
+	l.logj(slog.LevelWarn, j)
+}
+
+func (l *echoLogger) Error(i ...interface{}) {
+	l.log(slog.LevelError, i...)
+}
+
+func (l *echoLogger) Errorf(format string, args ...interface{}) {
+	l.logf(slog.LevelError, format, args...)
+}
+
+func (l *echoLogger) Errorj(j log.JSON) {
+	l.logj(slog.LevelError, j)
+}
+
+func (l *echoLogger) Fatal(i ...interface{}) {
+	l.log(slog.LevelError, i...)
+	os.Exit(1)
+}
+
+func (l *echoLogger) Fatalf(format string, args ...interface{}) {
+	l.logf(slog.LevelError, format, args...)
+	os.Exit(1)
+}
+
+func (l *echoLogger) Fatalj(j log.JSON) {
+	l.logj(slog.LevelError, j)
+	os.Exit(1)
+}
+
+func (l *echoLogger) Panic(i ...interface{}) {
+	l.log(slog.LevelError, i...)
+	panic(fmt.Sprint(i...))
+}
+
+func (l *echoLogger) Panicf(format string, args ...interface{}) {
+	l.logf(slog.LevelError, format, args...)
+	panic(fmt.Sprintf(format, args...))
+}
+
+func (l *echoLogger) Panicj(j log.JSON) {
+	l.logj(slog.LevelError, j)
+	panic(j)
+}
+
+// Output is where echo writes its own output, such as the address it
+// listens on; each write is logged as a line.
+func (l *echoLogger) Output() io.Writer { return lineWriter{l} }
+
+// The prefix, level and header of echo's logger are set by the slog logger
+// instead, so setting them has no effect.
+func (l *echoLogger) SetOutput(io.Writer) {}
+func (l *echoLogger) Prefix() string      { return "" }
+func (l *echoLogger) SetPrefix(string)    {}
+func (l *echoLogger) SetHeader(string)    {}
+func (l *echoLogger) SetLevel(log.Lvl)    {}
+
+func (l *echoLogger) Level() log.Lvl {
+	switch {
+	case l.logger.Enabled(l.context(), slog.LevelDebug):
+		return log.DEBUG
+	case l.logger.Enabled(l.context(), slog.LevelInfo):
+		return log.INFO
+	case l.logger.Enabled(l.context(), slog.LevelWarn):
+		return log.WARN
+	}
+	return log.ERROR
+}
+
+// lineWriter logs what is written to it, one line per write.
+type lineWriter struct {
+	l *echoLogger
+}
+
+func (w lineWriter) Write(p []byte) (int, error) {
+	if msg := string(bytes.TrimSpace(p)); msg != "" {
+		w.l.logger.Log(w.l.context(), slog.LevelInfo, msg)
+	}
+	return len(p), nil
+}
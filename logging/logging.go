@@ -0,0 +1,97 @@
+// Package logging sets up the structured logs of the server on log/slog.
+// Every line logged with a request's context carries the attributes attached
+// to it, such as the request, user and syllabus IDs, and personal data is
+// redacted before a line is written.
+package logging
+
+import (
+	"Syllybea/config"
+	"context"
+	"io"
+	"log/slog"
+	"os"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Setup makes the logger configured by cfg the default of log/slog, and so
+// of the standard log package, writing to stderr.
+func Setup(cfg config.Log) (*slog.Logger, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(cfg.Level)); err != nil {
+		return nil, err
+	}
+	logger := New(os.Stderr, cfg.Format, level)
+	slog.SetDefault(logger)
+	return logger, nil
+}
+
+// New returns a logger writing lines of format, config.LogText or
+// config.LogJSON, at level and above to w.
+func New(w io.Writer, format string, level slog.Level) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: level}
+	var h slog.Handler
+	if format == config.LogJSON {
+		h = slog.NewJSONHandler(w, opts)
+	} else {
+		h = slog.NewTextHandler(w, opts)
+	}
+	return slog.New(&contextHandler{next: h})
+}
+
+// attrsKey is the context key of the attributes attached by With.
+type attrsKey struct{}
+
+// With returns a copy of ctx whose log lines carry attrs, after those
+// already attached to it.
+func With(ctx context.Context, attrs ...slog.Attr) context.Context {
+	prev := Attrs(ctx)
+	all := make([]slog.Attr, 0, len(prev)+len(attrs))
+	all = append(append(all, prev...), attrs...)
+	return context.WithValue(ctx, attrsKey{}, all)
+}
+
+// Attrs returns the attributes attached to ctx by With.
+func Attrs(ctx context.Context) []slog.Attr {
+	attrs, _ := ctx.Value(attrsKey{}).([]slog.Attr)
+	return attrs
+}
+
+// contextHandler adds the attributes attached to the context of a line, and
+// the trace it belongs to, then redacts the line before handing it on.
+type contextHandler struct {
+	next slog.Handler
+}
+
+func (h *contextHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	out := slog.NewRecord(r.Time, r.Level, redactText(r.Message), r.PC)
+	if ctx != nil {
+		for _, a := range Attrs(ctx) {
+			out.AddAttrs(redact(a))
+		}
+		if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+			out.AddAttrs(slog.String("trace_id", span.TraceID().String()))
+		}
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		out.AddAttrs(redact(a))
+		return true
+	})
+	return h.next.Handle(ctx, out)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		redacted[i] = redact(a)
+	}
+	return &contextHandler{next: h.next.WithAttrs(redacted)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{next: h.next.WithGroup(name)}
+}
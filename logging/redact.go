@@ -0,0 +1,66 @@
+package logging
+
+import (
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+// Redacted replaces a value that must not be written to the logs.
+const Redacted = "[redacted]"
+
+// sensitiveKeys are the attributes whose values are personal data or
+// secrets, matched case-insensitively against a whole key or its last part,
+// so both "email" and "user_email" are redacted. Users are identified in the
+// logs by their ID only.
+var sensitiveKeys = []string{
+	"email", "name", "phone", "address",
+	"password", "secret", "token", "jwt", "cookie", "authorization", "dsn",
+}
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	// jwtPattern matches the three base64url parts of a JSON Web Token.
+	jwtPattern = regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`)
+)
+
+// redact returns a with its value replaced when its key is sensitive, and
+// with the email addresses and tokens in its text removed otherwise.
+func redact(a slog.Attr) slog.Attr {
+	if sensitive(a.Key) {
+		return slog.String(a.Key, Redacted)
+	}
+	v := a.Value.Resolve()
+	switch v.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, redactText(v.String()))
+	case slog.KindGroup:
+		group := v.Group()
+		redacted := make([]any, len(group))
+		for i, g := range group {
+			redacted[i] = redact(g)
+		}
+		return slog.Group(a.Key, redacted...)
+	case slog.KindAny:
+		// Errors and other values are logged as their text.
+		return slog.String(a.Key, redactText(v.String()))
+	}
+	return slog.Attr{Key: a.Key, Value: v}
+}
+
+// redactText removes the email addresses and tokens from free text, such as
+// a message or an error.
+func redactText(s string) string {
+	s = emailPattern.ReplaceAllString(s, Redacted)
+	return jwtPattern.ReplaceAllString(s, Redacted)
+}
+
+func sensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, k := range sensitiveKeys {
+		if key == k || strings.HasSuffix(key, "_"+k) || strings.HasSuffix(key, "."+k) {
+			return true
+		}
+	}
+	return false
+}
@@ -2,15 +2,32 @@ package main
 
 import (
 	"Syllybea/Render"
+	"Syllybea/bibliography"
+	"Syllybea/config"
 	"Syllybea/handler"
+	"Syllybea/jobs"
+	"Syllybea/logging"
+	"Syllybea/metrics"
+	"Syllybea/mid"
 	"Syllybea/repository"
 	"Syllybea/storage"
+	"Syllybea/tracing"
+	"context"
+	"errors"
+	"fmt"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"html/template"
 	"io"
 	"log"
+	"log/slog"
+	"net/http"
 	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
 )
 
 // TemplateRenderer is a custom renderer for Echo using the Go html/template package.
@@ -24,27 +41,236 @@ func (t *TemplateRenderer) Render(w io.Writer, name string, data interface{}, c
 }
 
 func main() {
-	dsn := os.Getenv("MYSQL_DSN")
-	if dsn == "" {
-		dsn = "root:admin@tcp(localhost:3306)/syllabus"
+	// "config print" shows the configuration the server would start with.
+	if len(os.Args) > 2 && os.Args[1] == "config" && os.Args[2] == "print" {
+		printConfig(os.Args[3:])
+		return
 	}
-	store, err := storage.NewStorage(dsn)
+
+	cfg, err := config.Load(os.Args[0], os.Args[1:], os.Getenv)
+	if err != nil {
+		log.Fatalf("Could not load configuration: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("Invalid configuration:\n%v", err)
+	}
+	logger, err := logging.Setup(cfg.Log)
+	if err != nil {
+		log.Fatalf("Could not set up logging: %v", err)
+	}
+	mid.ConfigureAuth(cfg.Auth)
+
+	// SIGINT or SIGTERM stops the server gracefully.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	store, err := storage.NewStorage(ctx, cfg.Database)
 	if err != nil {
-		log.Fatalf("Could not create storage: %v", err)
+		fatal("Could not create storage", err)
 	}
 	defer store.DB.Close()
 
 	repo := repository.NewRepository(store.DB)
 
+	// Requests are traced into the configured exporter and measured for /metrics.
+	shutdownTracing, err := tracing.Setup(cfg.Telemetry.Tracing)
+	if err != nil {
+		fatal("Could not set up tracing", err)
+	}
+	metrics.RegisterDB(store.DB, "syllabus")
+	metrics.RegisterStatusCounts(repo)
+
+	// Background workers, waited for on shutdown. The server reports ready
+	// once the data migrations have succeeded.
+	var workers sync.WaitGroup
+	var migrated atomic.Bool
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		if err := migrate(ctx, repo); err != nil {
+			slog.Error("Data migrations failed", "err", err)
+			return
+		}
+		migrated.Store(true)
+	}()
+
+	// Syllabi stay in the trash for the retention period before they are purged.
+	retention := time.Duration(cfg.Trash.RetentionDays) * 24 * time.Hour
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		jobs.PurgeTrash(ctx, repo, retention, cfg.Trash.PurgeInterval)
+	}()
+
+	// Bibliography entries are completed from a works file, the library catalog
+	// and the DOI resolver, in that order.
+	var resolvers bibliography.Resolvers
+	if path := cfg.Bibliography.File; path != "" {
+		works, err := bibliography.NewFileResolver(path)
+		if err != nil {
+			fatal("Could not read bibliography file", err)
+		}
+		resolvers = append(resolvers, works)
+	}
+	if isbnURL, doiURL := cfg.Bibliography.CatalogISBNURL, cfg.Bibliography.CatalogDOIURL; isbnURL != "" || doiURL != "" {
+		resolvers = append(resolvers, &bibliography.CatalogResolver{ISBNURL: isbnURL, DOIURL: doiURL})
+	}
+	if doiURL := cfg.Bibliography.DOIURL; doiURL != "" {
+		resolvers = append(resolvers, &bibliography.CatalogResolver{DOIURL: doiURL})
+	}
+
 	e := echo.New()
-	e.Use(middleware.Logger())
+	e.HideBanner = true
+	e.HidePort = true
+	e.Logger = logging.EchoLogger(logger)
+	// Requests are traced first, so their log lines carry the trace ID.
+	e.Use(mid.TelemetryMiddleware)
+	e.Use(mid.LoggingMiddleware)
 	e.Use(middleware.Recover())
+	e.Use(mid.LocaleMiddleware)
+
+	renderer := Render.NewTemplate(cfg.Server.ViewsDir)
+	e.Renderer = renderer
 
-	e.Renderer = Render.NewTemplate()
+	e.Static("/static", cfg.Server.StaticDir)
 
-	e.Static("/static", "static")
+	handler.RegisterHealth(e,
+		handler.ReadinessCheck{Name: "database", Check: repo.Ping},
+		handler.ReadinessCheck{Name: "migrations", Check: func(context.Context) error {
+			if !migrated.Load() {
+				return errors.New("data migrations still running")
+			}
+			return nil
+		}},
+		handler.ReadinessCheck{Name: "templates", Check: func(context.Context) error { return renderer.Loaded() }},
+	)
+	handler.RegisterRoutes(e, repo, resolvers, cfg.Auth)
+
+	serverErr := make(chan error, 2)
+	slog.Info("Listening", "addr", cfg.Server.Addr)
+	go func() {
+		if err := e.Start(cfg.Server.Addr); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+	}()
+
+	// Metrics are served on an address of their own, so they are not public
+	// along with the app.
+	var metricsServer *http.Server
+	if addr := cfg.Telemetry.MetricsAddr; addr != "" {
+		metricsServer = &http.Server{Addr: addr, Handler: metrics.Handler()}
+		slog.Info("Serving metrics", "addr", addr)
+		go func() {
+			if err := metricsServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				serverErr <- err
+			}
+		}()
+	}
+
+	var failed error
+	select {
+	case failed = <-serverErr:
+		slog.Error("Server failed", "err", failed)
+	case <-ctx.Done():
+		slog.Info("Shutting down")
+	}
+	stop()
+	if metricsServer != nil {
+		metricsServer.Close()
+	}
+	shutdown(e, &workers, cfg.Server.ShutdownTimeout)
+	if err := shutdownTracing(context.Background()); err != nil {
+		slog.Error("Could not flush traces", "err", err)
+	}
+	if failed != nil {
+		store.DB.Close()
+		os.Exit(1)
+	}
+}
 
-	handler.RegisterRoutes(e, repo)
+// migrate brings the data saved by earlier versions up to date, logging what
+// it changed. It stops at the first failure, as each step builds on the ones
+// before it, and when ctx is done.
+func migrate(ctx context.Context, repo *repository.Repository) error {
+	repo = repo.WithContext(ctx)
 
-	e.Logger.Fatal(e.Start(":9090"))
+	// Index the contents of syllabi saved before full-text search existed, or
+	// before the index covered what it covers now.
+	n, err := repo.RebuildSearchIndex()
+	if err != nil {
+		return fmt.Errorf("rebuild search index: %w", err)
+	}
+	if n > 0 {
+		slog.Info("Indexed syllabi for search", "count", n)
+	}
+
+	// Link syllabi saved with a free-text year and semester to academic terms.
+	if n, err = repo.MigrateDraftTerms(); err != nil {
+		return fmt.Errorf("migrate syllabus terms: %w", err)
+	}
+	if n > 0 {
+		slog.Info("Linked syllabi to academic terms", "count", n)
+	}
+
+	// Attach syllabi saved before course offerings existed to their offering.
+	if n, err = repo.BackfillOfferings(); err != nil {
+		return fmt.Errorf("assign course offerings: %w", err)
+	}
+	if n > 0 {
+		slog.Info("Assigned syllabi to course offerings", "count", n)
+	}
+
+	// Publish the syllabi approved before the public pages existed.
+	if n, err = repo.PublishApproved(); err != nil {
+		return fmt.Errorf("publish approved syllabi: %w", err)
+	}
+	if n > 0 {
+		slog.Info("Published approved syllabi", "count", n)
+	}
+	return nil
+}
+
+// shutdown stops the server from accepting requests, then waits up to timeout
+// for the requests in flight and the background workers to finish. The
+// workers stop once the context they were started with is done.
+func shutdown(e *echo.Echo, workers *sync.WaitGroup, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := e.Shutdown(ctx); err != nil {
+		slog.Warn("Requests did not finish before shutdown", "err", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		slog.Warn("Background workers did not finish before shutdown")
+	}
+}
+
+// fatal logs err and exits, for errors the server cannot start with.
+func fatal(msg string, err error) {
+	slog.Error(msg, "err", err)
+	os.Exit(1)
+}
+
+// printConfig writes the configuration loaded from args and the environment,
+// with its secrets redacted, and reports whether it is valid.
+func printConfig(args []string) {
+	cfg, err := config.Load(os.Args[0]+" config print", args, os.Getenv)
+	if err != nil {
+		log.Fatalf("Could not load configuration: %v", err)
+	}
+	if err := cfg.Print(os.Stdout); err != nil {
+		log.Fatal(err)
+	}
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid configuration:\n%v\n", err)
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,123 @@
+// Package metrics collects the Prometheus metrics of the server: the latency
+// of HTTP requests and database queries, the state of the connection pool
+// and the number of syllabi in each status. They are served by Handler.
+package metrics
+
+import (
+	"Syllybea/status"
+	"database/sql"
+	"errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// namespace prefixes the names of the metrics of the server.
+const namespace = "syllabea"
+
+// registry holds the metrics of the server, apart from the default registry
+// so only what is registered here is served.
+var registry = prometheus.NewRegistry()
+
+var (
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Latency of HTTP requests by method, route and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "code"})
+
+	dbDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "Latency of database queries by repository operation and outcome.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"operation", "outcome"})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpDuration,
+		dbDuration,
+	)
+}
+
+// ObserveRequest records how long a request to route took. route is the
+// pattern the request matched, such as "/syllabus/:id", so requests to
+// different syllabi share a series.
+func ObserveRequest(method, route string, code int, d time.Duration) {
+	httpDuration.WithLabelValues(method, route, strconv.Itoa(code)).Observe(d.Seconds())
+}
+
+// ObserveQuery records how long a query of a repository operation took, and
+// whether it failed.
+func ObserveQuery(operation string, d time.Duration, err error) {
+	outcome := "ok"
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		outcome = "error"
+	}
+	dbDuration.WithLabelValues(operation, outcome).Observe(d.Seconds())
+}
+
+// RegisterDB adds the statistics of the connection pool of db: open, idle
+// and in-use connections, and how often and how long callers waited for one.
+func RegisterDB(db *sql.DB, name string) {
+	registry.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+// StatusCounter counts the syllabi in each status, as the repository does.
+type StatusCounter interface {
+	CountSyllabiByStatus() (map[status.Status]int, error)
+}
+
+// RegisterStatusCounts adds a gauge of the syllabi in each status, counted
+// by counter whenever the metrics are scraped.
+func RegisterStatusCounts(counter StatusCounter) {
+	registry.MustRegister(&statusCollector{counter: counter})
+}
+
+var syllabiDesc = prometheus.NewDesc(
+	prometheus.BuildFQName(namespace, "", "syllabi"),
+	"Number of syllabi by status.",
+	[]string{"status"}, nil,
+)
+
+// statusCollector reports the syllabi by status from a fresh count on every
+// scrape, so the gauge cannot drift from the database.
+type statusCollector struct {
+	counter StatusCounter
+}
+
+func (s *statusCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- syllabiDesc
+}
+
+func (s *statusCollector) Collect(ch chan<- prometheus.Metric) {
+	counts, err := s.counter.CountSyllabiByStatus()
+	if err != nil {
+		slog.Error("Could not count syllabi by status", "err", err)
+		ch <- prometheus.NewInvalidMetric(syllabiDesc, err)
+		return
+	}
+	// Every status is reported, so a status no syllabus has reads as 0
+	// instead of disappearing.
+	for _, st := range status.All {
+		ch <- prometheus.MustNewConstMetric(syllabiDesc, prometheus.GaugeValue, float64(counts[st]), string(st))
+	}
+}
+
+// Handler serves the metrics in the Prometheus text format. A metric that
+// cannot be collected, such as the syllabi by status while the database is
+// down, is left out rather than failing the whole scrape.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{
+		Registry:      registry,
+		ErrorHandling: promhttp.ContinueOnError,
+	})
+}
@@ -1,6 +1,7 @@
 package mid
 
 import (
+	"Syllybea/config"
 	"errors"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/labstack/echo/v4"
@@ -9,7 +10,13 @@ import (
 	"time"
 )
 
-var jwtSecret = []byte("secret")
+// auth holds the JWT secret and session lifetime, set by ConfigureAuth at startup.
+var auth config.Auth
+
+// ConfigureAuth sets how sessions are signed and how long they last.
+func ConfigureAuth(cfg config.Auth) {
+	auth = cfg
+}
 
 type customClaims struct {
 	UserID int `json:"user_id"`
@@ -21,18 +28,18 @@ func GenerateToken(userID int) (string, error) {
 	claims := customClaims{
 		UserID: userID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(auth.SessionLifetime)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtSecret)
+	return token.SignedString([]byte(auth.JWTSecret))
 }
 
 func parseToken(tokenStr string) (*customClaims, error) {
 	claims := &customClaims{}
 	token, err := jwt.ParseWithClaims(tokenStr, claims, func(token *jwt.Token) (interface{}, error) {
-		return jwtSecret, nil
+		return []byte(auth.JWTSecret), nil
 	})
 	if err != nil || !token.Valid {
 		return nil, errors.New("invalid token")
@@ -40,8 +47,30 @@ func parseToken(tokenStr string) (*customClaims, error) {
 	return claims, nil
 }
 
+// errTokenMissing is returned when a request carries no JWT at all.
+var errTokenMissing = errors.New("token missing")
+
 // GetUserID retrieves the user ID from a JWT placed in the Authorization header or cookie.
 func GetUserID(c echo.Context) (int, error) {
+	userID, err := requestUserID(c)
+	if errors.Is(err, errTokenMissing) {
+		c.Logger().Warn("missing JWT token")
+	} else if err != nil {
+		c.Logger().Warn("invalid token: ", err)
+	}
+	return userID, err
+}
+
+// SessionUserID is GetUserID for requests that need not come from a logged-in
+// user, such as the exports of a syllabus; a missing or invalid token is not
+// logged.
+func SessionUserID(c echo.Context) (int, error) {
+	return requestUserID(c)
+}
+
+// requestUserID is GetUserID without the warnings, for requests that need
+// not come from a logged-in user.
+func requestUserID(c echo.Context) (int, error) {
 	tokenStr := ""
 
 	authHeader := c.Request().Header.Get("Authorization")
@@ -52,13 +81,11 @@ func GetUserID(c echo.Context) (int, error) {
 	}
 
 	if tokenStr == "" {
-		c.Logger().Warn("missing JWT token")
-		return 0, errors.New("token missing")
+		return 0, errTokenMissing
 	}
 
 	claims, err := parseToken(tokenStr)
 	if err != nil {
-		c.Logger().Warn("invalid token: ", err)
 		return 0, err
 	}
 
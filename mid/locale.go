@@ -0,0 +1,36 @@
+package mid
+
+import (
+	"Syllybea/i18n"
+	"github.com/labstack/echo/v4"
+)
+
+// LocaleCookie keeps the locale the user chose for the user interface.
+const LocaleCookie = "locale"
+
+// localeKey is the context key of the locale negotiated for a request.
+const localeKey = "locale"
+
+// LocaleMiddleware negotiates the locale of every request from the locale the
+// user chose and the Accept-Language header.
+func LocaleMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		chosen := ""
+		if cookie, err := c.Cookie(LocaleCookie); err == nil {
+			chosen = cookie.Value
+		}
+		tag := i18n.Negotiate(chosen, c.Request().Header.Get("Accept-Language"))
+		c.Set(localeKey, tag)
+		c.Response().Header().Set("Content-Language", tag)
+		c.Response().Header().Add("Vary", "Accept-Language")
+		return next(c)
+	}
+}
+
+// GetLocale returns the locale negotiated for the request.
+func GetLocale(c echo.Context) string {
+	if tag, ok := c.Get(localeKey).(string); ok {
+		return tag
+	}
+	return i18n.Default
+}
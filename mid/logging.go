@@ -0,0 +1,124 @@
+package mid
+
+import (
+	"Syllybea/logging"
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/labstack/echo/v4"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// quietRoutes are polled by probes and scrapers, so their requests are
+// logged at debug level only.
+var quietRoutes = map[string]bool{
+	"/healthz":  true,
+	"/readyz":   true,
+	"/metrics":  true,
+	"/static/*": true,
+}
+
+// LoggingMiddleware gives every request an ID, returned in the X-Request-ID
+// header, and attaches it to the request's context with the logged-in user
+// and the syllabus the request is about, so every line logged while serving
+// it carries them. c.Logger() logs through slog with that context, and each
+// request ends with a line of its outcome.
+func LoggingMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := c.Request()
+		id := req.Header.Get(echo.HeaderXRequestID)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		c.Response().Header().Set(echo.HeaderXRequestID, id)
+
+		attrs := []slog.Attr{slog.String("request_id", id)}
+		if userID, err := requestUserID(c); err == nil {
+			attrs = append(attrs, slog.Int("user_id", userID))
+		}
+		if syllabusID := requestSyllabusID(c); syllabusID > 0 {
+			attrs = append(attrs, slog.Int("syllabus_id", syllabusID))
+		}
+		c.SetRequest(req.WithContext(logging.With(req.Context(), attrs...)))
+		c.SetLogger(logging.RequestLogger(c, slog.Default()))
+
+		start := time.Now()
+		err := next(c)
+		if err != nil {
+			// Let echo write the error response now, so its status is logged.
+			c.Error(err)
+		}
+
+		code := c.Response().Status
+		level := slog.LevelInfo
+		switch {
+		case code >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case code >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		case quietRoutes[c.Path()]:
+			level = slog.LevelDebug
+		}
+		reqAttrs := []slog.Attr{
+			slog.String("method", req.Method),
+			slog.String("route", c.Path()),
+			slog.String("path", req.URL.Path),
+			slog.Int("status", code),
+			slog.Duration("duration", time.Since(start)),
+			slog.Int64("bytes", c.Response().Size),
+		}
+		if err != nil {
+			reqAttrs = append(reqAttrs, slog.String("err", err.Error()))
+		}
+		slog.Default().LogAttrs(c.Request().Context(), level, "request", reqAttrs...)
+		return nil
+	}
+}
+
+// LogSyllabus attaches the syllabus a request works on to the lines logged
+// for it, where the request does not name the syllabus itself, such as the
+// updates of the draft being edited.
+func LogSyllabus(c echo.Context, syllabusID int) {
+	ctx := logging.With(c.Request().Context(), slog.Int("syllabus_id", syllabusID))
+	c.SetRequest(c.Request().WithContext(ctx))
+}
+
+// requestSyllabusID returns the syllabus named by the route, as the :id of
+// a syllabus route or a syllabus_id query parameter, or 0 when there is none.
+func requestSyllabusID(c echo.Context) int {
+	if strings.Contains(c.Path(), "syllabus") {
+		if id, err := strconv.Atoi(c.Param("id")); err == nil {
+			return id
+		}
+	}
+	// The body is left to the handler: parsing it here would read every
+	// upload before the request is even authorized.
+	if id, err := strconv.Atoi(c.QueryParam("syllabus_id")); err == nil {
+		return id
+	}
+	return 0
+}
+
+// validRequestID reports whether id, sent by a client or proxy, is short
+// and plain enough to log as the request ID.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 64 {
+		return false
+	}
+	for _, r := range id {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID returns a random ID of 16 hex digits.
+func newRequestID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
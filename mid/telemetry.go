@@ -0,0 +1,54 @@
+package mid
+
+import (
+	"Syllybea/metrics"
+	"Syllybea/tracing"
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"time"
+)
+
+// TelemetryMiddleware times every request for the latency histogram and
+// traces it in a span, which the repository queries made while serving it
+// are children of.
+func TelemetryMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := c.Request()
+		route := c.Path()
+		if route == "" {
+			// Requests no route matched share one series, whatever their path.
+			route = "unmatched"
+		}
+
+		ctx := otel.GetTextMapPropagator().Extract(req.Context(), propagation.HeaderCarrier(req.Header))
+		ctx, span := tracing.Tracer().Start(ctx, req.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(semconv.HTTPRequestMethodKey.String(req.Method), semconv.HTTPRoute(route)),
+		)
+		defer span.End()
+		c.SetRequest(req.WithContext(ctx))
+
+		start := time.Now()
+		err := next(c)
+		if err != nil {
+			// Let echo write the error response now, so its status is recorded.
+			c.Error(err)
+		}
+		code := c.Response().Status
+		metrics.ObserveRequest(req.Method, route, code, time.Since(start))
+
+		span.SetAttributes(semconv.HTTPResponseStatusCode(code))
+		if err != nil {
+			span.RecordError(err)
+		}
+		if code >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(code))
+		}
+		return nil
+	}
+}
@@ -21,6 +21,76 @@ CREATE TABLE IF NOT EXISTS courses (
     FOREIGN KEY (department_id) REFERENCES departments(id) ON DELETE CASCADE
     );
 
+-- Courses a course requires, e.g. (Algorithms, Data Structures)
+CREATE TABLE IF NOT EXISTS course_prerequisites (
+                                                    course_id INT NOT NULL,
+                                                    prerequisite_id INT NOT NULL,
+                                                    PRIMARY KEY (course_id, prerequisite_id),
+    FOREIGN KEY (course_id) REFERENCES courses(id) ON DELETE CASCADE,
+    FOREIGN KEY (prerequisite_id) REFERENCES courses(id) ON DELETE CASCADE
+    );
+
+-- Academic terms. academic_year is the calendar year the academic year starts in
+-- (2025 for תשפ״ו), semester is '1', '2' or 'קיץ'
+CREATE TABLE IF NOT EXISTS terms (
+                                     id INT AUTO_INCREMENT PRIMARY KEY,
+                                     academic_year SMALLINT NOT NULL,
+                                     semester VARCHAR(16) NOT NULL,
+    start_date DATE NOT NULL,
+    end_date DATE NOT NULL,
+    hebrew_label VARCHAR(64) NOT NULL,
+    UNIQUE KEY uq_terms_year_semester (academic_year, semester)
+    );
+
+-- Days without teaching (holidays and academic breaks), inclusive
+CREATE TABLE IF NOT EXISTS holidays (
+                                        id INT AUTO_INCREMENT PRIMARY KEY,
+                                        name VARCHAR(255) NOT NULL,
+    start_date DATE NOT NULL,
+    end_date DATE NOT NULL,
+    INDEX idx_holidays_dates (start_date, end_date)
+    );
+
+-- Learning outcomes of a department's study program, managed by managers.
+-- Course learning outcomes are mapped to them in the syllabus outcome matrix
+CREATE TABLE IF NOT EXISTS program_outcomes (
+                                                id INT AUTO_INCREMENT PRIMARY KEY,
+                                                department_id INT NOT NULL,
+                                                code VARCHAR(20) NOT NULL,
+    description TEXT NOT NULL,
+    UNIQUE KEY uq_program_outcomes_code (department_id, code),
+    FOREIGN KEY (department_id) REFERENCES departments(id) ON DELETE CASCADE
+    );
+
+-- Credit-hour norm of a department; departments without a row use 45 hours per credit, ±20%
+CREATE TABLE IF NOT EXISTS workload_norms (
+                                              department_id INT PRIMARY KEY,
+                                              hours_per_credit DECIMAL(6,2) NOT NULL DEFAULT 45,
+    tolerance_percent DECIMAL(5,2) NOT NULL DEFAULT 20,
+    FOREIGN KEY (department_id) REFERENCES departments(id) ON DELETE CASCADE
+    );
+
+-- A course as given in a specific term and section
+CREATE TABLE IF NOT EXISTS course_offerings (
+                                                id INT AUTO_INCREMENT PRIMARY KEY,
+                                                course_id INT NOT NULL,
+                                                term_id INT NOT NULL,
+                                                section VARCHAR(16) NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_course_offerings_term (course_id, term_id, section),
+    FOREIGN KEY (course_id) REFERENCES courses(id) ON DELETE CASCADE,
+    FOREIGN KEY (term_id) REFERENCES terms(id) ON DELETE CASCADE
+    );
+
+-- Lecturers teaching an offering
+CREATE TABLE IF NOT EXISTS course_offering_lecturers (
+                                                         offering_id INT NOT NULL,
+                                                         user_id INT NOT NULL,
+                                                         PRIMARY KEY (offering_id, user_id),
+    FOREIGN KEY (offering_id) REFERENCES course_offerings(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+    );
+
 -- Create the syllabi table
 CREATE TABLE IF NOT EXISTS syllabi (
                                        id INT AUTO_INCREMENT PRIMARY KEY,
@@ -31,8 +101,17 @@ CREATE TABLE IF NOT EXISTS syllabi (
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
     data JSON NOT NULL,
+    source_syllabus_id INT NULL,
+    search_text LONGTEXT NULL,
+    offering_id INT NULL,
+    status_before_delete VARCHAR(32) NULL,
+    deleted_at TIMESTAMP NULL,
+    INDEX idx_syllabi_deleted_at (status, deleted_at),
+    FULLTEXT INDEX ft_syllabi_search_text (search_text),
     FOREIGN KEY (course_id) REFERENCES courses(id) ON DELETE CASCADE,
-    FOREIGN KEY (lecturer_id) REFERENCES users(id) ON DELETE CASCADE
+    FOREIGN KEY (lecturer_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (source_syllabus_id) REFERENCES syllabi(id) ON DELETE SET NULL,
+    FOREIGN KEY (offering_id) REFERENCES course_offerings(id) ON DELETE SET NULL
     );
 
 CREATE TABLE IF NOT EXISTS comments (
@@ -46,6 +125,62 @@ CREATE TABLE IF NOT EXISTS comments (
     FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
     );
 
+-- Every status transition of a syllabus, used for review turnaround reports
+CREATE TABLE IF NOT EXISTS syllabus_status_history (
+                                                       id INT AUTO_INCREMENT PRIMARY KEY,
+                                                       syllabus_id INT NOT NULL,
+                                                       from_status VARCHAR(32) NULL,
+    to_status VARCHAR(32) NOT NULL,
+    changed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (syllabus_id) REFERENCES syllabi(id) ON DELETE CASCADE
+    );
+
+-- The public copy of each approved syllabus: one per course, term and section,
+-- frozen at approval so later edits are not published until approved again
+CREATE TABLE IF NOT EXISTS published_syllabi (
+                                                 id INT AUTO_INCREMENT PRIMARY KEY,
+                                                 syllabus_id INT NOT NULL,
+                                                 course_id INT NOT NULL,
+                                                 term_id INT NOT NULL DEFAULT 0,
+                                                 section VARCHAR(16) NOT NULL DEFAULT '',
+    slug VARCHAR(128) NOT NULL,
+    data JSON NOT NULL,
+    published_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_published_syllabi_slug (slug),
+    UNIQUE KEY uq_published_syllabi_offering (course_id, term_id, section),
+    INDEX idx_published_syllabi_published_at (published_at),
+    FOREIGN KEY (syllabus_id) REFERENCES syllabi(id) ON DELETE CASCADE,
+    FOREIGN KEY (course_id) REFERENCES courses(id) ON DELETE CASCADE
+    );
+
+-- Department syllabus templates: Draft-shaped JSON plus the sections lecturers may not edit
+CREATE TABLE IF NOT EXISTS syllabus_templates (
+                                                  id INT AUTO_INCREMENT PRIMARY KEY,
+                                                  department_id INT NOT NULL,
+                                                  name VARCHAR(255) NOT NULL,
+    data JSON NOT NULL,
+    locked_sections JSON NOT NULL,
+    created_by INT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (department_id) REFERENCES departments(id) ON DELETE CASCADE,
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL
+    );
+
+-- Append-only audit log of every mutation. No foreign keys, so entries outlive
+-- the users and entities they describe.
+CREATE TABLE IF NOT EXISTS audit_log (
+                                         id BIGINT AUTO_INCREMENT PRIMARY KEY,
+                                         actor_id INT NULL,
+                                         action VARCHAR(64) NOT NULL,
+    entity_type VARCHAR(32) NOT NULL,
+    entity_id INT NOT NULL,
+    changes JSON NOT NULL,
+    ip VARCHAR(45) NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_audit_log_entity (entity_type, entity_id),
+    INDEX idx_audit_log_created_at (created_at)
+    );
 
 
 -- Insert sample departments (Hebrew names)
@@ -63,6 +198,38 @@ VALUES ('מערכות מבוזרות', 1);
 INSERT INTO users (name, email, role)
 VALUES ('מייקל ג''יי מיי', 'michael@example.com', 'Instructor');
 
+-- Insert academic terms
+INSERT INTO terms (academic_year, semester, start_date, end_date, hebrew_label)
+VALUES
+    (2024, '1', '2024-11-03', '2025-02-07', 'תשפ״ה סמסטר א\''),
+    (2024, '2', '2025-03-09', '2025-06-27', 'תשפ״ה סמסטר ב\''),
+    (2024, 'קיץ', '2025-07-13', '2025-09-12', 'תשפ״ה סמסטר קיץ'),
+    (2025, '1', '2025-10-19', '2026-01-23', 'תשפ״ו סמסטר א\''),
+    (2025, '2', '2026-03-01', '2026-06-19', 'תשפ״ו סמסטר ב\''),
+    (2025, 'קיץ', '2026-07-05', '2026-09-04', 'תשפ״ו סמסטר קיץ'),
+    (2026, '1', '2026-10-18', '2027-01-22', 'תשפ״ז סמסטר א\''),
+    (2026, '2', '2027-02-28', '2027-06-18', 'תשפ״ז סמסטר ב\''),
+    (2026, 'קיץ', '2027-07-04', '2027-09-03', 'תשפ״ז סמסטר קיץ');
+
+-- Insert the academic holidays of תשפ״ו and תשפ״ז (later years are added on the terms page)
+INSERT INTO holidays (name, start_date, end_date)
+VALUES
+    ('ראש השנה', '2025-09-22', '2025-09-24'),
+    ('יום כיפור', '2025-10-01', '2025-10-02'),
+    ('סוכות', '2025-10-06', '2025-10-14'),
+    ('פורים', '2026-03-03', '2026-03-03'),
+    ('פסח', '2026-04-01', '2026-04-09'),
+    ('יום הזיכרון ויום העצמאות', '2026-04-21', '2026-04-22'),
+    ('שבועות', '2026-05-21', '2026-05-22'),
+    ('תשעה באב', '2026-07-23', '2026-07-23'),
+    ('ראש השנה', '2026-09-11', '2026-09-13'),
+    ('יום כיפור', '2026-09-20', '2026-09-21'),
+    ('סוכות', '2026-09-25', '2026-10-03'),
+    ('פורים', '2027-03-23', '2027-03-23'),
+    ('פסח', '2027-04-21', '2027-04-28'),
+    ('יום הזיכרון ויום העצמאות', '2027-05-11', '2027-05-12'),
+    ('שבועות', '2027-06-10', '2027-06-11');
+
 -- Insert a syllabus for the course "מערכות מבוזרות" with a JSON null in the data column
 INSERT INTO syllabi (course_id, lecturer_id, status, submission_date, data)
 VALUES (1, 1, 'Draft', CURDATE(), CAST('null' AS JSON));
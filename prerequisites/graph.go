@@ -0,0 +1,134 @@
+// Package prerequisites models the prerequisite relation between courses:
+// it finds the cycles a new prerequisite would close and lays the graph of a
+// department out as SVG.
+package prerequisites
+
+import (
+	"errors"
+	"sort"
+)
+
+// ErrCycle is returned when a prerequisite would make a course, directly or
+// through other courses, a prerequisite of itself.
+var ErrCycle = errors.New("prerequisite cycle")
+
+// Edge makes Prerequisite a prerequisite of Course.
+type Edge struct {
+	Course       int
+	Prerequisite int
+}
+
+// Graph maps a course ID to the IDs of its prerequisites.
+type Graph map[int][]int
+
+// NewGraph builds the graph of a list of edges.
+func NewGraph(edges []Edge) Graph {
+	g := Graph{}
+	for _, e := range edges {
+		g.Add(e.Course, e.Prerequisite)
+	}
+	return g
+}
+
+// Add makes prerequisite a prerequisite of course, once.
+func (g Graph) Add(course, prerequisite int) {
+	for _, p := range g[course] {
+		if p == prerequisite {
+			return
+		}
+	}
+	g[course] = append(g[course], prerequisite)
+}
+
+// Path returns a chain of courses from one course down to another through
+// their prerequisites, both ends included, or nil when there is none.
+func (g Graph) Path(from, to int) []int {
+	seen := map[int]bool{}
+	var walk func(id int) []int
+	walk = func(id int) []int {
+		if id == to {
+			return []int{id}
+		}
+		if seen[id] {
+			return nil
+		}
+		seen[id] = true
+		for _, p := range g[id] {
+			if path := walk(p); path != nil {
+				return append([]int{id}, path...)
+			}
+		}
+		return nil
+	}
+	return walk(from)
+}
+
+// Cycle returns the cycle that making prerequisite a prerequisite of course
+// would close, starting and ending at course, or nil when it would close none.
+func (g Graph) Cycle(course, prerequisite int) []int {
+	path := g.Path(prerequisite, course)
+	if path == nil {
+		return nil
+	}
+	return append([]int{course}, path...)
+}
+
+// Check returns ErrCycle when prerequisite cannot be made a prerequisite of course.
+func (g Graph) Check(course, prerequisite int) error {
+	if g.Cycle(course, prerequisite) != nil {
+		return ErrCycle
+	}
+	return nil
+}
+
+// Levels gives each course the length of the longest chain of prerequisites
+// below it: 0 for courses without prerequisites. Edges that close a cycle are
+// reported separately and ignored, so every course gets a level.
+func (g Graph) Levels() (levels map[int]int, cyclic []Edge) {
+	levels = map[int]int{}
+	state := map[int]int{} // 1 while on the current chain, 2 when done
+	var visit func(id int) int
+	visit = func(id int) int {
+		if state[id] == 2 {
+			return levels[id]
+		}
+		state[id] = 1
+		level := 0
+		for _, p := range g[id] {
+			if state[p] == 1 {
+				cyclic = append(cyclic, Edge{Course: id, Prerequisite: p})
+				continue
+			}
+			if l := visit(p) + 1; l > level {
+				level = l
+			}
+		}
+		state[id] = 2
+		levels[id] = level
+		return level
+	}
+	for _, id := range g.courses() {
+		visit(id)
+	}
+	return levels, cyclic
+}
+
+// courses lists every course in the graph, with or without prerequisites, in ID order.
+func (g Graph) courses() []int {
+	seen := map[int]bool{}
+	var ids []int
+	add := func(id int) {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	for id, prereqs := range g {
+		add(id)
+		for _, p := range prereqs {
+			add(p)
+		}
+	}
+	sort.Ints(ids)
+	return ids
+}
@@ -0,0 +1,161 @@
+package prerequisites
+
+import (
+	"Syllybea/i18n"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Node is a course drawn in the graph.
+type Node struct {
+	ID       int
+	Name     string
+	Approved bool // The course has an approved syllabus
+	External bool // The course belongs to another department than the graph
+}
+
+// Layout of the drawing, in pixels. Courses without prerequisites are drawn
+// in the rightmost column and each level of prerequisites one column to the
+// left, so the graph reads from right to left like the Hebrew around it.
+const (
+	nodeWidth    = 180
+	nodeHeight   = 40
+	columnGap    = 80
+	rowGap       = 20
+	margin       = 20
+	maxNameRunes = 24
+)
+
+const svgStyle = `
+.node rect { fill: #ffffff; stroke: #617CFF; stroke-width: 1.5; }
+.node.missing rect { fill: #fff4f4; stroke: #d9534f; }
+.node.external rect { stroke-dasharray: 4 3; }
+.node text { font-family: Rubik, Arial, sans-serif; font-size: 13px; fill: #333333; }
+.edge { fill: none; stroke: #888888; stroke-width: 1.2; }
+.edge.cycle { stroke: #d9534f; stroke-dasharray: 5 3; }
+`
+
+// WriteSVG draws the prerequisite graph of the given courses as an SVG
+// document. Only edges between the given courses are drawn; edges that close
+// a cycle are drawn dashed in red, and courses without an approved syllabus
+// are highlighted. The text of the drawing is in the given locale.
+func WriteSVG(w io.Writer, nodes []Node, g Graph, locale string) error {
+	byID := make(map[int]Node, len(nodes))
+	for _, n := range nodes {
+		byID[n.ID] = n
+	}
+	sub := Graph{}
+	var edges []Edge
+	for _, n := range nodes {
+		sub[n.ID] = nil
+		for _, p := range g[n.ID] {
+			if _, ok := byID[p]; ok {
+				sub.Add(n.ID, p)
+				edges = append(edges, Edge{Course: n.ID, Prerequisite: p})
+			}
+		}
+	}
+	levels, cyclic := sub.Levels()
+	isCyclic := map[Edge]bool{}
+	for _, e := range cyclic {
+		isCyclic[e] = true
+	}
+
+	// Columns by level, courses sorted by name within each column.
+	maxLevel := 0
+	for _, l := range levels {
+		if l > maxLevel {
+			maxLevel = l
+		}
+	}
+	columns := make([][]Node, maxLevel+1)
+	for _, n := range nodes {
+		columns[levels[n.ID]] = append(columns[levels[n.ID]], n)
+	}
+	rows := 0
+	for _, col := range columns {
+		sort.Slice(col, func(i, j int) bool { return col[i].Name < col[j].Name })
+		if len(col) > rows {
+			rows = len(col)
+		}
+	}
+
+	type point struct{ x, y int }
+	pos := map[int]point{}
+	for level, col := range columns {
+		x := margin + (maxLevel-level)*(nodeWidth+columnGap)
+		for row, n := range col {
+			pos[n.ID] = point{x, margin + row*(nodeHeight+rowGap)}
+		}
+	}
+
+	width := 2*margin + (maxLevel+1)*nodeWidth + maxLevel*columnGap
+	height := 2*margin + rows*nodeHeight + max(rows-1, 0)*rowGap
+	if len(nodes) == 0 {
+		width, height = 2*margin+nodeWidth, 2*margin+nodeHeight
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" direction="rtl">`+"\n", width, height, width, height)
+	fmt.Fprintf(bw, "<style>%s</style>\n", svgStyle)
+	bw.WriteString(`<defs><marker id="arrow" viewBox="0 0 10 10" refX="10" refY="5" markerWidth="7" markerHeight="7" orient="auto-start-reverse"><path d="M 0 0 L 10 5 L 0 10 z" fill="#888888"/></marker></defs>` + "\n")
+
+	if len(nodes) == 0 {
+		fmt.Fprintf(bw, `<text x="%d" y="%d" text-anchor="middle" font-size="13">%s</text>`+"\n", width/2, height/2, escape(i18n.T(locale, "אין קורסים")))
+	}
+
+	// Edges run from the left side of a prerequisite to the right side of the
+	// course that requires it.
+	for _, e := range edges {
+		from, to := pos[e.Prerequisite], pos[e.Course]
+		x1, y1 := from.x, from.y+nodeHeight/2
+		x2, y2 := to.x+nodeWidth, to.y+nodeHeight/2
+		class := "edge"
+		if isCyclic[e] {
+			class += " cycle"
+			// A back edge points the other way; bend it around the columns.
+			x1, x2 = from.x+nodeWidth, to.x
+		}
+		mid := (x1 + x2) / 2
+		fmt.Fprintf(bw, `<path class="%s" d="M %d %d C %d %d, %d %d, %d %d" marker-end="url(#arrow)"/>`+"\n",
+			class, x1, y1, mid, y1, mid, y2, x2, y2)
+	}
+
+	for _, n := range nodes {
+		p := pos[n.ID]
+		class := "node"
+		if !n.Approved {
+			class += " missing"
+		}
+		if n.External {
+			class += " external"
+		}
+		fmt.Fprintf(bw, `<g class="%s"><title>%s</title>`, class, escape(n.Name))
+		fmt.Fprintf(bw, `<rect x="%d" y="%d" width="%d" height="%d" rx="6"/>`, p.x, p.y, nodeWidth, nodeHeight)
+		fmt.Fprintf(bw, `<text x="%d" y="%d" text-anchor="middle" dominant-baseline="middle">%s</text></g>`+"\n",
+			p.x+nodeWidth/2, p.y+nodeHeight/2, escape(truncate(n.Name)))
+	}
+
+	bw.WriteString("</svg>\n")
+	return bw.Flush()
+}
+
+// truncate shortens a course name to fit its box.
+func truncate(s string) string {
+	s = strings.TrimSpace(s)
+	if utf8.RuneCountInString(s) <= maxNameRunes {
+		return s
+	}
+	return string([]rune(s)[:maxNameRunes-1]) + "…"
+}
+
+func escape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
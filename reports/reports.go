@@ -0,0 +1,494 @@
+// Package reports computes the department-level analytics shown to managers:
+// approval rates, review turnaround, missing syllabi and content metrics.
+package reports
+
+import (
+	"Syllybea/UIcomponents"
+	"Syllybea/bibliography"
+	"Syllybea/i18n"
+	"Syllybea/status"
+	"Syllybea/types"
+	"Syllybea/utils"
+	"Syllybea/workload"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Table is a computed report ready to be rendered as HTML or exported as CSV.
+type Table struct {
+	Name    string // URL-safe identifier, used for the CSV download
+	Title   string
+	Columns []string
+	Rows    [][]string
+}
+
+// WriteCSV writes the table as UTF-8 CSV with a BOM so spreadsheet
+// applications display the Hebrew text correctly.
+func (t Table) WriteCSV(w io.Writer) error {
+	if _, err := io.WriteString(w, "\ufeff"); err != nil {
+		return err
+	}
+	cw := csv.NewWriter(w)
+	if err := cw.Write(t.Columns); err != nil {
+		return err
+	}
+	if err := cw.WriteAll(t.Rows); err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+// Input is everything the reports are computed from.
+type Input struct {
+	Syllabi     []types.Syllabus // Non-deleted syllabi, including their Draft JSON
+	Courses     []types.Course
+	Departments []types.Department
+	History     []types.StatusChange
+	Terms       []types.Term // Latest first, as returned by the repository
+	Offerings   []types.CourseOffering
+
+	ProgramOutcomes []types.ProgramOutcome
+	WorkloadNorms   []types.WorkloadNorm // One per department
+
+	Locale string // Locale tag of the titles, columns and labels of the reports
+}
+
+// topBibliographyLimit is the number of entries listed in the most-cited report.
+const topBibliographyLimit = 20
+
+// All computes every report in display order.
+func All(in Input) []Table {
+	drafts := decodeDrafts(in.Syllabi)
+	return []Table{
+		ApprovalRates(in, drafts),
+		ReviewTurnaround(in),
+		MissingSyllabi(in),
+		GradeDistribution(drafts, in.Locale),
+		TopBibliography(drafts, topBibliographyLimit, in.Locale),
+		OutcomeCoverage(in, drafts),
+		WorkloadOutliers(in, drafts),
+	}
+}
+
+// Find computes the report with the given name.
+func Find(in Input, name string) (Table, bool) {
+	for _, t := range All(in) {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return Table{}, false
+}
+
+// decodeDrafts unmarshals each syllabus' data, keyed by syllabus ID.
+// Syllabi with missing or invalid data are skipped.
+func decodeDrafts(syllabi []types.Syllabus) map[int]UIcomponents.Draft {
+	drafts := make(map[int]UIcomponents.Draft, len(syllabi))
+	for _, s := range syllabi {
+		var d UIcomponents.Draft
+		if len(s.Data) == 0 || json.Unmarshal(s.Data, &d) != nil {
+			continue
+		}
+		drafts[s.ID] = d
+	}
+	return drafts
+}
+
+// ApprovalRates reports, per term, the share of the course offerings of the
+// term that have an approved syllabus. Syllabi not linked to an offering are
+// grouped by their semester alone, by course, with no offerings to compare to.
+func ApprovalRates(in Input, drafts map[int]UIcomponents.Draft) Table {
+	termLabels := map[int]string{}
+	for _, t := range in.Terms {
+		termLabels[t.ID] = t.HebrewLabel
+	}
+	offeringTerms := map[int]string{}
+	offerings := map[string]int{}
+	for _, o := range in.Offerings {
+		label := termLabels[o.TermID]
+		offeringTerms[o.ID] = label
+		offerings[label]++
+	}
+
+	// Offerings with a syllabus per term, or courses for the unlinked semesters.
+	withSyllabus := map[string]map[int]bool{}
+	approved := map[string]map[int]bool{}
+	for _, s := range in.Syllabi {
+		semester, ok := offeringTerms[s.OfferingID]
+		key := s.OfferingID
+		if !ok {
+			semester = i18n.T(in.Locale, "לא צוין")
+			if label := utils.SemesterLabel(drafts[s.ID].Semester); label != "" {
+				semester = i18n.T(in.Locale, label)
+			}
+			key = s.CourseID
+		}
+		if withSyllabus[semester] == nil {
+			withSyllabus[semester] = map[int]bool{}
+			approved[semester] = map[int]bool{}
+		}
+		withSyllabus[semester][key] = true
+		if s.Status == status.Approved {
+			approved[semester][key] = true
+		}
+	}
+
+	// Terms in chronological order, then the unlinked semesters.
+	var semesters, unlinked []string
+	for i := len(in.Terms) - 1; i >= 0; i-- {
+		if label := in.Terms[i].HebrewLabel; offerings[label] > 0 {
+			semesters = append(semesters, label)
+		}
+	}
+	for sem := range withSyllabus {
+		if offerings[sem] == 0 {
+			unlinked = append(unlinked, sem)
+		}
+	}
+	sort.Strings(unlinked)
+
+	t := Table{
+		Name:    "approval-rates",
+		Title:   i18n.T(in.Locale, "אחוז המועדים עם סילבוס מאושר לפי סמסטר"),
+		Columns: translate(in.Locale, "סמסטר", "מועדים בסמסטר", "מועדים עם סילבוס", "מועדים עם סילבוס מאושר", "אחוז מאושר"),
+	}
+	for _, sem := range semesters {
+		t.Rows = append(t.Rows, []string{
+			sem,
+			strconv.Itoa(offerings[sem]),
+			strconv.Itoa(len(withSyllabus[sem])),
+			strconv.Itoa(len(approved[sem])),
+			percent(len(approved[sem]), offerings[sem]),
+		})
+	}
+	for _, sem := range unlinked {
+		t.Rows = append(t.Rows, []string{sem, "", strconv.Itoa(len(withSyllabus[sem])), strconv.Itoa(len(approved[sem])), ""})
+	}
+	return t
+}
+
+// ReviewTurnaround reports the median time from submission for review to approval, per department.
+func ReviewTurnaround(in Input) Table {
+	courseDept := map[int]int{}
+	for _, c := range in.Courses {
+		courseDept[c.ID] = c.DepartmentID
+	}
+	sylDept := map[int]int{}
+	for _, s := range in.Syllabi {
+		sylDept[s.ID] = courseDept[s.CourseID]
+	}
+
+	// Pair each approval with the latest submission that preceded it.
+	submitted := map[int]time.Time{}
+	durations := map[int][]time.Duration{}
+	var all []time.Duration
+	for _, h := range in.History {
+		switch h.ToStatus {
+		case status.InReview:
+			submitted[h.SyllabusID] = h.ChangedAt
+		case status.Approved:
+			start, ok := submitted[h.SyllabusID]
+			if !ok {
+				continue
+			}
+			dept, known := sylDept[h.SyllabusID]
+			if !known {
+				// Deleted since; not part of the current picture.
+				continue
+			}
+			d := h.ChangedAt.Sub(start)
+			durations[dept] = append(durations[dept], d)
+			all = append(all, d)
+			delete(submitted, h.SyllabusID)
+		}
+	}
+
+	t := Table{
+		Name:    "review-turnaround",
+		Title:   i18n.T(in.Locale, "זמן חציוני מהגשה לאישור"),
+		Columns: translate(in.Locale, "מחלקה", "סילבוסים שאושרו", "חציון (ימים)"),
+	}
+	for _, d := range in.Departments {
+		t.Rows = append(t.Rows, []string{d.Name, strconv.Itoa(len(durations[d.ID])), medianDays(durations[d.ID])})
+	}
+	t.Rows = append(t.Rows, []string{i18n.T(in.Locale, "כל המחלקות"), strconv.Itoa(len(all)), medianDays(all)})
+	return t
+}
+
+// MissingSyllabi lists the catalog courses that have no syllabus at all.
+func MissingSyllabi(in Input) Table {
+	deptNames := map[int]string{}
+	for _, d := range in.Departments {
+		deptNames[d.ID] = d.Name
+	}
+	hasSyllabus := map[int]bool{}
+	for _, s := range in.Syllabi {
+		hasSyllabus[s.CourseID] = true
+	}
+
+	t := Table{
+		Name:    "missing-syllabi",
+		Title:   i18n.T(in.Locale, "קורסים ללא סילבוס"),
+		Columns: translate(in.Locale, "קורס", "מחלקה"),
+	}
+	for _, c := range in.Courses {
+		if !hasSyllabus[c.ID] {
+			t.Rows = append(t.Rows, []string{c.Name, deptNames[c.DepartmentID]})
+		}
+	}
+	sort.Slice(t.Rows, func(i, j int) bool {
+		if t.Rows[i][1] != t.Rows[j][1] {
+			return t.Rows[i][1] < t.Rows[j][1]
+		}
+		return t.Rows[i][0] < t.Rows[j][0]
+	})
+	return t
+}
+
+// GradeDistribution reports the average weight of each grade component across syllabi.
+func GradeDistribution(drafts map[int]UIcomponents.Draft, locale string) Table {
+	type acc struct {
+		label string
+		sum   float64
+		count int
+	}
+	byName := map[string]*acc{}
+	for _, d := range drafts {
+		for _, comp := range d.GradeComponents {
+			key := normalize(comp.PartName)
+			if key == "" {
+				continue
+			}
+			pct := comp.Weight
+			if pct <= 0 {
+				continue
+			}
+			a := byName[key]
+			if a == nil {
+				a = &acc{label: strings.TrimSpace(comp.PartName)}
+				byName[key] = a
+			}
+			a.sum += pct
+			a.count++
+		}
+	}
+
+	accs := make([]*acc, 0, len(byName))
+	for _, a := range byName {
+		accs = append(accs, a)
+	}
+	sort.Slice(accs, func(i, j int) bool {
+		if accs[i].count != accs[j].count {
+			return accs[i].count > accs[j].count
+		}
+		return accs[i].label < accs[j].label
+	})
+
+	t := Table{
+		Name:    "grade-distribution",
+		Title:   i18n.T(locale, "התפלגות ממוצעת של רכיבי ציון"),
+		Columns: translate(locale, "רכיב ציון", "מספר סילבוסים", "משקל ממוצע (%)"),
+	}
+	for _, a := range accs {
+		t.Rows = append(t.Rows, []string{a.label, strconv.Itoa(a.count), fmt.Sprintf("%.1f", a.sum/float64(a.count))})
+	}
+	return t
+}
+
+// OutcomeCoverage reports, for each program outcome, the courses whose syllabi
+// map a learning outcome to it and how many of those assess that outcome.
+func OutcomeCoverage(in Input, drafts map[int]UIcomponents.Draft) Table {
+	courseNames := map[int]string{}
+	for _, c := range in.Courses {
+		courseNames[c.ID] = c.Name
+	}
+	mapped := map[int]map[int]bool{}   // program outcome -> course IDs
+	assessed := map[int]map[int]bool{} // program outcome -> course IDs assessing it
+	for _, s := range in.Syllabi {
+		d, ok := drafts[s.ID]
+		if !ok {
+			continue
+		}
+		for _, row := range d.OutcomeMatrix().Rows {
+			for _, id := range row.Links.ProgramOutcomes {
+				if mapped[id] == nil {
+					mapped[id] = map[int]bool{}
+					assessed[id] = map[int]bool{}
+				}
+				mapped[id][s.CourseID] = true
+				if row.Assessed {
+					assessed[id][s.CourseID] = true
+				}
+			}
+		}
+	}
+
+	t := Table{
+		Name:    "outcome-coverage",
+		Title:   i18n.T(in.Locale, "כיסוי תוצרי הלמידה של התכנית"),
+		Columns: translate(in.Locale, "מחלקה", "תוצר תכנית", "תיאור", "קורסים ממופים", "קורסים עם הערכה", "קורסים"),
+	}
+	for _, po := range in.ProgramOutcomes {
+		var courses []string
+		for id := range mapped[po.ID] {
+			courses = append(courses, courseNames[id])
+		}
+		sort.Strings(courses)
+		t.Rows = append(t.Rows, []string{
+			po.DepartmentName,
+			po.Code,
+			po.Description,
+			strconv.Itoa(len(mapped[po.ID])),
+			strconv.Itoa(len(assessed[po.ID])),
+			strings.Join(courses, ", "),
+		})
+	}
+	return t
+}
+
+// WorkloadOutliers lists the syllabi whose estimated student workload is
+// outside the credit-hour norm of their course's department.
+func WorkloadOutliers(in Input, drafts map[int]UIcomponents.Draft) Table {
+	courses := map[int]types.Course{}
+	for _, c := range in.Courses {
+		courses[c.ID] = c
+	}
+	norms := map[int]types.WorkloadNorm{}
+	for _, n := range in.WorkloadNorms {
+		norms[n.DepartmentID] = n
+	}
+
+	t := Table{
+		Name:    "workload-outliers",
+		Title:   i18n.T(in.Locale, "סילבוסים בעומס חריג"),
+		Columns: translate(in.Locale, "מחלקה", "קורס", "סטטוס", "נ״ז", "עומס מוערך", "נורמה", "סטייה"),
+	}
+	for _, s := range in.Syllabi {
+		d, ok := drafts[s.ID]
+		if !ok {
+			continue
+		}
+		course := courses[s.CourseID]
+		norm := norms[course.DepartmentID]
+		d.WorkloadNorm = workload.Norm{HoursPerCredit: norm.HoursPerCredit, TolerancePercent: norm.TolerancePercent}
+		w := d.Workload()
+		if w.Expected == 0 || w.WithinNorm() {
+			continue
+		}
+		t.Rows = append(t.Rows, []string{
+			norm.DepartmentName,
+			course.Name,
+			s.Status.Label(in.Locale),
+			d.Credits,
+			workload.Hours(w.Total),
+			workload.Hours(w.Expected),
+			fmt.Sprintf("%+.0f%%", w.Deviation()),
+		})
+	}
+	sort.Slice(t.Rows, func(i, j int) bool {
+		if t.Rows[i][0] != t.Rows[j][0] {
+			return t.Rows[i][0] < t.Rows[j][0]
+		}
+		return t.Rows[i][1] < t.Rows[j][1]
+	})
+	return t
+}
+
+// TopBibliography lists the most cited bibliography entries across syllabi.
+// Entries are matched like duplicates within a syllabus, and shown in APA style.
+func TopBibliography(drafts map[int]UIcomponents.Draft, limit int, locale string) Table {
+	type entry struct {
+		label    string
+		count    int
+		required int
+	}
+	byKey := map[string]*entry{}
+	add := func(item bibliography.Entry, required bool) {
+		key := item.Key()
+		if key == "" {
+			return
+		}
+		e := byKey[key]
+		if e == nil {
+			e = &entry{label: item.Format(bibliography.APA)}
+			byKey[key] = e
+		}
+		e.count++
+		if required {
+			e.required++
+		}
+	}
+	for _, d := range drafts {
+		for _, b := range d.BibliographyRequired {
+			add(b, true)
+		}
+		for _, b := range d.BibliographyRecommended {
+			add(b, false)
+		}
+	}
+
+	entries := make([]*entry, 0, len(byKey))
+	for _, e := range byKey {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].count != entries[j].count {
+			return entries[i].count > entries[j].count
+		}
+		return entries[i].label < entries[j].label
+	})
+	if len(entries) > limit {
+		entries = entries[:limit]
+	}
+
+	t := Table{
+		Name:    "top-bibliography",
+		Title:   i18n.T(locale, "הפריטים הביבליוגרפיים המצוטטים ביותר"),
+		Columns: translate(locale, "פריט", "מספר סילבוסים", "מתוכם קריאת חובה"),
+	}
+	for _, e := range entries {
+		t.Rows = append(t.Rows, []string{e.label, strconv.Itoa(e.count), strconv.Itoa(e.required)})
+	}
+	return t
+}
+
+// translate translates the column names of a report to a locale.
+func translate(locale string, columns ...string) []string {
+	translated := make([]string, len(columns))
+	for i, c := range columns {
+		translated[i] = i18n.T(locale, c)
+	}
+	return translated
+}
+
+func percent(part, total int) string {
+	if total == 0 {
+		return "0%"
+	}
+	return fmt.Sprintf("%.0f%%", float64(part)*100/float64(total))
+}
+
+func medianDays(ds []time.Duration) string {
+	if len(ds) == 0 {
+		return "-"
+	}
+	sorted := append([]time.Duration(nil), ds...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	mid := len(sorted) / 2
+	median := sorted[mid]
+	if len(sorted)%2 == 0 {
+		median = (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return fmt.Sprintf("%.1f", median.Hours()/24)
+}
+
+// normalize folds an entry for counting: lower-case with collapsed whitespace.
+func normalize(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+}
@@ -0,0 +1,260 @@
+package repository
+
+import (
+	"Syllybea/status"
+	"Syllybea/types"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// =============================
+//        AUDIT LOG
+// =============================
+
+// Actor identifies who performs the mutations made through a Repository.
+type Actor struct {
+	UserID int // 0 when nobody is logged in
+	IP     string
+}
+
+// WithActor returns a copy of the repository whose mutations are attributed to a.
+// The copy shares the DB connection, so it is cheap to create per request.
+func (r *Repository) WithActor(a Actor) *Repository {
+	scoped := *r
+	scoped.actor = a
+	return &scoped
+}
+
+// AuditFilter holds the optional filters of the audit log page. Zero values are ignored.
+type AuditFilter struct {
+	ActorID    int
+	Action     string
+	EntityType string
+	EntityID   int
+	FromDate   string // YYYY-MM-DD, inclusive
+	ToDate     string // YYYY-MM-DD, inclusive
+	Limit      int
+}
+
+// auditIgnoredKeys are bookkeeping fields that change on every write or are
+// filled in by joins, and would only add noise to the recorded changes.
+var auditIgnoredKeys = map[string]bool{
+	"created_at":      true,
+	"updated_at":      true,
+	"department_name": true,
+}
+
+// fieldChange is the before/after pair recorded for a single changed field.
+type fieldChange struct {
+	Before interface{} `json:"before,omitempty"`
+	After  interface{} `json:"after,omitempty"`
+}
+
+// audit appends an entry for a mutation to the audit log. before is nil for
+// creations and after is nil for deletions; updates that change nothing are skipped.
+func (r *Repository) audit(action, entityType string, entityID int, before, after interface{}) error {
+	changes, err := auditChanges(before, after)
+	if err != nil {
+		return fmt.Errorf("audit %s: %w", action, err)
+	}
+	if len(changes) == 0 && before != nil && after != nil {
+		return nil
+	}
+	data, err := json.Marshal(changes)
+	if err != nil {
+		return fmt.Errorf("audit %s (marshal): %w", action, err)
+	}
+
+	query := `INSERT INTO audit_log (actor_id, action, entity_type, entity_id, changes, ip) VALUES (?, ?, ?, ?, ?, ?)`
+	if _, err := r.db.Exec(query, nullableID(r.actor.UserID), action, entityType, entityID, data, r.actor.IP); err != nil {
+		return fmt.Errorf("audit %s: %w", action, err)
+	}
+	return nil
+}
+
+// auditChanges compares the JSON form of two snapshots field by field.
+// Nested objects are flattened into dotted keys such as "data.title".
+func auditChanges(before, after interface{}) (map[string]fieldChange, error) {
+	b, err := flattenJSON(before)
+	if err != nil {
+		return nil, err
+	}
+	a, err := flattenJSON(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := map[string]fieldChange{}
+	for k, bv := range b {
+		if av, ok := a[k]; !ok || !reflect.DeepEqual(bv, av) {
+			changes[k] = fieldChange{Before: bv, After: av}
+		}
+	}
+	for k, av := range a {
+		if _, ok := b[k]; !ok {
+			changes[k] = fieldChange{After: av}
+		}
+	}
+	return changes, nil
+}
+
+// flattenJSON marshals v and returns its leaf values keyed by dotted path.
+func flattenJSON(v interface{}) (map[string]interface{}, error) {
+	flat := map[string]interface{}{}
+	if v == nil {
+		return flat, nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var generic interface{}
+	if err := json.Unmarshal(raw, &generic); err != nil {
+		return nil, err
+	}
+
+	var walk func(prefix string, v interface{})
+	walk = func(prefix string, v interface{}) {
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			flat[prefix] = v
+			return
+		}
+		for k, child := range obj {
+			if auditIgnoredKeys[k] {
+				continue
+			}
+			key := k
+			if prefix != "" {
+				key = prefix + "." + k
+			}
+			walk(key, child)
+		}
+	}
+	if _, ok := generic.(map[string]interface{}); ok {
+		walk("", generic)
+	} else {
+		flat["value"] = generic
+	}
+	return flat, nil
+}
+
+// syllabusSnapshot is the audited form of a syllabus. The submission date is
+// kept at the DATE precision the column stores.
+func syllabusSnapshot(s *types.Syllabus) map[string]interface{} {
+	return map[string]interface{}{
+		"course_id":          s.CourseID,
+		"lecturer_id":        s.LecturerID,
+		"status":             s.Status,
+		"submission_date":    s.SubmissionDate.Format("2006-01-02"),
+		"source_syllabus_id": s.SourceSyllabusID,
+		"offering_id":        s.OfferingID,
+		"data":               s.Data,
+	}
+}
+
+// syllabusAction names a syllabus update after the status transition it makes.
+func syllabusAction(from, to status.Status) string {
+	switch {
+	case from == to:
+		return "syllabus.update"
+	case to == status.Deleted:
+		return "syllabus.delete"
+	case from == status.Deleted:
+		return "syllabus.restore"
+	case to == status.InReview:
+		return "syllabus.submit"
+	case to == status.Approved:
+		return "syllabus.approve"
+	case from == status.InReview && to == status.Draft:
+		return "syllabus.return"
+	}
+	return "syllabus.update"
+}
+
+// GetAuditLog retrieves audit entries matching the filter, newest first.
+func (r *Repository) GetAuditLog(f AuditFilter) ([]types.AuditEntry, error) {
+	var conditions []string
+	var args []interface{}
+	if f.ActorID > 0 {
+		conditions = append(conditions, "a.actor_id = ?")
+		args = append(args, f.ActorID)
+	}
+	if f.Action != "" {
+		conditions = append(conditions, "a.action = ?")
+		args = append(args, f.Action)
+	}
+	if f.EntityType != "" {
+		conditions = append(conditions, "a.entity_type = ?")
+		args = append(args, f.EntityType)
+	}
+	if f.EntityID > 0 {
+		conditions = append(conditions, "a.entity_id = ?")
+		args = append(args, f.EntityID)
+	}
+	if f.FromDate != "" {
+		conditions = append(conditions, "a.created_at >= ?")
+		args = append(args, f.FromDate+" 00:00:00")
+	}
+	if f.ToDate != "" {
+		conditions = append(conditions, "a.created_at <= ?")
+		args = append(args, f.ToDate+" 23:59:59")
+	}
+
+	query := `
+		SELECT a.id, COALESCE(a.actor_id, 0), COALESCE(u.name, ''), a.action, a.entity_type, a.entity_id, a.changes, a.ip, a.created_at
+		FROM audit_log a
+		LEFT JOIN users u ON a.actor_id = u.id
+	`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY a.created_at DESC, a.id DESC"
+	if f.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, f.Limit)
+	}
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("GetAuditLog: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []types.AuditEntry
+	for rows.Next() {
+		var e types.AuditEntry
+		var createdAtStr string
+		if err := rows.Scan(&e.ID, &e.ActorID, &e.ActorName, &e.Action, &e.EntityType, &e.EntityID, &e.Changes, &e.IP, &createdAtStr); err != nil {
+			return nil, fmt.Errorf("GetAuditLog scan: %w", err)
+		}
+		e.CreatedAt, err = time.Parse("2006-01-02 15:04:05", createdAtStr)
+		if err != nil {
+			return nil, fmt.Errorf("GetAuditLog: parsing created_at: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// GetAuditActions lists the distinct actions recorded so far, for the filter dropdown.
+func (r *Repository) GetAuditActions() ([]string, error) {
+	rows, err := r.db.Query(`SELECT DISTINCT action FROM audit_log ORDER BY action`)
+	if err != nil {
+		return nil, fmt.Errorf("GetAuditActions: %w", err)
+	}
+	defer rows.Close()
+
+	var actions []string
+	for rows.Next() {
+		var a string
+		if err := rows.Scan(&a); err != nil {
+			return nil, fmt.Errorf("GetAuditActions scan: %w", err)
+		}
+		actions = append(actions, a)
+	}
+	return actions, nil
+}
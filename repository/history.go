@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"Syllybea/status"
+	"Syllybea/types"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// =============================
+//     STATUS HISTORY
+// =============================
+
+// currentStatus returns the stored status of a syllabus, or no status if it does not exist.
+func (r *Repository) currentStatus(syllabusID int) (status.Status, error) {
+	var st status.Status
+	err := r.db.QueryRow(`SELECT status FROM syllabi WHERE id = ?`, syllabusID).Scan(&st)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("currentStatus: %w", err)
+	}
+	return st, nil
+}
+
+// statusList returns an SQL list of placeholders for statuses, e.g. "(?, ?)",
+// with the statuses as its arguments.
+func statusList(statuses []status.Status) (string, []interface{}) {
+	placeholders := make([]string, len(statuses))
+	args := make([]interface{}, len(statuses))
+	for i, st := range statuses {
+		placeholders[i] = "?"
+		args[i] = st
+	}
+	return "(" + strings.Join(placeholders, ", ") + ")", args
+}
+
+// recordStatusChange appends a status transition to the history when the
+// status changed. The first status of a syllabus is recorded from NULL.
+func (r *Repository) recordStatusChange(syllabusID int, from, to status.Status) error {
+	if from == to {
+		return nil
+	}
+	query := `INSERT INTO syllabus_status_history (syllabus_id, from_status, to_status) VALUES (?, ?, ?)`
+	if _, err := r.db.Exec(query, syllabusID, from, to); err != nil {
+		return fmt.Errorf("recordStatusChange: %w", err)
+	}
+	return nil
+}
+
+// GetStatusHistory retrieves all recorded status transitions in chronological order.
+func (r *Repository) GetStatusHistory() ([]types.StatusChange, error) {
+	query := `
+		SELECT id, syllabus_id, from_status, to_status, changed_at
+		FROM syllabus_status_history
+		ORDER BY changed_at, id
+	`
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("GetStatusHistory: %w", err)
+	}
+	defer rows.Close()
+
+	var history []types.StatusChange
+	for rows.Next() {
+		var h types.StatusChange
+		var changedAtStr string
+		if err := rows.Scan(&h.ID, &h.SyllabusID, &h.FromStatus, &h.ToStatus, &changedAtStr); err != nil {
+			return nil, fmt.Errorf("GetStatusHistory scan: %w", err)
+		}
+		h.ChangedAt, err = time.Parse("2006-01-02 15:04:05", changedAtStr)
+		if err != nil {
+			return nil, fmt.Errorf("GetStatusHistory: parsing changed_at: %w", err)
+		}
+		history = append(history, h)
+	}
+	return history, nil
+}
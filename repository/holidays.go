@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"Syllybea/types"
+	"fmt"
+	"time"
+)
+
+// =============================
+//          HOLIDAYS
+// =============================
+
+// CreateHoliday inserts a new holiday.
+func (r *Repository) CreateHoliday(h *types.Holiday) error {
+	return r.transaction(func(r *Repository) error {
+		query := `INSERT INTO holidays (name, start_date, end_date) VALUES (?, ?, ?)`
+		result, err := r.db.Exec(query, h.Name, h.StartDate.Format("2006-01-02"), h.EndDate.Format("2006-01-02"))
+		if err != nil {
+			return fmt.Errorf("CreateHoliday: %w", err)
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("CreateHoliday (retrieve id): %w", err)
+		}
+		h.ID = int(id)
+		return r.audit("holiday.create", "holiday", h.ID, nil, holidaySnapshot(h))
+	})
+}
+
+// GetHolidays retrieves the holidays overlapping the period from..to (inclusive), in date order.
+func (r *Repository) GetHolidays(from, to time.Time) ([]types.Holiday, error) {
+	query := `
+		SELECT id, name, start_date, end_date
+		FROM holidays
+		WHERE end_date >= ? AND start_date <= ?
+		ORDER BY start_date
+	`
+	rows, err := r.db.Query(query, from.Format("2006-01-02"), to.Format("2006-01-02"))
+	if err != nil {
+		return nil, fmt.Errorf("GetHolidays: %w", err)
+	}
+	defer rows.Close()
+
+	var holidays []types.Holiday
+	for rows.Next() {
+		var h types.Holiday
+		var startStr, endStr string
+		if err := rows.Scan(&h.ID, &h.Name, &startStr, &endStr); err != nil {
+			return nil, fmt.Errorf("GetHolidays scan: %w", err)
+		}
+		if h.StartDate, err = time.Parse("2006-01-02", startStr); err != nil {
+			return nil, fmt.Errorf("GetHolidays: parsing start_date: %w", err)
+		}
+		if h.EndDate, err = time.Parse("2006-01-02", endStr); err != nil {
+			return nil, fmt.Errorf("GetHolidays: parsing end_date: %w", err)
+		}
+		holidays = append(holidays, h)
+	}
+	return holidays, nil
+}
+
+// DeleteHoliday removes a holiday.
+func (r *Repository) DeleteHoliday(id int) error {
+	return r.transaction(func(r *Repository) error {
+		before := &types.Holiday{ID: id}
+		var startStr, endStr string
+		err := r.db.QueryRow(`SELECT name, start_date, end_date FROM holidays WHERE id = ?`, id).Scan(&before.Name, &startStr, &endStr)
+		if err != nil {
+			return fmt.Errorf("DeleteHoliday: %w", err)
+		}
+		before.StartDate, _ = time.Parse("2006-01-02", startStr)
+		before.EndDate, _ = time.Parse("2006-01-02", endStr)
+
+		if _, err := r.db.Exec(`DELETE FROM holidays WHERE id = ?`, id); err != nil {
+			return fmt.Errorf("DeleteHoliday: %w", err)
+		}
+		return r.audit("holiday.delete", "holiday", id, holidaySnapshot(before), nil)
+	})
+}
+
+// holidaySnapshot is the audited form of a holiday, with dates at DATE precision.
+func holidaySnapshot(h *types.Holiday) map[string]interface{} {
+	return map[string]interface{}{
+		"name":       h.Name,
+		"start_date": h.StartDate.Format("2006-01-02"),
+		"end_date":   h.EndDate.Format("2006-01-02"),
+	}
+}
@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"Syllybea/metrics"
+	"Syllybea/tracing"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// =============================
+//     QUERY INSTRUMENTATION
+// =============================
+
+// WithContext returns a copy of the repository whose queries are traced as
+// part of ctx, typically the context of the request being served. Like
+// WithActor, the copy shares the DB connection.
+func (r *Repository) WithContext(ctx context.Context) *Repository {
+	scoped := *r
+	scoped.db.ctx = ctx
+	return &scoped
+}
+
+// instrumentedDB runs queries with the context of its repository, recording
+// the latency of each under the name of the repository method that made it
+// and tracing it in a span of its own. Inside a transaction the queries run
+// in tx.
+type instrumentedDB struct {
+	*sql.DB
+	ctx context.Context
+	tx  *sql.Tx
+}
+
+// conn is what queries run in: the transaction, if any, or the pool.
+func (d instrumentedDB) conn() interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+} {
+	if d.tx != nil {
+		return d.tx
+	}
+	return d.DB
+}
+
+func (d instrumentedDB) Exec(query string, args ...interface{}) (sql.Result, error) {
+	ctx, done := d.start(query)
+	res, err := d.conn().ExecContext(ctx, query, args...)
+	done(err)
+	return res, err
+}
+
+// Query is timed until the rows are ready to be read; reading them is not
+// included.
+func (d instrumentedDB) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, done := d.start(query)
+	rows, err := d.conn().QueryContext(ctx, query, args...)
+	done(err)
+	return rows, err
+}
+
+func (d instrumentedDB) QueryRow(query string, args ...interface{}) *sql.Row {
+	ctx, done := d.start(query)
+	row := d.conn().QueryRowContext(ctx, query, args...)
+	done(row.Err())
+	return row
+}
+
+// start opens the span of a query and returns the function that ends it and
+// records its latency. It must be called directly by Exec, Query or
+// QueryRow, as the operation is named after their caller.
+func (d instrumentedDB) start(query string) (context.Context, func(error)) {
+	operation := caller(3)
+	ctx, span := tracing.Tracer().Start(d.ctx, "repository."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemNameMySQL, semconv.DBOperationName(operation), semconv.DBQueryText(query)),
+	)
+	start := time.Now()
+	return ctx, func(err error) {
+		metrics.ObserveQuery(operation, time.Since(start), err)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}
+}
+
+// caller returns the name of the function skip frames up the stack, without
+// its package and receiver, e.g. "GetUserByID". A query made in a function
+// literal, such as the body of a transaction, is named after the method the
+// literal is in.
+func caller(skip int) string {
+	pc, _, _, ok := runtime.Caller(skip)
+	if !ok {
+		return "unknown"
+	}
+	parts := strings.Split(runtime.FuncForPC(pc).Name(), ".")
+	for len(parts) > 1 && closureName(parts[len(parts)-1]) {
+		parts = parts[:len(parts)-1]
+	}
+	return parts[len(parts)-1]
+}
+
+// closureName reports whether part of a function name is one the compiler
+// gives a function literal, such as "func1" or the "2" of "func1.2".
+func closureName(part string) bool {
+	digits := strings.TrimPrefix(part, "func")
+	return digits != "" && strings.Trim(digits, "0123456789") == ""
+}
+
+// transaction runs fn with a copy of the repository whose queries run in a
+// single transaction, committed when fn returns nil and rolled back
+// otherwise. Mutations run in one with their audit entries, so neither is
+// kept without the other. Within a transaction, fn joins it.
+func (r *Repository) transaction(fn func(r *Repository) error) error {
+	if r.db.tx != nil {
+		return fn(r)
+	}
+	tx, err := r.db.BeginTx(r.db.ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	scoped := *r
+	scoped.db.tx = tx
+	if err := fn(&scoped); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
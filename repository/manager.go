@@ -0,0 +1,160 @@
+package repository
+
+import (
+	"Syllybea/UIcomponents"
+	"Syllybea/status"
+	"fmt"
+	"time"
+)
+
+// =============================
+//   MANAGER (ALL SYLLABI) VIEW
+// =============================
+
+// SyllabusFilter narrows the manager view of all syllabi. Zero values mean "any".
+type SyllabusFilter struct {
+	SyllabusID   int
+	DepartmentID int
+	LecturerID   int
+	Year         string // Draft year of study
+	TermID       int    // Academic term of the syllabus' offering
+	Statuses     []status.Status
+	Search       string // Matches course, department or lecturer name
+}
+
+// FilterAllCards returns the non-deleted syllabi of every lecturer that match the filter.
+func (r *Repository) FilterAllCards(f SyllabusFilter) ([]UIcomponents.Card, error) {
+	listed, params := statusList(status.Listed)
+	query := `
+		SELECT s.id, s.status, s.submission_date, c.name AS courseName, d.name AS departmentName, u.name AS lecturerName, u.id
+		FROM syllabi s
+		JOIN courses c ON s.course_id = c.id
+		JOIN departments d ON c.department_id = d.id
+		JOIN users u ON s.lecturer_id = u.id
+		WHERE s.status IN ` + listed + `
+	`
+
+	if f.SyllabusID != 0 {
+		query += " AND s.id = ?"
+		params = append(params, f.SyllabusID)
+	}
+	if f.DepartmentID != 0 {
+		query += " AND d.id = ?"
+		params = append(params, f.DepartmentID)
+	}
+	if f.LecturerID != 0 {
+		query += " AND u.id = ?"
+		params = append(params, f.LecturerID)
+	}
+	if f.Year != "" {
+		query += " AND JSON_UNQUOTE(JSON_EXTRACT(s.data, '$.year')) = ?"
+		params = append(params, f.Year)
+	}
+	if f.TermID != 0 {
+		query += " AND s.offering_id IN (SELECT id FROM course_offerings WHERE term_id = ?)"
+		params = append(params, f.TermID)
+	}
+	if len(f.Statuses) > 0 {
+		chosen, args := statusList(f.Statuses)
+		query += " AND s.status IN " + chosen
+		params = append(params, args...)
+	}
+	if f.Search != "" {
+		query += " AND (c.name LIKE ? OR d.name LIKE ? OR u.name LIKE ?)"
+		searchParam := "%" + f.Search + "%"
+		params = append(params, searchParam, searchParam, searchParam)
+	}
+	query += " ORDER BY s.submission_date DESC"
+
+	rows, err := r.db.Query(query, params...)
+	if err != nil {
+		return nil, fmt.Errorf("FilterAllCards: %w", err)
+	}
+	defer rows.Close()
+
+	var cards []UIcomponents.Card
+	for rows.Next() {
+		var card UIcomponents.Card
+		var submissionDateStr string
+		if err := rows.Scan(&card.ID, &card.Status, &submissionDateStr, &card.Title, &card.Field, &card.Lecturer, &card.LecturerID); err != nil {
+			return nil, fmt.Errorf("FilterAllCards scan: %w", err)
+		}
+		submissionDate, err := time.Parse("2006-01-02", submissionDateStr)
+		if err != nil {
+			return nil, fmt.Errorf("parsing date: %w", err)
+		}
+		card.Date = submissionDate.Format("02/01/2006")
+		cards = append(cards, card)
+	}
+	return cards, nil
+}
+
+// CountSyllabiByDepartment aggregates the non-deleted syllabi per department and status.
+func (r *Repository) CountSyllabiByDepartment() ([]UIcomponents.DepartmentStats, error) {
+	listed, args := statusList(status.Listed)
+	query := `
+		SELECT d.id, d.name, s.status, COUNT(s.id)
+		FROM departments d
+		LEFT JOIN courses c ON c.department_id = d.id
+		LEFT JOIN syllabi s ON s.course_id = c.id AND s.status IN ` + listed + `
+		GROUP BY d.id, d.name, s.status
+		ORDER BY d.name
+	`
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("CountSyllabiByDepartment: %w", err)
+	}
+	defer rows.Close()
+
+	var stats []UIcomponents.DepartmentStats
+	index := map[int]int{}
+	for rows.Next() {
+		var deptID, count int
+		var deptName string
+		var st status.Status
+		if err := rows.Scan(&deptID, &deptName, &st, &count); err != nil {
+			return nil, fmt.Errorf("CountSyllabiByDepartment scan: %w", err)
+		}
+		i, ok := index[deptID]
+		if !ok {
+			stats = append(stats, UIcomponents.DepartmentStats{DepartmentID: deptID, Department: deptName})
+			i = len(stats) - 1
+			index[deptID] = i
+		}
+		if st == "" {
+			// Department without syllabi.
+			continue
+		}
+		stats[i].Total += count
+		switch st {
+		case status.Draft:
+			stats[i].Drafts += count
+		case status.InReview:
+			stats[i].InReview += count
+		case status.Approved:
+			stats[i].Approved += count
+		}
+	}
+	return stats, nil
+}
+
+// CountSyllabiByStatus counts the syllabi in each status, including unsaved
+// and deleted ones.
+func (r *Repository) CountSyllabiByStatus() (map[status.Status]int, error) {
+	rows, err := r.db.Query(`SELECT status, COUNT(*) FROM syllabi GROUP BY status`)
+	if err != nil {
+		return nil, fmt.Errorf("CountSyllabiByStatus: %w", err)
+	}
+	defer rows.Close()
+
+	counts := map[status.Status]int{}
+	for rows.Next() {
+		var st status.Status
+		var count int
+		if err := rows.Scan(&st, &count); err != nil {
+			return nil, fmt.Errorf("CountSyllabiByStatus scan: %w", err)
+		}
+		counts[st] = count
+	}
+	return counts, rows.Err()
+}
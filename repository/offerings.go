@@ -0,0 +1,339 @@
+package repository
+
+import (
+	"Syllybea/UIcomponents"
+	"Syllybea/status"
+	"Syllybea/types"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// =============================
+//      COURSE OFFERINGS
+// =============================
+
+// EnsureOffering returns the offering of a course in a term, creating it when
+// it does not exist yet, and adds the lecturer (if any) to its lecturers.
+func (r *Repository) EnsureOffering(courseID, termID int, section string, lecturerID int) (int, error) {
+	var id int
+	err := r.transaction(func(r *Repository) error {
+		query := `
+			INSERT INTO course_offerings (course_id, term_id, section) VALUES (?, ?, ?)
+			ON DUPLICATE KEY UPDATE id = LAST_INSERT_ID(id)
+		`
+		result, err := r.db.Exec(query, courseID, termID, section)
+		if err != nil {
+			return fmt.Errorf("EnsureOffering: %w", err)
+		}
+		id64, err := result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("EnsureOffering (retrieve id): %w", err)
+		}
+		id = int(id64)
+
+		// One affected row means the offering was inserted rather than found.
+		if n, _ := result.RowsAffected(); n == 1 {
+			after := map[string]interface{}{"course_id": courseID, "term_id": termID, "section": section}
+			if err := r.audit("offering.create", "offering", id, nil, after); err != nil {
+				return err
+			}
+		}
+
+		if lecturerID > 0 {
+			if err := r.AddOfferingLecturer(id, lecturerID); err != nil {
+				return fmt.Errorf("EnsureOffering: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// AddOfferingLecturer adds a lecturer to an offering; adding an existing lecturer is a no-op.
+func (r *Repository) AddOfferingLecturer(offeringID, userID int) error {
+	return r.transaction(func(r *Repository) error {
+		result, err := r.db.Exec(`INSERT IGNORE INTO course_offering_lecturers (offering_id, user_id) VALUES (?, ?)`, offeringID, userID)
+		if err != nil {
+			return fmt.Errorf("AddOfferingLecturer: %w", err)
+		}
+		if n, _ := result.RowsAffected(); n == 1 {
+			return r.audit("offering.add_lecturer", "offering", offeringID, nil, map[string]interface{}{"lecturer_id": userID})
+		}
+		return nil
+	})
+}
+
+// OfferingForDraft resolves the offering a syllabus belongs to from its course and
+// the term and section chosen in the draft. It returns 0 while the course or term is not chosen.
+func (r *Repository) OfferingForDraft(courseID, lecturerID int, draft *UIcomponents.Draft) (int, error) {
+	if courseID == 0 || draft.TermID == 0 {
+		return 0, nil
+	}
+	return r.EnsureOffering(courseID, draft.TermID, draft.Section, lecturerID)
+}
+
+// draftOffering looks up the course selected in a draft being edited and its
+// offering in the chosen term. Offerings are only created when the syllabus is
+// saved, so the offering is 0 while the term is not chosen or the offering does
+// not exist yet; the course is 0 when no catalog course has the selected name.
+func (r *Repository) draftOffering(draft *UIcomponents.Draft) (courseID, offeringID int, err error) {
+	err = r.db.QueryRow(`SELECT id FROM courses WHERE name = ? ORDER BY id LIMIT 1`, draft.SelectedCourse).Scan(&courseID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, fmt.Errorf("draftOffering (course): %w", err)
+	}
+	if draft.TermID == 0 {
+		return courseID, 0, nil
+	}
+	query := `SELECT id FROM course_offerings WHERE course_id = ? AND term_id = ? AND section = ?`
+	err = r.db.QueryRow(query, courseID, draft.TermID, draft.Section).Scan(&offeringID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return courseID, 0, nil
+	}
+	if err != nil {
+		return 0, 0, fmt.Errorf("draftOffering: %w", err)
+	}
+	return courseID, offeringID, nil
+}
+
+// draftTerm sets the term of a loaded draft from its offering, or from the term
+// kept with the draft while it has no offering.
+func (r *Repository) draftTerm(draft *UIcomponents.Draft, offeringID int) error {
+	termID, err := r.OfferingTerm(offeringID)
+	if err != nil {
+		return err
+	}
+	draft.TermID = termID
+	if draft.TermID == 0 {
+		draft.TermID = draft.UnsavedTermID
+	}
+	return nil
+}
+
+// OfferingTerm returns the term of an offering, 0 for no offering.
+func (r *Repository) OfferingTerm(offeringID int) (int, error) {
+	if offeringID == 0 {
+		return 0, nil
+	}
+	var termID int
+	if err := r.db.QueryRow(`SELECT term_id FROM course_offerings WHERE id = ?`, offeringID).Scan(&termID); err != nil {
+		return 0, fmt.Errorf("OfferingTerm: %w", err)
+	}
+	return termID, nil
+}
+
+// BackfillOfferings assigns an offering to syllabi saved before offerings existed,
+// from the legacy termID MigrateDraftTerms left in their data, and removes the
+// legacy termID from the data of every syllabus with an offering.
+func (r *Repository) BackfillOfferings() (int, error) {
+	listed, args := statusList(status.Listed)
+	query := `
+		SELECT id, course_id, lecturer_id, data
+		FROM syllabi
+		WHERE offering_id IS NULL AND status IN ` + listed + `
+	`
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("BackfillOfferings: %w", err)
+	}
+
+	type pending struct {
+		id, courseID, lecturerID int
+		draft                    UIcomponents.Draft
+		legacy                   struct {
+			TermID int `json:"termID"`
+		}
+	}
+	var todo []pending
+	for rows.Next() {
+		var p pending
+		var data []byte
+		if err := rows.Scan(&p.id, &p.courseID, &p.lecturerID, &data); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("BackfillOfferings scan: %w", err)
+		}
+		if json.Unmarshal(data, &p.draft) != nil || json.Unmarshal(data, &p.legacy) != nil {
+			continue
+		}
+		todo = append(todo, p)
+	}
+	rows.Close()
+
+	assigned := 0
+	for _, p := range todo {
+		if p.courseID == 0 || p.legacy.TermID == 0 {
+			continue
+		}
+		offeringID, err := r.EnsureOffering(p.courseID, p.legacy.TermID, p.draft.Section, p.lecturerID)
+		if err != nil {
+			return assigned, fmt.Errorf("BackfillOfferings: %w", err)
+		}
+		if _, err := r.db.Exec(`UPDATE syllabi SET offering_id = ? WHERE id = ?`, offeringID, p.id); err != nil {
+			return assigned, fmt.Errorf("BackfillOfferings (update %d): %w", p.id, err)
+		}
+		assigned++
+	}
+
+	// The offering is where the term is kept; a copy left in the data could disagree with it.
+	query = `
+		UPDATE syllabi SET data = JSON_REMOVE(data, '$.termID'), updated_at = updated_at
+		WHERE offering_id IS NOT NULL AND JSON_CONTAINS_PATH(data, 'one', '$.termID')
+	`
+	if _, err := r.db.Exec(query); err != nil {
+		return assigned, fmt.Errorf("BackfillOfferings (remove termID): %w", err)
+	}
+	return assigned, nil
+}
+
+// GetAllOfferings lists the offerings of every course, without their lecturers.
+func (r *Repository) GetAllOfferings() ([]types.CourseOffering, error) {
+	rows, err := r.db.Query(`SELECT id, course_id, term_id, section, created_at FROM course_offerings ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("GetAllOfferings: %w", err)
+	}
+	defer rows.Close()
+
+	var offerings []types.CourseOffering
+	for rows.Next() {
+		var o types.CourseOffering
+		var createdAtStr string
+		if err := rows.Scan(&o.ID, &o.CourseID, &o.TermID, &o.Section, &createdAtStr); err != nil {
+			return nil, fmt.Errorf("GetAllOfferings scan: %w", err)
+		}
+		if o.CreatedAt, err = time.Parse("2006-01-02 15:04:05", createdAtStr); err != nil {
+			return nil, fmt.Errorf("GetAllOfferings (parse created_at): %w", err)
+		}
+		offerings = append(offerings, o)
+	}
+	return offerings, nil
+}
+
+// GetCourseOfferings lists the offerings of a course, newest term first, with
+// their lecturers and syllabi. Syllabi without an offering come last.
+func (r *Repository) GetCourseOfferings(courseID int) ([]UIcomponents.OfferingView, error) {
+	rows, err := r.db.Query(`
+		SELECT o.id, t.hebrew_label, o.section
+		FROM course_offerings o
+		JOIN terms t ON o.term_id = t.id
+		WHERE o.course_id = ?
+		ORDER BY t.start_date DESC, o.section
+	`, courseID)
+	if err != nil {
+		return nil, fmt.Errorf("GetCourseOfferings: %w", err)
+	}
+	var offerings []UIcomponents.OfferingView
+	index := map[int]int{}
+	for rows.Next() {
+		var o UIcomponents.OfferingView
+		if err := rows.Scan(&o.ID, &o.Term, &o.Section); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("GetCourseOfferings scan: %w", err)
+		}
+		index[o.ID] = len(offerings)
+		offerings = append(offerings, o)
+	}
+	rows.Close()
+
+	lecturers, err := r.db.Query(`
+		SELECT l.offering_id, u.name
+		FROM course_offering_lecturers l
+		JOIN course_offerings o ON l.offering_id = o.id
+		JOIN users u ON l.user_id = u.id
+		WHERE o.course_id = ?
+		ORDER BY u.name
+	`, courseID)
+	if err != nil {
+		return nil, fmt.Errorf("GetCourseOfferings (lecturers): %w", err)
+	}
+	for lecturers.Next() {
+		var offeringID int
+		var name string
+		if err := lecturers.Scan(&offeringID, &name); err != nil {
+			lecturers.Close()
+			return nil, fmt.Errorf("GetCourseOfferings lecturers scan: %w", err)
+		}
+		if i, ok := index[offeringID]; ok {
+			offerings[i].Lecturers = append(offerings[i].Lecturers, name)
+		}
+	}
+	lecturers.Close()
+
+	listed, args := statusList(status.Listed)
+	syllabi, err := r.db.Query(`
+		SELECT s.id, s.offering_id, s.status, u.name, s.updated_at
+		FROM syllabi s
+		JOIN users u ON s.lecturer_id = u.id
+		WHERE s.course_id = ? AND s.status IN `+listed+`
+		ORDER BY s.updated_at DESC
+	`, append([]interface{}{courseID}, args...)...)
+	if err != nil {
+		return nil, fmt.Errorf("GetCourseOfferings (syllabi): %w", err)
+	}
+	defer syllabi.Close()
+
+	var unassigned []UIcomponents.OfferingSyllabus
+	for syllabi.Next() {
+		var s UIcomponents.OfferingSyllabus
+		var offeringID sql.NullInt64
+		var updatedAtStr string
+		if err := syllabi.Scan(&s.ID, &offeringID, &s.Status, &s.Lecturer, &updatedAtStr); err != nil {
+			return nil, fmt.Errorf("GetCourseOfferings syllabi scan: %w", err)
+		}
+		updatedAt, err := time.Parse("2006-01-02 15:04:05", updatedAtStr)
+		if err != nil {
+			return nil, fmt.Errorf("GetCourseOfferings: parsing updated_at: %w", err)
+		}
+		s.Date = updatedAt.Format("02/01/2006")
+
+		if i, ok := index[int(offeringID.Int64)]; ok {
+			offerings[i].Syllabi = append(offerings[i].Syllabi, s)
+		} else {
+			unassigned = append(unassigned, s)
+		}
+	}
+	if len(unassigned) > 0 {
+		offerings = append(offerings, UIcomponents.OfferingView{Syllabi: unassigned})
+	}
+	return offerings, nil
+}
+
+// GetCourseCatalog lists all courses with their offerings and syllabi in the given
+// term, flagging courses that are offered in the term but have no syllabus yet.
+func (r *Repository) GetCourseCatalog(termID int) ([]UIcomponents.CourseRow, error) {
+	listed, args := statusList(status.Listed)
+	query := `
+		SELECT c.id, c.name, d.name,
+		       COUNT(DISTINCT o.id) AS termOfferings,
+		       COUNT(DISTINCT s.id) AS termSyllabi
+		FROM courses c
+		JOIN departments d ON c.department_id = d.id
+		LEFT JOIN course_offerings o ON o.course_id = c.id AND o.term_id = ?
+		LEFT JOIN syllabi s ON s.offering_id = o.id AND s.status IN ` + listed + `
+		GROUP BY c.id, c.name, d.name
+		ORDER BY d.name, c.name
+	`
+	rows, err := r.db.Query(query, append([]interface{}{termID}, args...)...)
+	if err != nil {
+		return nil, fmt.Errorf("GetCourseCatalog: %w", err)
+	}
+	defer rows.Close()
+
+	var courses []UIcomponents.CourseRow
+	for rows.Next() {
+		var c UIcomponents.CourseRow
+		if err := rows.Scan(&c.ID, &c.Name, &c.Department, &c.TermOfferings, &c.TermSyllabi); err != nil {
+			return nil, fmt.Errorf("GetCourseCatalog scan: %w", err)
+		}
+		c.Missing = c.TermOfferings > 0 && c.TermSyllabi == 0
+		courses = append(courses, c)
+	}
+	return courses, nil
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"Syllybea/types"
+	"fmt"
+)
+
+// =============================
+//      PROGRAM OUTCOMES
+// =============================
+
+// CreateProgramOutcome inserts a new program outcome.
+func (r *Repository) CreateProgramOutcome(o *types.ProgramOutcome) error {
+	return r.transaction(func(r *Repository) error {
+		query := `INSERT INTO program_outcomes (department_id, code, description) VALUES (?, ?, ?)`
+		result, err := r.db.Exec(query, o.DepartmentID, o.Code, o.Description)
+		if err != nil {
+			return fmt.Errorf("CreateProgramOutcome: %w", err)
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("CreateProgramOutcome (retrieve id): %w", err)
+		}
+		o.ID = int(id)
+		return r.audit("program_outcome.create", "program_outcome", o.ID, nil, o)
+	})
+}
+
+// GetProgramOutcomeByID retrieves a program outcome by ID together with its department name.
+func (r *Repository) GetProgramOutcomeByID(id int) (*types.ProgramOutcome, error) {
+	query := `
+		SELECT o.id, o.department_id, d.name, o.code, o.description
+		FROM program_outcomes o
+		JOIN departments d ON o.department_id = d.id
+		WHERE o.id = ?
+	`
+	var o types.ProgramOutcome
+	if err := r.db.QueryRow(query, id).Scan(&o.ID, &o.DepartmentID, &o.DepartmentName, &o.Code, &o.Description); err != nil {
+		return nil, fmt.Errorf("GetProgramOutcomeByID: %w", err)
+	}
+	return &o, nil
+}
+
+// GetProgramOutcomes retrieves the program outcomes of a department, or of all
+// departments when departmentID is 0, ordered by department and code.
+func (r *Repository) GetProgramOutcomes(departmentID int) ([]types.ProgramOutcome, error) {
+	query := `
+		SELECT o.id, o.department_id, d.name, o.code, o.description
+		FROM program_outcomes o
+		JOIN departments d ON o.department_id = d.id
+		WHERE ? = 0 OR o.department_id = ?
+		ORDER BY d.name, o.code
+	`
+	rows, err := r.db.Query(query, departmentID, departmentID)
+	if err != nil {
+		return nil, fmt.Errorf("GetProgramOutcomes: %w", err)
+	}
+	defer rows.Close()
+
+	var outcomes []types.ProgramOutcome
+	for rows.Next() {
+		var o types.ProgramOutcome
+		if err := rows.Scan(&o.ID, &o.DepartmentID, &o.DepartmentName, &o.Code, &o.Description); err != nil {
+			return nil, fmt.Errorf("GetProgramOutcomes scan: %w", err)
+		}
+		outcomes = append(outcomes, o)
+	}
+	return outcomes, nil
+}
+
+// UpdateProgramOutcome updates the code and description of a program outcome.
+func (r *Repository) UpdateProgramOutcome(o *types.ProgramOutcome) error {
+	return r.transaction(func(r *Repository) error {
+		before, err := r.GetProgramOutcomeByID(o.ID)
+		if err != nil {
+			return fmt.Errorf("UpdateProgramOutcome: %w", err)
+		}
+		query := `UPDATE program_outcomes SET code = ?, description = ? WHERE id = ?`
+		if _, err := r.db.Exec(query, o.Code, o.Description, o.ID); err != nil {
+			return fmt.Errorf("UpdateProgramOutcome: %w", err)
+		}
+		o.DepartmentID, o.DepartmentName = before.DepartmentID, before.DepartmentName
+		return r.audit("program_outcome.update", "program_outcome", o.ID, before, o)
+	})
+}
+
+// DeleteProgramOutcome removes a program outcome. Syllabi that mapped their
+// outcomes to it keep the ID, which no longer matches any program outcome.
+func (r *Repository) DeleteProgramOutcome(id int) error {
+	return r.transaction(func(r *Repository) error {
+		before, err := r.GetProgramOutcomeByID(id)
+		if err != nil {
+			return fmt.Errorf("DeleteProgramOutcome: %w", err)
+		}
+		if _, err := r.db.Exec(`DELETE FROM program_outcomes WHERE id = ?`, id); err != nil {
+			return fmt.Errorf("DeleteProgramOutcome: %w", err)
+		}
+		return r.audit("program_outcome.delete", "program_outcome", id, before, nil)
+	})
+}
@@ -0,0 +1,223 @@
+package repository
+
+import (
+	"Syllybea/UIcomponents"
+	"Syllybea/prerequisites"
+	"Syllybea/status"
+	"Syllybea/types"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// =============================
+//      COURSE PREREQUISITES
+// =============================
+
+// coursePrerequisite is the audited form of a prerequisite of a course.
+type coursePrerequisite struct {
+	PrerequisiteID   int    `json:"prerequisite_id"`
+	PrerequisiteName string `json:"prerequisite_name"`
+}
+
+// GetPrerequisiteGraph retrieves the prerequisites of all courses.
+func (r *Repository) GetPrerequisiteGraph() (prerequisites.Graph, error) {
+	return r.queryGraph("GetPrerequisiteGraph", `SELECT course_id, prerequisite_id FROM course_prerequisites`)
+}
+
+// lockPrerequisitesBelow retrieves the prerequisites of a course and, in
+// turn, of each of them: the part of the graph a cycle through the course
+// would run through. They are read in a locking read, so until the
+// transaction ends no other can add a prerequisite to any of those courses
+// and the part stays free of cycles while it is checked and changed.
+func (r *Repository) lockPrerequisitesBelow(courseID int) (prerequisites.Graph, error) {
+	g := prerequisites.Graph{}
+	seen := map[int]bool{courseID: true}
+	for level := []int{courseID}; len(level) > 0; {
+		placeholders := make([]string, len(level))
+		args := make([]interface{}, len(level))
+		for i, id := range level {
+			placeholders[i] = "?"
+			args[i] = id
+		}
+		query := `SELECT course_id, prerequisite_id FROM course_prerequisites WHERE course_id IN (` + strings.Join(placeholders, ", ") + `) FOR UPDATE`
+		below, err := r.queryGraph("lockPrerequisitesBelow", query, args...)
+		if err != nil {
+			return nil, err
+		}
+		level = nil
+		for course, prereqs := range below {
+			for _, p := range prereqs {
+				g.Add(course, p)
+				if !seen[p] {
+					seen[p] = true
+					level = append(level, p)
+				}
+			}
+		}
+	}
+	return g, nil
+}
+
+func (r *Repository) queryGraph(name, query string, args ...interface{}) (prerequisites.Graph, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	defer rows.Close()
+
+	var edges []prerequisites.Edge
+	for rows.Next() {
+		var e prerequisites.Edge
+		if err := rows.Scan(&e.Course, &e.Prerequisite); err != nil {
+			return nil, fmt.Errorf("%s scan: %w", name, err)
+		}
+		edges = append(edges, e)
+	}
+	return prerequisites.NewGraph(edges), nil
+}
+
+// GetCoursePrerequisites retrieves the prerequisites of a course, ordered by name.
+func (r *Repository) GetCoursePrerequisites(courseID int) ([]types.Course, error) {
+	query := `
+		SELECT c.id, c.name, c.department_id
+		FROM course_prerequisites p
+		JOIN courses c ON p.prerequisite_id = c.id
+		WHERE p.course_id = ?
+		ORDER BY c.name
+	`
+	return r.queryCourses("GetCoursePrerequisites", query, courseID)
+}
+
+// GetDependentCourses retrieves the courses a course is a prerequisite of, ordered by name.
+func (r *Repository) GetDependentCourses(courseID int) ([]types.Course, error) {
+	query := `
+		SELECT c.id, c.name, c.department_id
+		FROM course_prerequisites p
+		JOIN courses c ON p.course_id = c.id
+		WHERE p.prerequisite_id = ?
+		ORDER BY c.name
+	`
+	return r.queryCourses("GetDependentCourses", query, courseID)
+}
+
+func (r *Repository) queryCourses(name, query string, args ...interface{}) ([]types.Course, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	defer rows.Close()
+
+	var courses []types.Course
+	for rows.Next() {
+		var c types.Course
+		if err := rows.Scan(&c.ID, &c.Name, &c.DepartmentID); err != nil {
+			return nil, fmt.Errorf("%s scan: %w", name, err)
+		}
+		courses = append(courses, c)
+	}
+	return courses, nil
+}
+
+// AddCoursePrerequisite makes a course a prerequisite of another. It fails
+// with prerequisites.ErrCycle when the course would end up, directly or
+// through other courses, a prerequisite of itself. The check and the insert
+// are made in one transaction, so two additions made at once cannot close a
+// cycle between them.
+func (r *Repository) AddCoursePrerequisite(courseID, prerequisiteID int) error {
+	return r.transaction(func(r *Repository) error {
+		if courseID == prerequisiteID {
+			return fmt.Errorf("AddCoursePrerequisite: %w", prerequisites.ErrCycle)
+		}
+		// A cycle would run from the new prerequisite down to the course.
+		g, err := r.lockPrerequisitesBelow(prerequisiteID)
+		if err != nil {
+			return fmt.Errorf("AddCoursePrerequisite: %w", err)
+		}
+		if err := g.Check(courseID, prerequisiteID); err != nil {
+			return fmt.Errorf("AddCoursePrerequisite: %w", err)
+		}
+		prerequisite, err := r.GetCourseByID(prerequisiteID)
+		if err != nil {
+			return fmt.Errorf("AddCoursePrerequisite: %w", err)
+		}
+
+		query := `INSERT IGNORE INTO course_prerequisites (course_id, prerequisite_id) VALUES (?, ?)`
+		result, err := r.db.Exec(query, courseID, prerequisiteID)
+		if err != nil {
+			return fmt.Errorf("AddCoursePrerequisite: %w", err)
+		}
+		if n, _ := result.RowsAffected(); n == 0 {
+			return nil
+		}
+		return r.audit("course.prerequisite_add", "course", courseID, nil, coursePrerequisite{prerequisite.ID, prerequisite.Name})
+	})
+}
+
+// RemoveCoursePrerequisite removes a prerequisite of a course.
+func (r *Repository) RemoveCoursePrerequisite(courseID, prerequisiteID int) error {
+	return r.transaction(func(r *Repository) error {
+		prerequisite, err := r.GetCourseByID(prerequisiteID)
+		if err != nil {
+			return fmt.Errorf("RemoveCoursePrerequisite: %w", err)
+		}
+		query := `DELETE FROM course_prerequisites WHERE course_id = ? AND prerequisite_id = ?`
+		result, err := r.db.Exec(query, courseID, prerequisiteID)
+		if err != nil {
+			return fmt.Errorf("RemoveCoursePrerequisite: %w", err)
+		}
+		if n, _ := result.RowsAffected(); n == 0 {
+			return nil
+		}
+		return r.audit("course.prerequisite_remove", "course", courseID, coursePrerequisite{prerequisite.ID, prerequisite.Name}, nil)
+	})
+}
+
+// approvePrerequisites adds the prerequisite courses listed by a syllabus, on
+// its approval, to the prerequisites of its course. Prerequisites it does not
+// list are kept; they are removed on the course page only. A course that would
+// close a cycle, or is no longer in the catalog, stays listed by the syllabus
+// only. A syllabus whose list was not started from its course is left out, as
+// its list is not about that course.
+func (r *Repository) approvePrerequisites(syllabusID int) error {
+	syl, err := r.GetSyllabusByID(syllabusID)
+	if err != nil {
+		return fmt.Errorf("approvePrerequisites: %w", err)
+	}
+	var draft UIcomponents.Draft
+	if err := json.Unmarshal(syl.Data, &draft); err != nil {
+		return fmt.Errorf("approvePrerequisites (unmarshal): %w", err)
+	}
+	if draft.PrerequisitesFor != syl.CourseID {
+		return nil
+	}
+
+	for _, p := range draft.PrerequisiteCourses {
+		err := r.AddCoursePrerequisite(syl.CourseID, p.ID)
+		if err != nil && !errors.Is(err, prerequisites.ErrCycle) && !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("approvePrerequisites: %w", err)
+		}
+	}
+	return nil
+}
+
+// GetApprovedCourseIDs retrieves the IDs of the courses that have an approved syllabus.
+func (r *Repository) GetApprovedCourseIDs() (map[int]bool, error) {
+	rows, err := r.db.Query(`SELECT DISTINCT course_id FROM syllabi WHERE status = ?`, status.Approved)
+	if err != nil {
+		return nil, fmt.Errorf("GetApprovedCourseIDs: %w", err)
+	}
+	defer rows.Close()
+
+	approved := map[int]bool{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("GetApprovedCourseIDs scan: %w", err)
+		}
+		approved[id] = true
+	}
+	return approved, nil
+}
@@ -0,0 +1,310 @@
+package repository
+
+import (
+	"Syllybea/UIcomponents"
+	"Syllybea/status"
+	"Syllybea/types"
+	"Syllybea/utils"
+	"Syllybea/workload"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// =============================
+//     PUBLISHED SYLLABI
+// =============================
+
+const publishedColumns = `
+	p.id, p.syllabus_id, p.course_id, p.term_id, p.section, p.slug, p.data, p.published_at,
+	c.name, d.name, COALESCE(t.hebrew_label, '')
+`
+
+const publishedFrom = `
+	FROM published_syllabi p
+	JOIN courses c ON c.id = p.course_id
+	JOIN departments d ON d.id = c.department_id
+	LEFT JOIN terms t ON t.id = p.term_id
+`
+
+// scanPublished reads a row selected with publishedColumns.
+func scanPublished(scan func(dest ...interface{}) error) (*types.PublishedSyllabus, error) {
+	p := &types.PublishedSyllabus{}
+	var publishedAtStr string
+	if err := scan(&p.ID, &p.SyllabusID, &p.CourseID, &p.TermID, &p.Section, &p.Slug, &p.Data, &publishedAtStr,
+		&p.CourseName, &p.Department, &p.TermLabel); err != nil {
+		return nil, err
+	}
+	var err error
+	if p.PublishedAt, err = time.Parse("2006-01-02 15:04:05", publishedAtStr); err != nil {
+		return nil, fmt.Errorf("parsing published_at: %w", err)
+	}
+	return p, nil
+}
+
+// PublishSyllabus publishes a frozen copy of a syllabus under the public URL of its
+// course, term and section, replacing what was published there before. The slug of
+// an existing publication is kept so its URL stays the same, and a new one never
+// takes the slug of another.
+func (r *Repository) PublishSyllabus(syllabusID int) error {
+	return r.transaction(func(r *Repository) error {
+		syl, err := r.GetSyllabusByID(syllabusID)
+		if err != nil {
+			return fmt.Errorf("PublishSyllabus: %w", err)
+		}
+		var draft UIcomponents.Draft
+		if err := json.Unmarshal(syl.Data, &draft); err != nil {
+			return fmt.Errorf("PublishSyllabus (unmarshal): %w", err)
+		}
+		data, err := r.publishedData(draft)
+		if err != nil {
+			return fmt.Errorf("PublishSyllabus: %w", err)
+		}
+
+		termID, err := r.OfferingTerm(syl.OfferingID)
+		if err != nil {
+			return fmt.Errorf("PublishSyllabus: %w", err)
+		}
+		var term *types.Term
+		if termID != 0 {
+			if term, err = r.GetTermByID(termID); err != nil {
+				return fmt.Errorf("PublishSyllabus: %w", err)
+			}
+		}
+
+		// A syllabus moved to another term or section is no longer published under the old one.
+		query := `DELETE FROM published_syllabi WHERE syllabus_id = ? AND NOT (course_id = ? AND term_id = ? AND section = ?)`
+		if _, err := r.db.Exec(query, syl.ID, syl.CourseID, termID, draft.Section); err != nil {
+			return fmt.Errorf("PublishSyllabus: %w", err)
+		}
+
+		var id int
+		var published string
+		query = `SELECT id, slug FROM published_syllabi WHERE course_id = ? AND term_id = ? AND section = ? FOR UPDATE`
+		err = r.db.QueryRow(query, syl.CourseID, termID, draft.Section).Scan(&id, &published)
+		switch {
+		case err == nil:
+			query = `UPDATE published_syllabi SET syllabus_id = ?, data = ?, published_at = CURRENT_TIMESTAMP WHERE id = ?`
+			if _, err := r.db.Exec(query, syl.ID, data, id); err != nil {
+				return fmt.Errorf("PublishSyllabus: %w", err)
+			}
+		case errors.Is(err, sql.ErrNoRows):
+			if published, err = r.freeSlug(publishedSlug(syl.CourseID, term, draft.Section)); err != nil {
+				return fmt.Errorf("PublishSyllabus: %w", err)
+			}
+			query = `INSERT INTO published_syllabi (syllabus_id, course_id, term_id, section, slug, data) VALUES (?, ?, ?, ?, ?, ?)`
+			if _, err := r.db.Exec(query, syl.ID, syl.CourseID, termID, draft.Section, published, data); err != nil {
+				return fmt.Errorf("PublishSyllabus: %w", err)
+			}
+		default:
+			return fmt.Errorf("PublishSyllabus: %w", err)
+		}
+		return r.audit("syllabus.publish", "syllabus", syl.ID, nil, map[string]interface{}{"slug": published})
+	})
+}
+
+// publishedData is the frozen copy of a draft that is published, with the
+// program outcomes and workload norm of its department.
+func (r *Repository) publishedData(draft UIcomponents.Draft) ([]byte, error) {
+	norm := workload.DefaultNorm
+	published := UIcomponents.PublishedDraft{Draft: draft, DepartmentNorm: &norm}
+	departments, err := r.GetAllDepartments()
+	if err != nil {
+		return nil, err
+	}
+	for _, dept := range departments {
+		if dept.Name != draft.SyllabusDepartment {
+			continue
+		}
+		outcomes, err := r.GetProgramOutcomes(dept.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, o := range outcomes {
+			published.DepartmentOutcomes = append(published.DepartmentOutcomes, UIcomponents.ProgramOutcomeOption{
+				ID: o.ID, Code: o.Code, Description: o.Description,
+			})
+		}
+		n, err := r.GetWorkloadNorm(dept.ID)
+		if err != nil {
+			return nil, err
+		}
+		published.DepartmentNorm = &workload.Norm{HoursPerCredit: n.HoursPerCredit, TolerancePercent: n.TolerancePercent}
+		break
+	}
+	return json.Marshal(published)
+}
+
+// UnpublishSyllabus takes a syllabus off the public pages. Unpublishing a
+// syllabus that is not published is a no-op.
+func (r *Repository) UnpublishSyllabus(syllabusID int) error {
+	return r.transaction(func(r *Repository) error {
+		var slug string
+		err := r.db.QueryRow(`SELECT slug FROM published_syllabi WHERE syllabus_id = ?`, syllabusID).Scan(&slug)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("UnpublishSyllabus: %w", err)
+		}
+		if _, err := r.db.Exec(`DELETE FROM published_syllabi WHERE syllabus_id = ?`, syllabusID); err != nil {
+			return fmt.Errorf("UnpublishSyllabus: %w", err)
+		}
+		return r.audit("syllabus.unpublish", "syllabus", syllabusID, map[string]interface{}{"slug": slug}, nil)
+	})
+}
+
+// GetPublishedBySlug retrieves a published syllabus by its public URL slug.
+func (r *Repository) GetPublishedBySlug(slug string) (*types.PublishedSyllabus, error) {
+	query := `SELECT ` + publishedColumns + publishedFrom + ` WHERE p.slug = ?`
+	p, err := scanPublished(r.db.QueryRow(query, slug).Scan)
+	if err != nil {
+		return nil, fmt.Errorf("GetPublishedBySlug: %w", err)
+	}
+	return p, nil
+}
+
+// GetPublishedBySyllabus retrieves the publication of a syllabus.
+func (r *Repository) GetPublishedBySyllabus(syllabusID int) (*types.PublishedSyllabus, error) {
+	query := `SELECT ` + publishedColumns + publishedFrom + ` WHERE p.syllabus_id = ? ORDER BY p.published_at DESC, p.id DESC LIMIT 1`
+	p, err := scanPublished(r.db.QueryRow(query, syllabusID).Scan)
+	if err != nil {
+		return nil, fmt.Errorf("GetPublishedBySyllabus: %w", err)
+	}
+	return p, nil
+}
+
+// GetPublishedSyllabi lists the published syllabi by department and course name,
+// limited to a term when termID is not 0.
+func (r *Repository) GetPublishedSyllabi(termID int) ([]types.PublishedSyllabus, error) {
+	query := `SELECT ` + publishedColumns + publishedFrom + ` WHERE ? = 0 OR p.term_id = ?
+		ORDER BY d.name, c.name, t.start_date DESC, p.section`
+	return r.queryPublished("GetPublishedSyllabi", query, termID, termID)
+}
+
+// GetRecentlyPublished lists the latest publications, newest first.
+func (r *Repository) GetRecentlyPublished(limit int) ([]types.PublishedSyllabus, error) {
+	query := `SELECT ` + publishedColumns + publishedFrom + ` ORDER BY p.published_at DESC, p.id DESC LIMIT ?`
+	return r.queryPublished("GetRecentlyPublished", query, limit)
+}
+
+func (r *Repository) queryPublished(name, query string, args ...interface{}) ([]types.PublishedSyllabus, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	defer rows.Close()
+
+	var published []types.PublishedSyllabus
+	for rows.Next() {
+		p, err := scanPublished(rows.Scan)
+		if err != nil {
+			return nil, fmt.Errorf("%s scan: %w", name, err)
+		}
+		published = append(published, *p)
+	}
+	return published, nil
+}
+
+// GetPublishedTermOptions lists the terms that have published syllabi, latest first.
+func (r *Repository) GetPublishedTermOptions() ([]UIcomponents.TermOption, error) {
+	query := `
+		SELECT t.id, t.hebrew_label
+		FROM terms t
+		WHERE EXISTS (SELECT 1 FROM published_syllabi p WHERE p.term_id = t.id)
+		ORDER BY t.start_date DESC
+	`
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("GetPublishedTermOptions: %w", err)
+	}
+	defer rows.Close()
+
+	var options []UIcomponents.TermOption
+	for rows.Next() {
+		var o UIcomponents.TermOption
+		if err := rows.Scan(&o.ID, &o.Label); err != nil {
+			return nil, fmt.Errorf("GetPublishedTermOptions scan: %w", err)
+		}
+		options = append(options, o)
+	}
+	return options, nil
+}
+
+// PublishApproved publishes the syllabi approved before publishing existed. It
+// only runs while nothing is published, so unpublished syllabi stay that way.
+func (r *Repository) PublishApproved() (int, error) {
+	var count int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM published_syllabi`).Scan(&count); err != nil {
+		return 0, fmt.Errorf("PublishApproved: %w", err)
+	}
+	if count > 0 {
+		return 0, nil
+	}
+
+	// Oldest first, so the latest approval of a course and term is the one left published.
+	rows, err := r.db.Query(`SELECT id FROM syllabi WHERE status = ? ORDER BY updated_at, id`, status.Approved)
+	if err != nil {
+		return 0, fmt.Errorf("PublishApproved: %w", err)
+	}
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("PublishApproved scan: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+
+	for i, id := range ids {
+		if err := r.PublishSyllabus(id); err != nil {
+			return i, fmt.Errorf("PublishApproved: %w", err)
+		}
+	}
+	return len(ids), nil
+}
+
+// publishedSlug builds the public URL slug of a course in a term, such as
+// "42-2025-26-a", or "42-2025-26-a-2" for section 2. Only the ASCII letters
+// and digits of the section are kept, so sections may share a slug; see
+// freeSlug.
+func publishedSlug(courseID int, term *types.Term, section string) string {
+	slug := strconv.Itoa(courseID)
+	if term != nil {
+		slug += "-" + utils.TermSlug(term.AcademicYear, term.Semester)
+	}
+	section = strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, section)
+	if section != "" {
+		slug += "-" + section
+	}
+	return slug
+}
+
+// freeSlug returns slug, or when another publication has it, slug with the
+// first number from 2 up that makes it unused, such as "42-2025-26-a~2".
+func (r *Repository) freeSlug(slug string) (string, error) {
+	candidate := slug
+	for n := 2; ; n++ {
+		var taken bool
+		err := r.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM published_syllabi WHERE slug = ?)`, candidate).Scan(&taken)
+		if err != nil {
+			return "", fmt.Errorf("freeSlug: %w", err)
+		}
+		if !taken {
+			return candidate, nil
+		}
+		candidate = slug + "~" + strconv.Itoa(n)
+	}
+}
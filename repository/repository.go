@@ -2,9 +2,14 @@ package repository
 
 import (
 	"Syllybea/UIcomponents"
+	"Syllybea/bibliography"
+	"Syllybea/grading"
+	"Syllybea/status"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -14,12 +19,20 @@ import (
 
 // Repository wraps the DB connection.
 type Repository struct {
-	DB *sql.DB
+	// db runs the queries, timed and traced.
+	db instrumentedDB
+	// actor is who mutations made through this repository are attributed to in the audit log.
+	actor Actor
 }
 
 // NewRepository creates a new Repository instance.
 func NewRepository(db *sql.DB) *Repository {
-	return &Repository{DB: db}
+	return &Repository{db: instrumentedDB{DB: db, ctx: context.Background()}}
+}
+
+// Ping reports whether the database answers.
+func (r *Repository) Ping(ctx context.Context) error {
+	return r.db.PingContext(ctx)
 }
 
 // =======================
@@ -28,24 +41,26 @@ func NewRepository(db *sql.DB) *Repository {
 
 // CreateUser inserts a new user into the DB.
 func (r *Repository) CreateUser(u *types.User) error {
-	query := `INSERT INTO users (name, email, role) VALUES (?, ?, ?)`
-	result, err := r.DB.Exec(query, u.Name, u.Email, u.Role)
-	if err != nil {
-		return fmt.Errorf("CreateUser: %w", err)
-	}
-	id, err := result.LastInsertId()
-	if err != nil {
-		return fmt.Errorf("CreateUser (retrieve id): %w", err)
-	}
-	u.ID = int(id)
-	return nil
+	return r.transaction(func(r *Repository) error {
+		query := `INSERT INTO users (name, email, role) VALUES (?, ?, ?)`
+		result, err := r.db.Exec(query, u.Name, u.Email, u.Role)
+		if err != nil {
+			return fmt.Errorf("CreateUser: %w", err)
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("CreateUser (retrieve id): %w", err)
+		}
+		u.ID = int(id)
+		return r.audit("user.create", "user", u.ID, nil, u)
+	})
 }
 
 func (r *Repository) GetUserByID(id int) (*types.User, error) {
 	query := `SELECT id, name, email, role, created_at FROM users WHERE id = ?`
 	u := &types.User{}
 	var createdAtStr string
-	if err := r.DB.QueryRow(query, id).Scan(&u.ID, &u.Name, &u.Email, &u.Role, &createdAtStr); err != nil {
+	if err := r.db.QueryRow(query, id).Scan(&u.ID, &u.Name, &u.Email, &u.Role, &createdAtStr); err != nil {
 		return nil, fmt.Errorf("GetUserByID: %w", err)
 	}
 	// Parse the created_at string into a time.Time.
@@ -62,7 +77,7 @@ func (r *Repository) GetUserByEmail(email string) (*types.User, error) {
 	query := `SELECT id, name, email, role, created_at FROM users WHERE email = ?`
 	user := &types.User{}
 	var createdAtStr string
-	if err := r.DB.QueryRow(query, email).Scan(&user.ID, &user.Name, &user.Email, &user.Role, &createdAtStr); err != nil {
+	if err := r.db.QueryRow(query, email).Scan(&user.ID, &user.Name, &user.Email, &user.Role, &createdAtStr); err != nil {
 		return nil, fmt.Errorf("GetUserByEmail: %w", err)
 	}
 
@@ -78,8 +93,8 @@ func (r *Repository) GetUserByEmail(email string) (*types.User, error) {
 
 // GetAllUsers retrieves all users.
 func (r *Repository) GetAllUsers() ([]types.User, error) {
-	query := `SELECT id, name, email, role, created_at FROM users`
-	rows, err := r.DB.Query(query)
+	query := `SELECT id, name, email, role, created_at FROM users ORDER BY name`
+	rows, err := r.db.Query(query)
 	if err != nil {
 		return nil, fmt.Errorf("GetAllUsers: %w", err)
 	}
@@ -88,9 +103,14 @@ func (r *Repository) GetAllUsers() ([]types.User, error) {
 	var users []types.User
 	for rows.Next() {
 		var u types.User
-		if err := rows.Scan(&u.ID, &u.Name, &u.Email, &u.Role, &u.CreatedAt); err != nil {
+		var createdAtStr string
+		if err := rows.Scan(&u.ID, &u.Name, &u.Email, &u.Role, &createdAtStr); err != nil {
 			return nil, fmt.Errorf("GetAllUsers scan: %w", err)
 		}
+		u.CreatedAt, err = time.Parse("2006-01-02 15:04:05", createdAtStr)
+		if err != nil {
+			return nil, fmt.Errorf("GetAllUsers: parsing created_at: %w", err)
+		}
 		users = append(users, u)
 	}
 	return users, nil
@@ -98,22 +118,34 @@ func (r *Repository) GetAllUsers() ([]types.User, error) {
 
 // UpdateUser updates an existing user.
 func (r *Repository) UpdateUser(u *types.User) error {
-	query := `UPDATE users SET name = ?, email = ?, role = ? WHERE id = ?`
-	_, err := r.DB.Exec(query, u.Name, u.Email, u.Role, u.ID)
-	if err != nil {
-		return fmt.Errorf("UpdateUser: %w", err)
-	}
-	return nil
+	return r.transaction(func(r *Repository) error {
+		before, err := r.GetUserByID(u.ID)
+		if err != nil {
+			return fmt.Errorf("UpdateUser: %w", err)
+		}
+		query := `UPDATE users SET name = ?, email = ?, role = ? WHERE id = ?`
+		_, err = r.db.Exec(query, u.Name, u.Email, u.Role, u.ID)
+		if err != nil {
+			return fmt.Errorf("UpdateUser: %w", err)
+		}
+		return r.audit("user.update", "user", u.ID, before, u)
+	})
 }
 
 // DeleteUser removes a user by ID.
 func (r *Repository) DeleteUser(id int) error {
-	query := `DELETE FROM users WHERE id = ?`
-	_, err := r.DB.Exec(query, id)
-	if err != nil {
-		return fmt.Errorf("DeleteUser: %w", err)
-	}
-	return nil
+	return r.transaction(func(r *Repository) error {
+		before, err := r.GetUserByID(id)
+		if err != nil {
+			return fmt.Errorf("DeleteUser: %w", err)
+		}
+		query := `DELETE FROM users WHERE id = ?`
+		_, err = r.db.Exec(query, id)
+		if err != nil {
+			return fmt.Errorf("DeleteUser: %w", err)
+		}
+		return r.audit("user.delete", "user", id, before, nil)
+	})
 }
 
 // =============================
@@ -122,24 +154,26 @@ func (r *Repository) DeleteUser(id int) error {
 
 // CreateDepartment inserts a new department.
 func (r *Repository) CreateDepartment(d *types.Department) error {
-	query := `INSERT INTO departments (name) VALUES (?)`
-	result, err := r.DB.Exec(query, d.Name)
-	if err != nil {
-		return fmt.Errorf("CreateDepartment: %w", err)
-	}
-	id, err := result.LastInsertId()
-	if err != nil {
-		return fmt.Errorf("CreateDepartment (retrieve id): %w", err)
-	}
-	d.ID = int(id)
-	return nil
+	return r.transaction(func(r *Repository) error {
+		query := `INSERT INTO departments (name) VALUES (?)`
+		result, err := r.db.Exec(query, d.Name)
+		if err != nil {
+			return fmt.Errorf("CreateDepartment: %w", err)
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("CreateDepartment (retrieve id): %w", err)
+		}
+		d.ID = int(id)
+		return r.audit("department.create", "department", d.ID, nil, d)
+	})
 }
 
 // GetDepartmentByID retrieves a department by ID.
 func (r *Repository) GetDepartmentByID(id int) (*types.Department, error) {
 	query := `SELECT id, name FROM departments WHERE id = ?`
 	d := &types.Department{}
-	if err := r.DB.QueryRow(query, id).Scan(&d.ID, &d.Name); err != nil {
+	if err := r.db.QueryRow(query, id).Scan(&d.ID, &d.Name); err != nil {
 		return nil, fmt.Errorf("GetDepartmentByID: %w", err)
 	}
 	return d, nil
@@ -148,7 +182,7 @@ func (r *Repository) GetDepartmentByID(id int) (*types.Department, error) {
 // GetAllDepartments retrieves all departments.
 func (r *Repository) GetAllDepartments() ([]types.Department, error) {
 	query := `SELECT id, name FROM departments`
-	rows, err := r.DB.Query(query)
+	rows, err := r.db.Query(query)
 	if err != nil {
 		return nil, fmt.Errorf("GetAllDepartments: %w", err)
 	}
@@ -167,22 +201,34 @@ func (r *Repository) GetAllDepartments() ([]types.Department, error) {
 
 // UpdateDepartment updates an existing department.
 func (r *Repository) UpdateDepartment(d *types.Department) error {
-	query := `UPDATE departments SET name = ? WHERE id = ?`
-	_, err := r.DB.Exec(query, d.Name, d.ID)
-	if err != nil {
-		return fmt.Errorf("UpdateDepartment: %w", err)
-	}
-	return nil
+	return r.transaction(func(r *Repository) error {
+		before, err := r.GetDepartmentByID(d.ID)
+		if err != nil {
+			return fmt.Errorf("UpdateDepartment: %w", err)
+		}
+		query := `UPDATE departments SET name = ? WHERE id = ?`
+		_, err = r.db.Exec(query, d.Name, d.ID)
+		if err != nil {
+			return fmt.Errorf("UpdateDepartment: %w", err)
+		}
+		return r.audit("department.update", "department", d.ID, before, d)
+	})
 }
 
 // DeleteDepartment removes a department by ID.
 func (r *Repository) DeleteDepartment(id int) error {
-	query := `DELETE FROM departments WHERE id = ?`
-	_, err := r.DB.Exec(query, id)
-	if err != nil {
-		return fmt.Errorf("DeleteDepartment: %w", err)
-	}
-	return nil
+	return r.transaction(func(r *Repository) error {
+		before, err := r.GetDepartmentByID(id)
+		if err != nil {
+			return fmt.Errorf("DeleteDepartment: %w", err)
+		}
+		query := `DELETE FROM departments WHERE id = ?`
+		_, err = r.db.Exec(query, id)
+		if err != nil {
+			return fmt.Errorf("DeleteDepartment: %w", err)
+		}
+		return r.audit("department.delete", "department", id, before, nil)
+	})
 }
 
 // =============================
@@ -191,24 +237,26 @@ func (r *Repository) DeleteDepartment(id int) error {
 
 // CreateCourse inserts a new course.
 func (r *Repository) CreateCourse(c *types.Course) error {
-	query := `INSERT INTO courses (name, department_id) VALUES (?, ?)`
-	result, err := r.DB.Exec(query, c.Name, c.DepartmentID)
-	if err != nil {
-		return fmt.Errorf("CreateCourse: %w", err)
-	}
-	id, err := result.LastInsertId()
-	if err != nil {
-		return fmt.Errorf("CreateCourse (retrieve id): %w", err)
-	}
-	c.ID = int(id)
-	return nil
+	return r.transaction(func(r *Repository) error {
+		query := `INSERT INTO courses (name, department_id) VALUES (?, ?)`
+		result, err := r.db.Exec(query, c.Name, c.DepartmentID)
+		if err != nil {
+			return fmt.Errorf("CreateCourse: %w", err)
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("CreateCourse (retrieve id): %w", err)
+		}
+		c.ID = int(id)
+		return r.audit("course.create", "course", c.ID, nil, c)
+	})
 }
 
 // GetCourseByID retrieves a course by ID.
 func (r *Repository) GetCourseByID(id int) (*types.Course, error) {
 	query := `SELECT id, name, department_id FROM courses WHERE id = ?`
 	c := &types.Course{}
-	if err := r.DB.QueryRow(query, id).Scan(&c.ID, &c.Name, &c.DepartmentID); err != nil {
+	if err := r.db.QueryRow(query, id).Scan(&c.ID, &c.Name, &c.DepartmentID); err != nil {
 		return nil, fmt.Errorf("GetCourseByID: %w", err)
 	}
 	return c, nil
@@ -217,7 +265,7 @@ func (r *Repository) GetCourseByID(id int) (*types.Course, error) {
 // GetAllCourses retrieves all courses.
 func (r *Repository) GetAllCourses() ([]types.Course, error) {
 	query := `SELECT id, name, department_id FROM courses`
-	rows, err := r.DB.Query(query)
+	rows, err := r.db.Query(query)
 	if err != nil {
 		return nil, fmt.Errorf("GetAllCourses: %w", err)
 	}
@@ -236,22 +284,34 @@ func (r *Repository) GetAllCourses() ([]types.Course, error) {
 
 // UpdateCourse updates an existing course.
 func (r *Repository) UpdateCourse(c *types.Course) error {
-	query := `UPDATE courses SET name = ?, department_id = ? WHERE id = ?`
-	_, err := r.DB.Exec(query, c.Name, c.DepartmentID, c.ID)
-	if err != nil {
-		return fmt.Errorf("UpdateCourse: %w", err)
-	}
-	return nil
+	return r.transaction(func(r *Repository) error {
+		before, err := r.GetCourseByID(c.ID)
+		if err != nil {
+			return fmt.Errorf("UpdateCourse: %w", err)
+		}
+		query := `UPDATE courses SET name = ?, department_id = ? WHERE id = ?`
+		_, err = r.db.Exec(query, c.Name, c.DepartmentID, c.ID)
+		if err != nil {
+			return fmt.Errorf("UpdateCourse: %w", err)
+		}
+		return r.audit("course.update", "course", c.ID, before, c)
+	})
 }
 
 // DeleteCourse removes a course by ID.
 func (r *Repository) DeleteCourse(id int) error {
-	query := `DELETE FROM courses WHERE id = ?`
-	_, err := r.DB.Exec(query, id)
-	if err != nil {
-		return fmt.Errorf("DeleteCourse: %w", err)
-	}
-	return nil
+	return r.transaction(func(r *Repository) error {
+		before, err := r.GetCourseByID(id)
+		if err != nil {
+			return fmt.Errorf("DeleteCourse: %w", err)
+		}
+		query := `DELETE FROM courses WHERE id = ?`
+		_, err = r.db.Exec(query, id)
+		if err != nil {
+			return fmt.Errorf("DeleteCourse: %w", err)
+		}
+		return r.audit("course.delete", "course", id, before, nil)
+	})
 }
 
 // =============================
@@ -261,29 +321,37 @@ func (r *Repository) DeleteCourse(id int) error {
 // CreateSyllabus inserts a new syllabus.
 // Note: submission_date is stored as DATE; we format the time accordingly.
 func (r *Repository) CreateSyllabus(s *types.Syllabus) error {
-	query := `INSERT INTO syllabi (course_id, lecturer_id, status, submission_date, data) VALUES (?, ?, ?, ?, ?)`
-	result, err := r.DB.Exec(query, s.CourseID, s.LecturerID, s.Status, s.SubmissionDate.Format("2006-01-02"), s.Data)
-	if err != nil {
-		return fmt.Errorf("CreateSyllabus: %w", err)
-	}
-	id, err := result.LastInsertId()
-	if err != nil {
-		return fmt.Errorf("CreateSyllabus (retrieve id): %w", err)
-	}
-	s.ID = int(id)
-	return nil
+	return r.transaction(func(r *Repository) error {
+		query := `INSERT INTO syllabi (course_id, lecturer_id, status, submission_date, data, source_syllabus_id, offering_id, search_text) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+		result, err := r.db.Exec(query, s.CourseID, s.LecturerID, s.Status, s.SubmissionDate.Format("2006-01-02"), s.Data, nullableID(s.SourceSyllabusID), nullableID(s.OfferingID), draftSearchText(s.Data))
+		if err != nil {
+			return fmt.Errorf("CreateSyllabus: %w", err)
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("CreateSyllabus (retrieve id): %w", err)
+		}
+		s.ID = int(id)
+		if err := r.recordStatusChange(s.ID, "", s.Status); err != nil {
+			return err
+		}
+		return r.audit("syllabus.create", "syllabus", s.ID, nil, syllabusSnapshot(s))
+	})
 }
 func (r *Repository) GetSyllabusByID(id int) (*types.Syllabus, error) {
-	query := "SELECT id, course_id, lecturer_id, status, submission_date, created_at, updated_at, data FROM syllabi WHERE id = ?"
-	row := r.DB.QueryRow(query, id)
+	query := "SELECT id, course_id, lecturer_id, status, submission_date, created_at, updated_at, data, source_syllabus_id, offering_id FROM syllabi WHERE id = ?"
+	row := r.db.QueryRow(query, id)
 
 	var syl types.Syllabus
 	var submissionDateStr, createdAtStr, updatedAtStr string
+	var sourceID, offeringID sql.NullInt64
 
 	// Scan into all fields of the syllabus
-	if err := row.Scan(&syl.ID, &syl.CourseID, &syl.LecturerID, &syl.Status, &submissionDateStr, &createdAtStr, &updatedAtStr, &syl.Data); err != nil {
+	if err := row.Scan(&syl.ID, &syl.CourseID, &syl.LecturerID, &syl.Status, &submissionDateStr, &createdAtStr, &updatedAtStr, &syl.Data, &sourceID, &offeringID); err != nil {
 		return nil, fmt.Errorf("GetSyllabusByID: %w", err)
 	}
+	syl.SourceSyllabusID = int(sourceID.Int64)
+	syl.OfferingID = int(offeringID.Int64)
 
 	// Parse the date strings into time.Time
 	submissionDate, err := time.Parse("2006-01-02", submissionDateStr)
@@ -307,15 +375,23 @@ func (r *Repository) GetSyllabusByID(id int) (*types.Syllabus, error) {
 	return &syl, nil
 }
 
+// nullableID maps a zero ID to SQL NULL for optional foreign keys.
+func nullableID(id int) interface{} {
+	if id == 0 {
+		return nil
+	}
+	return id
+}
+
 // GetSyllabiByLecturer fetches all syllabi for the given lecturer (user) ID.
 func (r *Repository) GetSyllabiByLecturer(lecturerID int) ([]types.Syllabus, error) {
 	query := `
         SELECT id, course_id, lecturer_id, status, submission_date, created_at, updated_at, data
         FROM syllabi
-        WHERE lecturer_id = ? AND status != 'Deleted'
+        WHERE lecturer_id = ? AND status != ?
         ORDER BY submission_date DESC
     `
-	rows, err := r.DB.Query(query, lecturerID)
+	rows, err := r.db.Query(query, lecturerID, status.Deleted)
 	if err != nil {
 		return nil, fmt.Errorf("GetSyllabiByLecturer: %w", err)
 	}
@@ -349,8 +425,8 @@ func (r *Repository) GetSyllabiByLecturer(lecturerID int) ([]types.Syllabus, err
 
 // GetAllSyllabi retrieves all syllabi.
 func (r *Repository) GetAllSyllabi() ([]types.Syllabus, error) {
-	query := `SELECT id, course_id, lecturer_id, status, submission_date, created_at, updated_at, data FROM syllabi WHERE status != 'Deleted'`
-	rows, err := r.DB.Query(query)
+	query := `SELECT id, course_id, lecturer_id, status, submission_date, created_at, updated_at, data, offering_id FROM syllabi WHERE status != ?`
+	rows, err := r.db.Query(query, status.Deleted)
 	if err != nil {
 		return nil, fmt.Errorf("GetAllSyllabi: %w", err)
 	}
@@ -359,15 +435,25 @@ func (r *Repository) GetAllSyllabi() ([]types.Syllabus, error) {
 	var syllabi []types.Syllabus
 	for rows.Next() {
 		var s types.Syllabus
-		var submissionDate string
-		if err := rows.Scan(&s.ID, &s.CourseID, &s.LecturerID, &s.Status, &submissionDate, &s.CreatedAt, &s.UpdatedAt, &s.Data); err != nil {
+		var submissionDate, createdAtStr, updatedAtStr string
+		var offeringID sql.NullInt64
+		if err := rows.Scan(&s.ID, &s.CourseID, &s.LecturerID, &s.Status, &submissionDate, &createdAtStr, &updatedAtStr, &s.Data, &offeringID); err != nil {
 			return nil, fmt.Errorf("GetAllSyllabi scan: %w", err)
 		}
+		s.OfferingID = int(offeringID.Int64)
 		parsed, err := time.Parse("2006-01-02", submissionDate)
 		if err != nil {
 			return nil, fmt.Errorf("GetAllSyllabi (parse submission_date): %w", err)
 		}
 		s.SubmissionDate = parsed
+		s.CreatedAt, err = time.Parse("2006-01-02 15:04:05", createdAtStr)
+		if err != nil {
+			return nil, fmt.Errorf("GetAllSyllabi (parse created_at): %w", err)
+		}
+		s.UpdatedAt, err = time.Parse("2006-01-02 15:04:05", updatedAtStr)
+		if err != nil {
+			return nil, fmt.Errorf("GetAllSyllabi (parse updated_at): %w", err)
+		}
 		syllabi = append(syllabi, s)
 	}
 	return syllabi, nil
@@ -375,22 +461,81 @@ func (r *Repository) GetAllSyllabi() ([]types.Syllabus, error) {
 
 // UpdateSyllabus updates an existing syllabus.
 func (r *Repository) UpdateSyllabus(s *types.Syllabus) error {
-	query := `UPDATE syllabi SET course_id = ?, lecturer_id = ?, status = ?, submission_date = ?, data = ? WHERE id = ?`
-	_, err := r.DB.Exec(query, s.CourseID, s.LecturerID, s.Status, s.SubmissionDate.Format("2006-01-02"), s.Data, s.ID)
-	if err != nil {
-		return fmt.Errorf("UpdateSyllabus: %w", err)
-	}
-	return nil
+	return r.transaction(func(r *Repository) error {
+		before, err := r.GetSyllabusByID(s.ID)
+		if err != nil {
+			return fmt.Errorf("UpdateSyllabus: %w", err)
+		}
+		if err := before.Status.Check(s.Status); err != nil {
+			return fmt.Errorf("UpdateSyllabus: %w", err)
+		}
+
+		query := `UPDATE syllabi SET course_id = ?, lecturer_id = ?, status = ?, submission_date = ?, data = ?, offering_id = ?, search_text = ? WHERE id = ?`
+		_, err = r.db.Exec(query, s.CourseID, s.LecturerID, s.Status, s.SubmissionDate.Format("2006-01-02"), s.Data, nullableID(s.OfferingID), draftSearchText(s.Data), s.ID)
+		if err != nil {
+			return fmt.Errorf("UpdateSyllabus: %w", err)
+		}
+		if err := r.recordStatusChange(s.ID, before.Status, s.Status); err != nil {
+			return err
+		}
+		return r.audit(syllabusAction(before.Status, s.Status), "syllabus", s.ID, syllabusSnapshot(before), syllabusSnapshot(s))
+	})
+}
+
+// UpdateSyllabusStatus changes only the status of a syllabus. It fails with
+// status.ErrTransition when the syllabus cannot move to that status.
+func (r *Repository) UpdateSyllabusStatus(id int, to status.Status) error {
+	return r.transaction(func(r *Repository) error {
+		previous, err := r.currentStatus(id)
+		if err != nil {
+			return fmt.Errorf("UpdateSyllabusStatus: %w", err)
+		}
+		if previous == "" {
+			return fmt.Errorf("UpdateSyllabusStatus: %w", sql.ErrNoRows)
+		}
+		if err := previous.Check(to); err != nil {
+			return fmt.Errorf("UpdateSyllabusStatus: %w", err)
+		}
+
+		query := `UPDATE syllabi SET status = ? WHERE id = ?`
+		_, err = r.db.Exec(query, to, id)
+		if err != nil {
+			return fmt.Errorf("UpdateSyllabusStatus: %w", err)
+		}
+		if err := r.recordStatusChange(id, previous, to); err != nil {
+			return err
+		}
+		err = r.audit(syllabusAction(previous, to), "syllabus", id,
+			map[string]interface{}{"status": previous}, map[string]interface{}{"status": to})
+		if err != nil {
+			return err
+		}
+		// Approval publishes the syllabus as it is now, and gives its course the
+		// prerequisites it lists; later edits wait for the next approval.
+		if to == status.Approved {
+			if err := r.approvePrerequisites(id); err != nil {
+				return fmt.Errorf("UpdateSyllabusStatus: %w", err)
+			}
+			return r.PublishSyllabus(id)
+		}
+		return nil
+	})
 }
 
 // DeleteSyllabus removes a syllabus by ID.
 func (r *Repository) DeleteSyllabus(id int) error {
-	query := `DELETE FROM syllabi WHERE id = ?`
-	_, err := r.DB.Exec(query, id)
-	if err != nil {
-		return fmt.Errorf("DeleteSyllabus: %w", err)
-	}
-	return nil
+	return r.transaction(func(r *Repository) error {
+		before, err := r.GetSyllabusByID(id)
+		if err != nil {
+			return fmt.Errorf("DeleteSyllabus: %w", err)
+		}
+		query := `DELETE FROM syllabi WHERE id = ?`
+		_, err = r.db.Exec(query, id)
+		if err != nil {
+			return fmt.Errorf("DeleteSyllabus: %w", err)
+		}
+		return r.audit("syllabus.purge", "syllabus", id, syllabusSnapshot(before), nil)
+	})
 }
 
 // RETURN UI COMPONENTS
@@ -401,10 +546,10 @@ func (r *Repository) GetCardsByLecturer(lecturerID int) ([]UIcomponents.Card, er
 		JOIN courses c ON s.course_id = c.id
 		JOIN departments d ON c.department_id = d.id
 		JOIN users u ON s.lecturer_id = u.id
-		WHERE s.lecturer_id = ? and status != 'Deleted'
+		WHERE s.lecturer_id = ? and status != ?
 		ORDER BY s.submission_date DESC
 	`
-	rows, err := r.DB.Query(query, lecturerID)
+	rows, err := r.db.Query(query, lecturerID, status.Deleted)
 	if err != nil {
 		return nil, fmt.Errorf("GetCardsByLecturer: %w", err)
 	}
@@ -414,14 +559,14 @@ func (r *Repository) GetCardsByLecturer(lecturerID int) ([]UIcomponents.Card, er
 	for rows.Next() {
 		var (
 			id                int
-			status            string
+			st                status.Status
 			submissionDateStr string
 			courseName        string
 			departmentName    string
 			lecturerName      string
 		)
 
-		if err := rows.Scan(&id, &status, &submissionDateStr, &courseName, &departmentName, &lecturerName); err != nil {
+		if err := rows.Scan(&id, &st, &submissionDateStr, &courseName, &departmentName, &lecturerName); err != nil {
 			return nil, fmt.Errorf("GetCardsByLecturer scan: %w", err)
 		}
 
@@ -431,13 +576,12 @@ func (r *Repository) GetCardsByLecturer(lecturerID int) ([]UIcomponents.Card, er
 		}
 
 		card := UIcomponents.Card{
-			ID:          id,
-			Date:        submissionDate.Format("02/01/2006"),
-			Title:       courseName,
-			Lecturer:    lecturerName,
-			Field:       departmentName,
-			Status:      status,
-			StatusLabel: status,
+			ID:       id,
+			Date:     submissionDate.Format("02/01/2006"),
+			Title:    courseName,
+			Lecturer: lecturerName,
+			Field:    departmentName,
+			Status:   st,
 		}
 		cards = append(cards, card)
 	}
@@ -448,15 +592,15 @@ func (r *Repository) GetCardsByLecturer(lecturerID int) ([]UIcomponents.Card, er
 // GetDeletedCardsByLecturer fetches all deleted syllabi for the given lecturer (user) ID.
 func (r *Repository) GetDeletedCardsByLecturer(lecturerID int) ([]UIcomponents.Card, error) {
 	query := `
-		SELECT s.id, s.status, s.submission_date, c.name AS courseName, d.name AS departmentName, u.name AS lecturerName
+		SELECT s.id, s.status, DATE(COALESCE(s.deleted_at, s.updated_at)) AS deletedDate, c.name AS courseName, d.name AS departmentName, u.name AS lecturerName
 		FROM syllabi s
 		JOIN courses c ON s.course_id = c.id
 		JOIN departments d ON c.department_id = d.id
 		JOIN users u ON s.lecturer_id = u.id
-		WHERE s.lecturer_id = ? and status = 'Deleted'
-		ORDER BY s.submission_date DESC
+		WHERE s.lecturer_id = ? and status = ?
+		ORDER BY deletedDate DESC
 	`
-	rows, err := r.DB.Query(query, lecturerID)
+	rows, err := r.db.Query(query, lecturerID, status.Deleted)
 	if err != nil {
 		return nil, fmt.Errorf("GetDeletedCardsByLecturer: %w", err)
 	}
@@ -466,14 +610,14 @@ func (r *Repository) GetDeletedCardsByLecturer(lecturerID int) ([]UIcomponents.C
 	for rows.Next() {
 		var (
 			id                int
-			status            string
+			st                status.Status
 			submissionDateStr string
 			courseName        string
 			departmentName    string
 			lecturerName      string
 		)
 
-		if err := rows.Scan(&id, &status, &submissionDateStr, &courseName, &departmentName, &lecturerName); err != nil {
+		if err := rows.Scan(&id, &st, &submissionDateStr, &courseName, &departmentName, &lecturerName); err != nil {
 			return nil, fmt.Errorf("GetDeletedCardsByLecturer scan: %w", err)
 		}
 
@@ -483,13 +627,12 @@ func (r *Repository) GetDeletedCardsByLecturer(lecturerID int) ([]UIcomponents.C
 		}
 
 		card := UIcomponents.Card{
-			ID:          id,
-			Date:        submissionDate.Format("02/01/2006"),
-			Title:       courseName,
-			Lecturer:    lecturerName,
-			Field:       departmentName,
-			Status:      status,
-			StatusLabel: status,
+			ID:       id,
+			Date:     submissionDate.Format("02/01/2006"),
+			Title:    courseName,
+			Lecturer: lecturerName,
+			Field:    departmentName,
+			Status:   st,
 		}
 		cards = append(cards, card)
 	}
@@ -497,16 +640,16 @@ func (r *Repository) GetDeletedCardsByLecturer(lecturerID int) ([]UIcomponents.C
 	return cards, nil
 }
 
-func (r *Repository) FilterCardsByLecturer(lecturerID int, search, fromDate, toDate string, statuses []string) ([]map[string]interface{}, error) {
+func (r *Repository) FilterCardsByLecturer(lecturerID int, search, fromDate, toDate string, termID int, statuses []status.Status) ([]map[string]interface{}, error) {
 	baseQuery := `
 		SELECT s.status, s.submission_date, c.name AS courseName, d.name AS departmentName, u.name AS lecturerName
 		FROM syllabi s
 		JOIN courses c ON s.course_id = c.id
 		JOIN departments d ON c.department_id = d.id
 		JOIN users u ON s.lecturer_id = u.id
-		WHERE s.lecturer_id = ? and s.status != 'Deleted'
+		WHERE s.lecturer_id = ? and s.status != ?
 	`
-	params := []interface{}{lecturerID}
+	params := []interface{}{lecturerID, status.Deleted}
 
 	// Add search filter if provided.
 	if search != "" {
@@ -535,23 +678,24 @@ func (r *Repository) FilterCardsByLecturer(lecturerID int, search, fromDate, toD
 		params = append(params, parsedTo.Format("2006-01-02"))
 	}
 
+	// Filter by academic term if provided.
+	if termID != 0 {
+		baseQuery += " AND s.offering_id IN (SELECT id FROM course_offerings WHERE term_id = ?)"
+		params = append(params, termID)
+	}
+
 	// Filter by statuses if provided.
 	if len(statuses) > 0 {
-		placeholders := make([]string, len(statuses))
-		for i := range statuses {
-			placeholders[i] = "?"
-		}
-		baseQuery += " AND s.status IN (" + strings.Join(placeholders, ",") + ")"
-		for _, s := range statuses {
-			params = append(params, s)
-		}
+		chosen, args := statusList(statuses)
+		baseQuery += " AND s.status IN " + chosen
+		params = append(params, args...)
 	}
 
 	// Order results by submission_date in descending order.
 	baseQuery += " ORDER BY s.submission_date DESC"
 
 	// Execute the query.
-	rows, err := r.DB.Query(baseQuery, params...)
+	rows, err := r.db.Query(baseQuery, params...)
 	if err != nil {
 		return nil, fmt.Errorf("FilterCardsByLecturer: %w", err)
 	}
@@ -559,10 +703,11 @@ func (r *Repository) FilterCardsByLecturer(lecturerID int, search, fromDate, toD
 
 	var cards []map[string]interface{}
 	for rows.Next() {
-		var status, courseName, departmentName, lecturerName string
+		var st status.Status
+		var courseName, departmentName, lecturerName string
 		var submissionDateStr string
 
-		if err := rows.Scan(&status, &submissionDateStr, &courseName, &departmentName, &lecturerName); err != nil {
+		if err := rows.Scan(&st, &submissionDateStr, &courseName, &departmentName, &lecturerName); err != nil {
 			return nil, fmt.Errorf("FilterCardsByLecturer scan: %w", err)
 		}
 
@@ -577,7 +722,7 @@ func (r *Repository) FilterCardsByLecturer(lecturerID int, search, fromDate, toD
 			"title":    courseName,
 			"lecturer": lecturerName,
 			"field":    departmentName,
-			"status":   status,
+			"status":   st,
 		}
 		cards = append(cards, card)
 	}
@@ -593,7 +738,7 @@ func (r *Repository) InsertSyllabusFromDraft(userID int, draft *UIcomponents.Dra
 		ID:             0, // dummy value; DB will auto-increment
 		CourseID:       0,
 		LecturerID:     userID,
-		Status:         "Draft",
+		Status:         status.Draft,
 		SubmissionDate: now, // Save the current time
 		CreatedAt:      now,
 		UpdatedAt:      now,
@@ -612,9 +757,12 @@ func (r *Repository) InsertSyllabusFromDraft(userID int, draft *UIcomponents.Dra
 			break
 		}
 	}
+	if syl.OfferingID, err = r.OfferingForDraft(syl.CourseID, userID, draft); err != nil {
+		return err
+	}
 
 	// Encode the draft into JSON.
-	jsonData, err := json.Marshal(draft)
+	jsonData, err := draft.Marshal()
 	if err != nil {
 		return err
 	}
@@ -637,7 +785,7 @@ func (r *Repository) GetCommentsBySyllabusID(syllabusID int) ([]types.Comment, e
         WHERE syllabus_id = ? 
         ORDER BY created_at ASC
     `
-	rows, err := r.DB.Query(query, syllabusID)
+	rows, err := r.db.Query(query, syllabusID)
 	if err != nil {
 		return nil, fmt.Errorf("GetCommentsBySyllabusID: %w", err)
 	}
@@ -671,34 +819,37 @@ func (r *Repository) GetCommentsBySyllabusID(syllabusID int) ([]types.Comment, e
 
 // AddComment inserts a new comment associated with a syllabus into the DB.
 func (r *Repository) AddComment(c *types.Comment) error {
-	query := `INSERT INTO comments (syllabus_id, user_id, content) VALUES (?, ?, ?)`
-	result, err := r.DB.Exec(query, c.SyllabusID, c.UserID, c.Content)
-	if err != nil {
-		return fmt.Errorf("AddComment: %w", err)
-	}
-	id, err := result.LastInsertId()
-	if err != nil {
-		return fmt.Errorf("AddComment (retrieve id): %w", err)
-	}
-	c.ID = int(id)
-	return nil
+	return r.transaction(func(r *Repository) error {
+		query := `INSERT INTO comments (syllabus_id, user_id, content) VALUES (?, ?, ?)`
+		result, err := r.db.Exec(query, c.SyllabusID, c.UserID, c.Content)
+		if err != nil {
+			return fmt.Errorf("AddComment: %w", err)
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("AddComment (retrieve id): %w", err)
+		}
+		c.ID = int(id)
+		return r.audit("comment.create", "comment", c.ID, nil, c)
+	})
 }
 
 // GetUserDraft retrieves a user's draft from the database.
 func (r *Repository) GetUserDraft(userID int) (*UIcomponents.Draft, error) {
 	// Check if there's a draft syllabus for this user
 	query := `
-		SELECT id, data 
-		FROM syllabi 
-		WHERE lecturer_id = ? AND status = 'Draft' 
-		ORDER BY updated_at DESC 
+		SELECT s.id, s.data, COALESCE(o.term_id, 0)
+		FROM syllabi s
+		LEFT JOIN course_offerings o ON s.offering_id = o.id
+		WHERE s.lecturer_id = ? AND s.status = ?
+		ORDER BY s.updated_at DESC 
 		LIMIT 1
 	`
-	row := r.DB.QueryRow(query, userID)
+	row := r.db.QueryRow(query, userID, status.Draft)
 
-	var id int
+	var id, termID int
 	var data []byte
-	err := row.Scan(&id, &data)
+	err := row.Scan(&id, &data, &termID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			// No draft found, create a new one
@@ -717,13 +868,13 @@ func (r *Repository) GetUserDraft(userID int) (*UIcomponents.Draft, error) {
 				CourseStructure:         []string{},
 				AssignmentsStructure:    []string{},
 				SyllabusRows:            []UIcomponents.SyllabusRow{{}},
-				GradeComponents:         []UIcomponents.GradeComponent{},
-				BibliographyRequired:    []string{},
-				BibliographyRecommended: []string{},
+				GradeComponents:         []grading.Component{},
+				BibliographyRequired:    []bibliography.Entry{},
+				BibliographyRecommended: []bibliography.Entry{},
 			}
 
 			// Store the new draft in the database
-			jsonData, err := json.Marshal(draft)
+			jsonData, err := draft.Marshal()
 			if err != nil {
 				return nil, fmt.Errorf("GetUserDraft (marshal): %w", err)
 			}
@@ -733,7 +884,7 @@ func (r *Repository) GetUserDraft(userID int) (*UIcomponents.Draft, error) {
 				ID:             0,
 				CourseID:       1,
 				LecturerID:     userID,
-				Status:         "Draft",
+				Status:         status.Draft,
 				SubmissionDate: now,
 				CreatedAt:      now,
 				UpdatedAt:      now,
@@ -759,6 +910,10 @@ func (r *Repository) GetUserDraft(userID int) (*UIcomponents.Draft, error) {
 	}
 
 	draft.ID = id
+	draft.TermID = termID
+	if draft.TermID == 0 {
+		draft.TermID = draft.UnsavedTermID
+	}
 	return &draft, nil
 }
 
@@ -781,13 +936,13 @@ func (r *Repository) CreateNewUserDraft(userID int) (*UIcomponents.Draft, error)
 		CourseStructure:         []string{},
 		AssignmentsStructure:    []string{},
 		SyllabusRows:            []UIcomponents.SyllabusRow{{}},
-		GradeComponents:         []UIcomponents.GradeComponent{},
-		BibliographyRequired:    []string{},
-		BibliographyRecommended: []string{},
+		GradeComponents:         []grading.Component{},
+		BibliographyRequired:    []bibliography.Entry{},
+		BibliographyRecommended: []bibliography.Entry{},
 	}
 
 	// Store the new draft in the database
-	jsonData, err := json.Marshal(draft)
+	jsonData, err := draft.Marshal()
 	if err != nil {
 		return nil, fmt.Errorf("CreateNewUserDraft (marshal): %w", err)
 	}
@@ -797,7 +952,7 @@ func (r *Repository) CreateNewUserDraft(userID int) (*UIcomponents.Draft, error)
 		ID:             0,
 		CourseID:       1,
 		LecturerID:     userID,
-		Status:         "Draft",
+		Status:         status.Draft,
 		SubmissionDate: now,
 		CreatedAt:      now,
 		UpdatedAt:      now,
@@ -815,43 +970,74 @@ func (r *Repository) CreateNewUserDraft(userID int) (*UIcomponents.Draft, error)
 
 // SaveUserDraft saves a user's draft to the database.
 func (r *Repository) SaveUserDraft(userID int, draft *UIcomponents.Draft) error {
-	// Marshal the draft to JSON
-	jsonData, err := json.Marshal(draft)
-	if err != nil {
-		return fmt.Errorf("SaveUserDraft (marshal): %w", err)
-	}
-
-	// Check if this is a new draft or an existing one
-	if draft.ID == -1 {
-		// Create a new draft
-		now := time.Now()
-		syl := types.Syllabus{
-			ID:             0,
-			CourseID:       0,
-			LecturerID:     userID,
-			Status:         "Draft",
-			SubmissionDate: now,
-			CreatedAt:      now,
-			UpdatedAt:      now,
-			Data:           jsonData,
+	return r.transaction(func(r *Repository) error {
+		// The term of the draft is kept in the offering of its course once
+		// the syllabus is saved, and with the draft until then.
+		courseID, offeringID, err := r.draftOffering(draft)
+		if err != nil {
+			return fmt.Errorf("SaveUserDraft: %w", err)
 		}
+		draft.KeepTerm(offeringID)
 
-		err = r.CreateSyllabus(&syl)
+		// Marshal the draft to JSON
+		jsonData, err := draft.Marshal()
 		if err != nil {
-			return fmt.Errorf("SaveUserDraft (create): %w", err)
+			return fmt.Errorf("SaveUserDraft (marshal): %w", err)
 		}
 
-		draft.ID = syl.ID
-	} else {
-		// Update existing draft
-		query := `UPDATE syllabi SET data = ?, updated_at = ? WHERE id = ?`
-		_, err := r.DB.Exec(query, jsonData, time.Now().Format("2006-01-02 15:04:05"), draft.ID)
-		if err != nil {
-			return fmt.Errorf("SaveUserDraft (update): %w", err)
+		// Check if this is a new draft or an existing one
+		if draft.ID == -1 {
+			// Create a new draft, under the default course until one from the catalog is selected
+			if courseID == 0 {
+				courseID = 1
+			}
+			now := time.Now()
+			syl := types.Syllabus{
+				ID:             0,
+				CourseID:       courseID,
+				LecturerID:     userID,
+				Status:         status.Draft,
+				SubmissionDate: now,
+				CreatedAt:      now,
+				UpdatedAt:      now,
+				Data:           jsonData,
+				OfferingID:     offeringID,
+			}
+
+			err = r.CreateSyllabus(&syl)
+			if err != nil {
+				return fmt.Errorf("SaveUserDraft (create): %w", err)
+			}
+
+			draft.ID = syl.ID
+		} else {
+			// Update existing draft
+			var previous json.RawMessage
+			var previousCourse int
+			var previousOffering sql.NullInt64
+			query := `SELECT data, course_id, offering_id FROM syllabi WHERE id = ?`
+			if err := r.db.QueryRow(query, draft.ID).Scan(&previous, &previousCourse, &previousOffering); err != nil {
+				return fmt.Errorf("SaveUserDraft (read previous): %w", err)
+			}
+			// A course name outside the catalog keeps the course the draft had.
+			if courseID == 0 {
+				courseID = previousCourse
+			}
+			query = `UPDATE syllabi SET data = ?, search_text = ?, updated_at = ?, course_id = ?, offering_id = ? WHERE id = ?`
+			_, err := r.db.Exec(query, jsonData, draftSearchText(jsonData), time.Now().Format("2006-01-02 15:04:05"), courseID, nullableID(offeringID), draft.ID)
+			if err != nil {
+				return fmt.Errorf("SaveUserDraft (update): %w", err)
+			}
+			err = r.audit("syllabus.edit", "syllabus", draft.ID,
+				map[string]interface{}{"data": previous, "course_id": previousCourse, "offering_id": int(previousOffering.Int64)},
+				map[string]interface{}{"data": json.RawMessage(jsonData), "course_id": courseID, "offering_id": offeringID})
+			if err != nil {
+				return err
+			}
 		}
-	}
 
-	return nil
+		return nil
+	})
 }
 
 // GetEditedSyllabus retrieves a syllabus by ID and unmarshals its data into a Draft.
@@ -867,6 +1053,84 @@ func (r *Repository) GetEditedSyllabus(syllabusID int) (*UIcomponents.Draft, err
 		return nil, fmt.Errorf("GetEditedSyllabus (unmarshal): %w", err)
 	}
 
+	draft.ID = syl.ID
+	if err := r.draftTerm(&draft, syl.OfferingID); err != nil {
+		return nil, fmt.Errorf("GetEditedSyllabus: %w", err)
+	}
+	return &draft, nil
+}
+
+// =============================
+//    SYLLABUS DUPLICATION
+// =============================
+
+// DuplicateSyllabus copies an existing syllabus into a new draft owned by userID.
+// The term fields are cleared so the lecturer picks the new semester, numeric lesson
+// numbers are shifted by lessonOffset, and the source ID is kept for lineage.
+func (r *Repository) DuplicateSyllabus(sourceID, userID, lessonOffset int) (*UIcomponents.Draft, error) {
+	source, err := r.GetSyllabusByID(sourceID)
+	if err != nil {
+		return nil, fmt.Errorf("DuplicateSyllabus: %w", err)
+	}
+
+	user, err := r.GetUserByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("DuplicateSyllabus (get user): %w", err)
+	}
+
+	// Unmarshalling the stored JSON into a fresh Draft gives us a deep copy.
+	var draft UIcomponents.Draft
+	if err := json.Unmarshal(source.Data, &draft); err != nil {
+		return nil, fmt.Errorf("DuplicateSyllabus (unmarshal): %w", err)
+	}
+
+	draft.LecturerName = user.Name
+	draft.LecturerEmail = user.Email
+	draft.Year = ""
+	draft.Semester = ""
+	draft.TermID = 0
+	draft.UnsavedTermID = 0
+	if len(draft.SyllabusRows) == 0 {
+		draft.SyllabusRows = []UIcomponents.SyllabusRow{{}}
+	}
+	// Lesson dates belong to the source term; they are regenerated for the new one.
+	for i := range draft.SyllabusRows {
+		draft.SyllabusRows[i].Date = ""
+		draft.SyllabusRows[i].Holiday = ""
+	}
+	if lessonOffset != 0 {
+		before := append([]UIcomponents.SyllabusRow(nil), draft.SyllabusRows...)
+		for i := range draft.SyllabusRows {
+			n, err := strconv.Atoi(strings.TrimSpace(draft.SyllabusRows[i].LessonNumber))
+			if err != nil {
+				// Leave free-text lesson numbers untouched.
+				continue
+			}
+			draft.SyllabusRows[i].LessonNumber = strconv.Itoa(n + lessonOffset)
+		}
+		draft.RelinkLessons(before, draft.SyllabusRows)
+	}
+
+	jsonData, err := draft.Marshal()
+	if err != nil {
+		return nil, fmt.Errorf("DuplicateSyllabus (marshal): %w", err)
+	}
+
+	now := time.Now()
+	syl := types.Syllabus{
+		CourseID:         source.CourseID,
+		LecturerID:       userID,
+		Status:           status.Draft,
+		SubmissionDate:   now,
+		CreatedAt:        now,
+		UpdatedAt:        now,
+		Data:             jsonData,
+		SourceSyllabusID: source.ID,
+	}
+	if err := r.CreateSyllabus(&syl); err != nil {
+		return nil, fmt.Errorf("DuplicateSyllabus (create): %w", err)
+	}
+
 	draft.ID = syl.ID
 	return &draft, nil
 }
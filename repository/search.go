@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"Syllybea/UIcomponents"
+	"Syllybea/bibliography"
+	"Syllybea/status"
+	"Syllybea/utils"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// =============================
+//     FULL-TEXT SEARCH
+// =============================
+
+// maxSnippets is the number of highlighted snippets returned per search result.
+const maxSnippets = 3
+
+// draftSearchText extracts the searchable text of a syllabus from its JSON data,
+// one item per line, for the FULLTEXT-indexed search_text column. The Hebrew
+// text comes first, then its translations by language code.
+func draftSearchText(data []byte) string {
+	var draft UIcomponents.Draft
+	if len(data) == 0 || json.Unmarshal(data, &draft) != nil {
+		return ""
+	}
+
+	var lines []string
+	add := func(values ...string) {
+		for _, v := range values {
+			if v = strings.TrimSpace(v); v != "" {
+				lines = append(lines, v)
+			}
+		}
+	}
+
+	add(draft.SelectedCourse, draft.SyllabusDepartment, draft.LecturerName, draft.Prerequisites)
+	add(draft.CourseRequirements...)
+	add(draft.LearningOutcomes...)
+	add(draft.CourseObjectives...)
+	add(draft.ActiveLearning1, draft.ActiveLearning2, draft.ActiveLearning3, draft.ActiveLearning4)
+	for _, row := range draft.SyllabusRows {
+		add(row.MainTopic, row.LessonTopics, row.Subtopics, row.ReadingMaterial)
+	}
+	for _, comp := range draft.GradeComponents {
+		add(comp.PartName)
+	}
+	add(draft.AssignmentsStructure...)
+	for _, list := range [][]bibliography.Entry{draft.BibliographyRequired, draft.BibliographyRecommended} {
+		for _, b := range list {
+			add(b.Format(bibliography.APA), b.ISBN)
+		}
+	}
+
+	codes := make([]string, 0, len(draft.Translations))
+	for code := range draft.Translations {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		t := draft.Translations[code]
+		if t == nil {
+			continue
+		}
+		add(t.CourseName, t.Prerequisites)
+		add(t.CourseRequirements...)
+		add(t.LearningOutcomes...)
+		add(t.CourseObjectives...)
+		add(t.OtherCourseStructure, t.ActiveLearning1, t.ActiveLearning2, t.ActiveLearning3, t.ActiveLearning4)
+		for _, lesson := range t.Lessons {
+			add(lesson.MainTopic, lesson.LessonTopics, lesson.Subtopics, lesson.ReadingMaterial)
+		}
+		add(t.AssignmentsStructure...)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// RebuildSearchIndex brings search_text up to date for syllabi saved before the
+// column existed or indexed by an earlier version of draftSearchText. It
+// returns the number of syllabi reindexed.
+func (r *Repository) RebuildSearchIndex() (int, error) {
+	rows, err := r.db.Query(`SELECT id, data, search_text FROM syllabi`)
+	if err != nil {
+		return 0, fmt.Errorf("RebuildSearchIndex: %w", err)
+	}
+
+	type pending struct {
+		id   int
+		text string
+	}
+	var updates []pending
+	for rows.Next() {
+		var id int
+		var data []byte
+		var indexed sql.NullString
+		if err := rows.Scan(&id, &data, &indexed); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("RebuildSearchIndex scan: %w", err)
+		}
+		if text := draftSearchText(data); !indexed.Valid || indexed.String != text {
+			updates = append(updates, pending{id: id, text: text})
+		}
+	}
+	rows.Close()
+
+	// Reindexing is not an edit: keep updated_at.
+	for _, u := range updates {
+		if _, err := r.db.Exec(`UPDATE syllabi SET search_text = ?, updated_at = updated_at WHERE id = ?`, u.text, u.id); err != nil {
+			return 0, fmt.Errorf("RebuildSearchIndex (update %d): %w", u.id, err)
+		}
+	}
+	return len(updates), nil
+}
+
+// SearchSyllabi runs a ranked full-text search over the contents of all
+// non-deleted syllabi and returns the best matches with highlighted snippets.
+func (r *Repository) SearchSyllabi(search string, limit int) ([]UIcomponents.SearchResult, error) {
+	terms := utils.SearchTerms(search)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+
+	// Boolean mode with prefix matching so short or partial words still match;
+	// MATCH() still returns a relevance score we can rank on.
+	boolQuery := make([]string, len(terms))
+	for i, t := range terms {
+		boolQuery[i] = t + "*"
+	}
+	against := strings.Join(boolQuery, " ")
+
+	query := `
+		SELECT s.id, s.status, c.name, d.name, u.name, COALESCE(s.search_text, ''),
+		       MATCH(s.search_text) AGAINST (? IN BOOLEAN MODE) AS score
+		FROM syllabi s
+		JOIN courses c ON s.course_id = c.id
+		JOIN departments d ON c.department_id = d.id
+		JOIN users u ON s.lecturer_id = u.id
+		WHERE s.status != ? AND MATCH(s.search_text) AGAINST (? IN BOOLEAN MODE)
+		ORDER BY score DESC, s.updated_at DESC
+		LIMIT ?
+	`
+	rows, err := r.db.Query(query, against, status.Deleted, against, limit)
+	if err != nil {
+		return nil, fmt.Errorf("SearchSyllabi: %w", err)
+	}
+	defer rows.Close()
+
+	var results []UIcomponents.SearchResult
+	for rows.Next() {
+		var res UIcomponents.SearchResult
+		var text string
+		if err := rows.Scan(&res.ID, &res.Status, &res.Title, &res.Field, &res.Lecturer, &text, &res.Score); err != nil {
+			return nil, fmt.Errorf("SearchSyllabi scan: %w", err)
+		}
+		res.Snippets = utils.HighlightSnippets(text, terms, maxSnippets)
+		results = append(results, res)
+	}
+	return results, nil
+}
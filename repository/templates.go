@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"Syllybea/UIcomponents"
+	"Syllybea/types"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// =============================
+//    SYLLABUS TEMPLATES CRUD
+// =============================
+
+// CreateTemplate inserts a new department template.
+func (r *Repository) CreateTemplate(t *types.SyllabusTemplate) error {
+	return r.transaction(func(r *Repository) error {
+		locked, err := json.Marshal(t.LockedSections)
+		if err != nil {
+			return fmt.Errorf("CreateTemplate (marshal locked sections): %w", err)
+		}
+		query := `INSERT INTO syllabus_templates (department_id, name, data, locked_sections, created_by) VALUES (?, ?, ?, ?, ?)`
+		result, err := r.db.Exec(query, t.DepartmentID, t.Name, t.Data, locked, nullableID(t.CreatedBy))
+		if err != nil {
+			return fmt.Errorf("CreateTemplate: %w", err)
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("CreateTemplate (retrieve id): %w", err)
+		}
+		t.ID = int(id)
+		return r.audit("template.create", "template", t.ID, nil, t)
+	})
+}
+
+// GetTemplateByID retrieves a template by ID together with its department name.
+func (r *Repository) GetTemplateByID(id int) (*types.SyllabusTemplate, error) {
+	query := `
+		SELECT t.id, t.department_id, d.name, t.name, t.data, t.locked_sections, COALESCE(t.created_by, 0), t.created_at, t.updated_at
+		FROM syllabus_templates t
+		JOIN departments d ON t.department_id = d.id
+		WHERE t.id = ?
+	`
+	t, err := scanTemplate(r.db.QueryRow(query, id))
+	if err != nil {
+		return nil, fmt.Errorf("GetTemplateByID: %w", err)
+	}
+	return t, nil
+}
+
+// GetAllTemplates retrieves all templates ordered by department and name.
+func (r *Repository) GetAllTemplates() ([]types.SyllabusTemplate, error) {
+	query := `
+		SELECT t.id, t.department_id, d.name, t.name, t.data, t.locked_sections, COALESCE(t.created_by, 0), t.created_at, t.updated_at
+		FROM syllabus_templates t
+		JOIN departments d ON t.department_id = d.id
+		ORDER BY d.name, t.name
+	`
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("GetAllTemplates: %w", err)
+	}
+	defer rows.Close()
+
+	var templates []types.SyllabusTemplate
+	for rows.Next() {
+		t, err := scanTemplate(rows)
+		if err != nil {
+			return nil, fmt.Errorf("GetAllTemplates scan: %w", err)
+		}
+		templates = append(templates, *t)
+	}
+	return templates, nil
+}
+
+// UpdateTemplate updates an existing template.
+func (r *Repository) UpdateTemplate(t *types.SyllabusTemplate) error {
+	return r.transaction(func(r *Repository) error {
+		locked, err := json.Marshal(t.LockedSections)
+		if err != nil {
+			return fmt.Errorf("UpdateTemplate (marshal locked sections): %w", err)
+		}
+		before, err := r.GetTemplateByID(t.ID)
+		if err != nil {
+			return fmt.Errorf("UpdateTemplate: %w", err)
+		}
+		query := `UPDATE syllabus_templates SET department_id = ?, name = ?, data = ?, locked_sections = ? WHERE id = ?`
+		_, err = r.db.Exec(query, t.DepartmentID, t.Name, t.Data, locked, t.ID)
+		if err != nil {
+			return fmt.Errorf("UpdateTemplate: %w", err)
+		}
+		return r.audit("template.update", "template", t.ID, before, t)
+	})
+}
+
+// DeleteTemplate removes a template by ID. Drafts created from it keep their content.
+func (r *Repository) DeleteTemplate(id int) error {
+	return r.transaction(func(r *Repository) error {
+		before, err := r.GetTemplateByID(id)
+		if err != nil {
+			return fmt.Errorf("DeleteTemplate: %w", err)
+		}
+		query := `DELETE FROM syllabus_templates WHERE id = ?`
+		_, err = r.db.Exec(query, id)
+		if err != nil {
+			return fmt.Errorf("DeleteTemplate: %w", err)
+		}
+		return r.audit("template.delete", "template", id, before, nil)
+	})
+}
+
+// CreateDraftFromTemplate creates a new draft for the user pre-populated with the
+// template content and carrying the template's locked sections.
+func (r *Repository) CreateDraftFromTemplate(templateID, userID int) (*UIcomponents.Draft, error) {
+	t, err := r.GetTemplateByID(templateID)
+	if err != nil {
+		return nil, fmt.Errorf("CreateDraftFromTemplate: %w", err)
+	}
+
+	draft, err := r.CreateNewUserDraft(userID)
+	if err != nil {
+		return nil, fmt.Errorf("CreateDraftFromTemplate: %w", err)
+	}
+
+	var content UIcomponents.Draft
+	if err := json.Unmarshal(t.Data, &content); err != nil {
+		return nil, fmt.Errorf("CreateDraftFromTemplate (unmarshal): %w", err)
+	}
+
+	if content.CourseRequirements != nil {
+		draft.CourseRequirements = content.CourseRequirements
+	}
+	if content.LearningOutcomes != nil {
+		draft.LearningOutcomes = content.LearningOutcomes
+	}
+	if content.CourseObjectives != nil {
+		draft.CourseObjectives = content.CourseObjectives
+	}
+	if content.GradeComponents != nil {
+		draft.GradeComponents = content.GradeComponents
+	}
+	if content.AssignmentsStructure != nil {
+		draft.AssignmentsStructure = content.AssignmentsStructure
+	}
+	if content.BibliographyRequired != nil {
+		draft.BibliographyRequired = content.BibliographyRequired
+	}
+	if content.BibliographyRecommended != nil {
+		draft.BibliographyRecommended = content.BibliographyRecommended
+	}
+	draft.SyllabusDepartment = t.DepartmentName
+	draft.TemplateID = t.ID
+	draft.LockedSections = t.LockedSections
+
+	if err := r.SaveUserDraft(userID, draft); err != nil {
+		return nil, fmt.Errorf("CreateDraftFromTemplate: %w", err)
+	}
+	return draft, nil
+}
+
+// scanTemplate scans a template row selected with its department name.
+func scanTemplate(row interface{ Scan(...interface{}) error }) (*types.SyllabusTemplate, error) {
+	var t types.SyllabusTemplate
+	var locked []byte
+	var createdAtStr, updatedAtStr string
+	if err := row.Scan(&t.ID, &t.DepartmentID, &t.DepartmentName, &t.Name, &t.Data, &locked, &t.CreatedBy, &createdAtStr, &updatedAtStr); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(locked, &t.LockedSections); err != nil {
+		return nil, fmt.Errorf("parsing locked_sections: %w", err)
+	}
+	var err error
+	t.CreatedAt, err = time.Parse("2006-01-02 15:04:05", createdAtStr)
+	if err != nil {
+		return nil, fmt.Errorf("parsing created_at: %w", err)
+	}
+	t.UpdatedAt, err = time.Parse("2006-01-02 15:04:05", updatedAtStr)
+	if err != nil {
+		return nil, fmt.Errorf("parsing updated_at: %w", err)
+	}
+	return &t, nil
+}
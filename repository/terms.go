@@ -0,0 +1,249 @@
+package repository
+
+import (
+	"Syllybea/UIcomponents"
+	"Syllybea/types"
+	"Syllybea/utils"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// =============================
+//       ACADEMIC TERMS
+// =============================
+
+const termColumns = `id, academic_year, semester, start_date, end_date, hebrew_label`
+
+// scanTerm reads a row selected with termColumns.
+func scanTerm(scan func(dest ...interface{}) error) (*types.Term, error) {
+	t := &types.Term{}
+	var startStr, endStr string
+	if err := scan(&t.ID, &t.AcademicYear, &t.Semester, &startStr, &endStr, &t.HebrewLabel); err != nil {
+		return nil, err
+	}
+	var err error
+	if t.StartDate, err = time.Parse("2006-01-02", startStr); err != nil {
+		return nil, fmt.Errorf("parsing start_date: %w", err)
+	}
+	if t.EndDate, err = time.Parse("2006-01-02", endStr); err != nil {
+		return nil, fmt.Errorf("parsing end_date: %w", err)
+	}
+	return t, nil
+}
+
+// CreateTerm inserts a new term. An empty label is filled in from the year and semester.
+func (r *Repository) CreateTerm(t *types.Term) error {
+	return r.transaction(func(r *Repository) error {
+		if t.HebrewLabel == "" {
+			t.HebrewLabel = utils.TermLabel(t.AcademicYear, t.Semester)
+		}
+		query := `INSERT INTO terms (academic_year, semester, start_date, end_date, hebrew_label) VALUES (?, ?, ?, ?, ?)`
+		result, err := r.db.Exec(query, t.AcademicYear, t.Semester, t.StartDate.Format("2006-01-02"), t.EndDate.Format("2006-01-02"), t.HebrewLabel)
+		if err != nil {
+			return fmt.Errorf("CreateTerm: %w", err)
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("CreateTerm (retrieve id): %w", err)
+		}
+		t.ID = int(id)
+		return r.audit("term.create", "term", t.ID, nil, termSnapshot(t))
+	})
+}
+
+// GetTermByID retrieves a term by ID.
+func (r *Repository) GetTermByID(id int) (*types.Term, error) {
+	t, err := scanTerm(r.db.QueryRow(`SELECT `+termColumns+` FROM terms WHERE id = ?`, id).Scan)
+	if err != nil {
+		return nil, fmt.Errorf("GetTermByID: %w", err)
+	}
+	return t, nil
+}
+
+// GetAllTerms retrieves all terms, latest first.
+func (r *Repository) GetAllTerms() ([]types.Term, error) {
+	rows, err := r.db.Query(`SELECT ` + termColumns + ` FROM terms ORDER BY start_date DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("GetAllTerms: %w", err)
+	}
+	defer rows.Close()
+
+	var terms []types.Term
+	for rows.Next() {
+		t, err := scanTerm(rows.Scan)
+		if err != nil {
+			return nil, fmt.Errorf("GetAllTerms scan: %w", err)
+		}
+		terms = append(terms, *t)
+	}
+	return terms, nil
+}
+
+// UpdateTerm updates the dates and label of a term.
+func (r *Repository) UpdateTerm(t *types.Term) error {
+	return r.transaction(func(r *Repository) error {
+		before, err := r.GetTermByID(t.ID)
+		if err != nil {
+			return fmt.Errorf("UpdateTerm: %w", err)
+		}
+		query := `UPDATE terms SET start_date = ?, end_date = ?, hebrew_label = ? WHERE id = ?`
+		if _, err := r.db.Exec(query, t.StartDate.Format("2006-01-02"), t.EndDate.Format("2006-01-02"), t.HebrewLabel, t.ID); err != nil {
+			return fmt.Errorf("UpdateTerm: %w", err)
+		}
+		t.AcademicYear, t.Semester = before.AcademicYear, before.Semester
+		return r.audit("term.update", "term", t.ID, termSnapshot(before), termSnapshot(t))
+	})
+}
+
+// EnsureTerm returns the term of an academic year and semester, creating it
+// with the default dates when it does not exist yet.
+func (r *Repository) EnsureTerm(academicYear int, semester string) (*types.Term, error) {
+	query := `SELECT ` + termColumns + ` FROM terms WHERE academic_year = ? AND semester = ?`
+	t, err := scanTerm(r.db.QueryRow(query, academicYear, semester).Scan)
+	if err == nil {
+		return t, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("EnsureTerm: %w", err)
+	}
+
+	t = &types.Term{AcademicYear: academicYear, Semester: semester}
+	t.StartDate, t.EndDate = utils.DefaultTermDates(academicYear, semester)
+	if err := r.CreateTerm(t); err != nil {
+		return nil, fmt.Errorf("EnsureTerm: %w", err)
+	}
+	return t, nil
+}
+
+// CurrentTerm returns the term taking place at the given time, or the next one
+// to start when it falls between terms.
+func (r *Repository) CurrentTerm(at time.Time) (*types.Term, error) {
+	query := `SELECT ` + termColumns + ` FROM terms WHERE end_date >= ? ORDER BY start_date LIMIT 1`
+	t, err := scanTerm(r.db.QueryRow(query, at.Format("2006-01-02")).Scan)
+	if err == nil {
+		return t, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("CurrentTerm: %w", err)
+	}
+	return r.EnsureTerm(utils.AcademicYear(at), utils.CurrentSemester(at))
+}
+
+// GetTermOptions lists all terms as dropdown options, latest first.
+func (r *Repository) GetTermOptions() ([]UIcomponents.TermOption, error) {
+	terms, err := r.GetAllTerms()
+	if err != nil {
+		return nil, err
+	}
+	options := make([]UIcomponents.TermOption, 0, len(terms))
+	for _, t := range terms {
+		options = append(options, UIcomponents.TermOption{ID: t.ID, Label: t.HebrewLabel})
+	}
+	return options, nil
+}
+
+// MigrateDraftTerms links syllabi saved before terms existed to a term, reading
+// the free-text year and semester lecturers entered. An academic year typed in
+// the year field ("2025/26") is moved to the term and the field is cleared;
+// otherwise the academic year is the one the syllabus was created in. The term
+// is kept in the legacy termID of the data until BackfillOfferings moves it to
+// the offering of the syllabus.
+func (r *Repository) MigrateDraftTerms() (int, error) {
+	query := `
+		SELECT id, data, created_at
+		FROM syllabi
+		WHERE offering_id IS NULL AND JSON_TYPE(data) = 'OBJECT' AND JSON_EXTRACT(data, '$.termID') IS NULL
+	`
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return 0, fmt.Errorf("MigrateDraftTerms: %w", err)
+	}
+
+	type pending struct {
+		id        int
+		draft     UIcomponents.Draft
+		createdAt time.Time
+	}
+	var todo []pending
+	for rows.Next() {
+		var p pending
+		var data []byte
+		var createdAtStr string
+		if err := rows.Scan(&p.id, &data, &createdAtStr); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("MigrateDraftTerms scan: %w", err)
+		}
+		if json.Unmarshal(data, &p.draft) != nil {
+			continue
+		}
+		if p.createdAt, err = time.Parse("2006-01-02 15:04:05", createdAtStr); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("MigrateDraftTerms: parsing created_at: %w", err)
+		}
+		todo = append(todo, p)
+	}
+	rows.Close()
+
+	migrated := 0
+	for _, p := range todo {
+		year, semester, ok := parseDraftTerm(&p.draft, p.createdAt)
+		if !ok {
+			continue
+		}
+		term, err := r.EnsureTerm(year, semester)
+		if err != nil {
+			return migrated, fmt.Errorf("MigrateDraftTerms: %w", err)
+		}
+		p.draft.Semester = semester
+
+		data, err := json.Marshal(p.draft)
+		if err != nil {
+			return migrated, fmt.Errorf("MigrateDraftTerms (marshal %d): %w", p.id, err)
+		}
+		// A data migration, not an edit: keep updated_at and stay out of the audit log.
+		query := `UPDATE syllabi SET data = JSON_SET(?, '$.termID', ?), updated_at = updated_at WHERE id = ?`
+		if _, err := r.db.Exec(query, data, term.ID, p.id); err != nil {
+			return migrated, fmt.Errorf("MigrateDraftTerms (update %d): %w", p.id, err)
+		}
+		migrated++
+	}
+	return migrated, nil
+}
+
+// parseDraftTerm reads the academic year and semester from the free-text fields
+// of a draft. It returns false when no semester can be recognized.
+func parseDraftTerm(d *UIcomponents.Draft, createdAt time.Time) (int, string, bool) {
+	year := utils.AcademicYear(createdAt)
+	if y, ok := utils.ParseAcademicYear(d.Year); ok {
+		year = y
+		d.Year = ""
+	}
+
+	semester := utils.NormalizeSemester(d.Semester)
+	if semester == "" {
+		// Values such as "סמסטר א' 2025/26" carry both parts.
+		for _, token := range strings.Fields(d.Semester) {
+			if y, ok := utils.ParseAcademicYear(token); ok {
+				year = y
+			} else if s := utils.NormalizeSemester(token); s != "" {
+				semester = s
+			}
+		}
+	}
+	return year, semester, semester != ""
+}
+
+// termSnapshot is the audited form of a term, with dates at DATE precision.
+func termSnapshot(t *types.Term) map[string]interface{} {
+	return map[string]interface{}{
+		"academic_year": t.AcademicYear,
+		"semester":      t.Semester,
+		"start_date":    t.StartDate.Format("2006-01-02"),
+		"end_date":      t.EndDate.Format("2006-01-02"),
+		"hebrew_label":  t.HebrewLabel,
+	}
+}
@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"Syllybea/status"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// =============================
+//            TRASH
+// =============================
+
+// TrashSyllabus moves a syllabus to the trash, remembering its status and
+// when it was deleted so it can be restored or purged later.
+func (r *Repository) TrashSyllabus(id int) error {
+	return r.transaction(func(r *Repository) error {
+		previous, err := r.currentStatus(id)
+		if err != nil {
+			return fmt.Errorf("TrashSyllabus: %w", err)
+		}
+		if previous == "" {
+			return fmt.Errorf("TrashSyllabus: %w", sql.ErrNoRows)
+		}
+		if previous == status.Deleted {
+			return nil
+		}
+
+		// MySQL assigns left to right, so status_before_delete receives the old status.
+		query := `UPDATE syllabi SET status_before_delete = status, status = ?, deleted_at = NOW() WHERE id = ?`
+		if _, err := r.db.Exec(query, status.Deleted, id); err != nil {
+			return fmt.Errorf("TrashSyllabus: %w", err)
+		}
+		if err := r.recordStatusChange(id, previous, status.Deleted); err != nil {
+			return err
+		}
+		if err := r.UnpublishSyllabus(id); err != nil {
+			return fmt.Errorf("TrashSyllabus: %w", err)
+		}
+		return r.audit("syllabus.delete", "syllabus", id,
+			map[string]interface{}{"status": previous}, map[string]interface{}{"status": status.Deleted})
+	})
+}
+
+// RestoreSyllabus takes a syllabus out of the trash with the status it had
+// before deletion, and returns that status. Syllabi deleted before the previous
+// status was recorded come back as drafts.
+func (r *Repository) RestoreSyllabus(id int) (status.Status, error) {
+	restored := status.Draft
+	err := r.transaction(func(r *Repository) error {
+		var previous status.Status
+		err := r.db.QueryRow(`SELECT status_before_delete FROM syllabi WHERE id = ? AND status = ?`, id, status.Deleted).Scan(&previous)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("RestoreSyllabus: syllabus %d is not in the trash: %w", id, err)
+		}
+		if err != nil {
+			return fmt.Errorf("RestoreSyllabus: %w", err)
+		}
+
+		if status.Deleted.CanBecome(previous) && previous != status.Deleted {
+			restored = previous
+		}
+
+		query := `UPDATE syllabi SET status = ?, status_before_delete = NULL, deleted_at = NULL WHERE id = ?`
+		if _, err := r.db.Exec(query, restored, id); err != nil {
+			return fmt.Errorf("RestoreSyllabus: %w", err)
+		}
+		if err := r.recordStatusChange(id, status.Deleted, restored); err != nil {
+			return err
+		}
+		err = r.audit("syllabus.restore", "syllabus", id,
+			map[string]interface{}{"status": status.Deleted}, map[string]interface{}{"status": restored})
+		if err != nil {
+			return err
+		}
+		if restored == status.Approved {
+			if err := r.PublishSyllabus(id); err != nil {
+				return fmt.Errorf("RestoreSyllabus: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return restored, nil
+}
+
+// EmptyTrash permanently deletes every syllabus in the lecturer's trash and
+// returns how many were removed.
+func (r *Repository) EmptyTrash(lecturerID int) (int, error) {
+	ids, err := r.trashedIDs(`SELECT id FROM syllabi WHERE status = ? AND lecturer_id = ?`, status.Deleted, lecturerID)
+	if err != nil {
+		return 0, fmt.Errorf("EmptyTrash: %w", err)
+	}
+	return r.purge(ids)
+}
+
+// PurgeTrash permanently deletes syllabi that have been in the trash since
+// before the cutoff and returns how many were removed. Syllabi deleted before
+// the deletion time was recorded are aged by their last update.
+func (r *Repository) PurgeTrash(cutoff time.Time) (int, error) {
+	query := `SELECT id FROM syllabi WHERE status = ? AND COALESCE(deleted_at, updated_at) < ?`
+	ids, err := r.trashedIDs(query, status.Deleted, cutoff.Format("2006-01-02 15:04:05"))
+	if err != nil {
+		return 0, fmt.Errorf("PurgeTrash: %w", err)
+	}
+	return r.purge(ids)
+}
+
+func (r *Repository) trashedIDs(query string, args ...interface{}) ([]int, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// purge deletes the syllabi one by one so each deletion is audited.
+func (r *Repository) purge(ids []int) (int, error) {
+	for i, id := range ids {
+		if err := r.DeleteSyllabus(id); err != nil {
+			return i, err
+		}
+	}
+	return len(ids), nil
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"Syllybea/types"
+	"Syllybea/workload"
+	"fmt"
+)
+
+// =============================
+//      WORKLOAD NORMS
+// =============================
+
+// GetWorkloadNorms retrieves the workload norm of every department, ordered by
+// department name. Departments without a norm of their own get the default one.
+func (r *Repository) GetWorkloadNorms() ([]types.WorkloadNorm, error) {
+	query := `
+		SELECT d.id, d.name, COALESCE(n.hours_per_credit, ?), COALESCE(n.tolerance_percent, ?)
+		FROM departments d
+		LEFT JOIN workload_norms n ON n.department_id = d.id
+		ORDER BY d.name
+	`
+	rows, err := r.db.Query(query, workload.DefaultNorm.HoursPerCredit, workload.DefaultNorm.TolerancePercent)
+	if err != nil {
+		return nil, fmt.Errorf("GetWorkloadNorms: %w", err)
+	}
+	defer rows.Close()
+
+	var norms []types.WorkloadNorm
+	for rows.Next() {
+		var n types.WorkloadNorm
+		if err := rows.Scan(&n.DepartmentID, &n.DepartmentName, &n.HoursPerCredit, &n.TolerancePercent); err != nil {
+			return nil, fmt.Errorf("GetWorkloadNorms scan: %w", err)
+		}
+		norms = append(norms, n)
+	}
+	return norms, nil
+}
+
+// GetWorkloadNorm retrieves the workload norm of a department, or the default
+// norm when the department has not set one.
+func (r *Repository) GetWorkloadNorm(departmentID int) (*types.WorkloadNorm, error) {
+	query := `
+		SELECT d.id, d.name, COALESCE(n.hours_per_credit, ?), COALESCE(n.tolerance_percent, ?)
+		FROM departments d
+		LEFT JOIN workload_norms n ON n.department_id = d.id
+		WHERE d.id = ?
+	`
+	var n types.WorkloadNorm
+	err := r.db.QueryRow(query, workload.DefaultNorm.HoursPerCredit, workload.DefaultNorm.TolerancePercent, departmentID).
+		Scan(&n.DepartmentID, &n.DepartmentName, &n.HoursPerCredit, &n.TolerancePercent)
+	if err != nil {
+		return nil, fmt.Errorf("GetWorkloadNorm: %w", err)
+	}
+	return &n, nil
+}
+
+// SaveWorkloadNorm sets the workload norm of a department.
+func (r *Repository) SaveWorkloadNorm(n *types.WorkloadNorm) error {
+	return r.transaction(func(r *Repository) error {
+		before, err := r.GetWorkloadNorm(n.DepartmentID)
+		if err != nil {
+			return fmt.Errorf("SaveWorkloadNorm: %w", err)
+		}
+		query := `
+			INSERT INTO workload_norms (department_id, hours_per_credit, tolerance_percent) VALUES (?, ?, ?)
+			ON DUPLICATE KEY UPDATE hours_per_credit = VALUES(hours_per_credit), tolerance_percent = VALUES(tolerance_percent)
+		`
+		if _, err := r.db.Exec(query, n.DepartmentID, n.HoursPerCredit, n.TolerancePercent); err != nil {
+			return fmt.Errorf("SaveWorkloadNorm: %w", err)
+		}
+		n.DepartmentName = before.DepartmentName
+		return r.audit("workload_norm.update", "workload_norm", n.DepartmentID, before, n)
+	})
+}
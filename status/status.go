@@ -0,0 +1,176 @@
+// Package status defines the statuses of a syllabus: their values in the
+// database, their labels and styles in the user interface, and which status
+// a syllabus may move to from another.
+package status
+
+import (
+	"Syllybea/i18n"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+)
+
+// Status is the status of a syllabus, stored as the value of the status enum
+// of the syllabi table.
+type Status string
+
+// Statuses of a syllabus. The zero value is no status, as before a syllabus
+// was first saved.
+const (
+	UnsavedDraft Status = "UnsavedDraft"
+	Draft        Status = "Draft"
+	InReview     Status = "In Review"
+	Approved     Status = "Approved"
+	Deleted      Status = "Deleted"
+)
+
+// All lists the statuses in the order a syllabus usually goes through them.
+var All = []Status{UnsavedDraft, Draft, InReview, Approved, Deleted}
+
+// Listed are the statuses of the syllabi shown in lists and counted in
+// reports; unsaved and deleted syllabi are left out. They are also the
+// statuses lists can be filtered by.
+var Listed = []Status{Draft, InReview, Approved}
+
+// ErrTransition is returned when a syllabus cannot move between two statuses.
+var ErrTransition = errors.New("status transition not allowed")
+
+// transitions lists the statuses a syllabus may move to from each status.
+// Saving a syllabus of any status makes it a draft again, and a deleted
+// syllabus is restored to the status it had.
+var transitions = map[Status][]Status{
+	UnsavedDraft: {Draft, InReview, Deleted},
+	Draft:        {InReview, Deleted},
+	InReview:     {Draft, Approved, Deleted},
+	Approved:     {Draft, InReview, Deleted},
+	Deleted:      {Draft, InReview, Approved},
+}
+
+// labels are the messages of the statuses, translated by the catalogs of package i18n.
+var labels = map[Status]string{
+	UnsavedDraft: "טיוטה שלא נשמרה",
+	Draft:        "טיוטא",
+	InReview:     "בתהליך",
+	Approved:     "מאושר",
+	Deleted:      "נמחק",
+}
+
+// Parse returns the status with the given value.
+func Parse(s string) (Status, error) {
+	st := Status(s)
+	if !st.Valid() {
+		return "", fmt.Errorf("unknown syllabus status %q", s)
+	}
+	return st, nil
+}
+
+// ParseList returns the valid statuses among the given values, as sent by
+// the status filters of a list, and drops the rest.
+func ParseList(values []string) []Status {
+	var statuses []Status
+	for _, v := range values {
+		if st, err := Parse(v); err == nil {
+			statuses = append(statuses, st)
+		}
+	}
+	return statuses
+}
+
+// Valid reports whether s is one of the statuses.
+func (s Status) Valid() bool {
+	_, ok := transitions[s]
+	return ok
+}
+
+// IsListed reports whether a syllabus of this status is shown in lists.
+func (s Status) IsListed() bool {
+	for _, st := range Listed {
+		if s == st {
+			return true
+		}
+	}
+	return false
+}
+
+// AwaitsReview reports whether a syllabus of this status waits for a manager
+// to approve it or return it to the lecturer.
+func (s Status) AwaitsReview() bool {
+	return s == InReview
+}
+
+// CanBecome reports whether a syllabus may move from s to another status.
+// Keeping its status is always allowed, and so is any status for a syllabus
+// that has none yet.
+func (s Status) CanBecome(to Status) bool {
+	if s == to || s == "" {
+		return to.Valid()
+	}
+	for _, next := range transitions[s] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Check returns an error wrapping ErrTransition when a syllabus cannot move
+// from s to another status.
+func (s Status) Check(to Status) error {
+	if !s.CanBecome(to) {
+		return fmt.Errorf("%w: from %q to %q", ErrTransition, s, to)
+	}
+	return nil
+}
+
+// Label returns the name of the status in a locale.
+func (s Status) Label(locale string) string {
+	if label, ok := labels[s]; ok {
+		return i18n.T(locale, label)
+	}
+	return string(s)
+}
+
+// Class returns the CSS class of the status badge, e.g. "in-review".
+func (s Status) Class() string {
+	switch s {
+	case UnsavedDraft, Draft:
+		return "draft"
+	case InReview:
+		return "in-review"
+	case Approved:
+		return "approved"
+	case Deleted:
+		return "deleted"
+	}
+	return ""
+}
+
+// Scan implements sql.Scanner. A NULL or empty status scans as no status.
+func (s *Status) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*s = ""
+		return nil
+	case []byte:
+		*s = Status(v)
+	case string:
+		*s = Status(v)
+	default:
+		return fmt.Errorf("status: cannot scan %T", src)
+	}
+	if *s != "" && !s.Valid() {
+		return fmt.Errorf("status: unknown value %q", string(*s))
+	}
+	return nil
+}
+
+// Value implements driver.Valuer. No status is stored as NULL.
+func (s Status) Value() (driver.Value, error) {
+	if s == "" {
+		return nil, nil
+	}
+	if !s.Valid() {
+		return nil, fmt.Errorf("status: unknown value %q", string(s))
+	}
+	return string(s), nil
+}
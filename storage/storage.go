@@ -1,24 +1,41 @@
 package storage
 
 import (
+	"Syllybea/config"
+	"context"
 	"database/sql"
 	"fmt"
 	_ "github.com/go-sql-driver/mysql"
+	"log/slog"
+	"time"
 )
 
 type Storage struct {
 	DB *sql.DB
 }
 
-func NewStorage(dsn string) (*Storage, error) {
+// Delays between attempts to reach the database at startup, doubling from
+// the first to the last.
+const (
+	firstRetryDelay = 500 * time.Millisecond
+	maxRetryDelay   = 5 * time.Second
+)
+
+// NewStorage opens the database and sizes its connection pool as configured.
+// Until the database answers it retries for cfg.ConnectTimeout, or until ctx
+// is done, so the server may start before the database does.
+func NewStorage(ctx context.Context, cfg config.Database) (*Storage, error) {
 	// Open the database connection
-	db, err := sql.Open("mysql", dsn)
+	db, err := sql.Open("mysql", cfg.DSN)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open DB: %w", err)
 	}
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
 
 	// Test the connection
-	if err = db.Ping(); err != nil {
+	if err = ping(ctx, db, cfg.ConnectTimeout); err != nil {
 		db.Close()
 		return nil, fmt.Errorf("failed to ping DB: %w", err)
 	}
@@ -26,3 +43,26 @@ func NewStorage(dsn string) (*Storage, error) {
 	// Return the Storage struct with the open DB
 	return &Storage{DB: db}, nil
 }
+
+// ping pings the database until it answers or timeout has passed.
+func ping(ctx context.Context, db *sql.DB, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	delay := firstRetryDelay
+	for attempt := 1; ; attempt++ {
+		err := db.PingContext(ctx)
+		if err == nil {
+			return nil
+		}
+		if time.Now().Add(delay).After(deadline) {
+			return fmt.Errorf("after %d attempts: %w", attempt, err)
+		}
+		slog.WarnContext(ctx, "Database not reachable, retrying", "attempt", attempt, "delay", delay, "err", err)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, maxRetryDelay)
+	}
+}
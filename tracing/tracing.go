@@ -0,0 +1,54 @@
+// Package tracing sets up OpenTelemetry tracing. A request is traced from the
+// handler that serves it to the repository queries it makes, and the spans
+// are exported as configured; with no exporter they are not recorded at all.
+package tracing
+
+import (
+	"Syllybea/config"
+	"context"
+	"fmt"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// serviceName identifies the server in the exported spans.
+const serviceName = "syllabea"
+
+// Tracer starts the spans of the server. Until Setup installs a provider it
+// is a no-op, so spans cost nothing when tracing is off.
+func Tracer() trace.Tracer {
+	return otel.Tracer("Syllybea")
+}
+
+// Setup installs the tracer provider for exporter, one of config.TracingNone
+// and config.TracingStdout, and the W3C trace context propagator, so a trace
+// started by a caller continues in the server. The returned function flushes
+// the spans not yet exported and must be called on shutdown.
+func Setup(exporter string) (shutdown func(context.Context) error, err error) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	var exp sdktrace.SpanExporter
+	switch exporter {
+	case config.TracingNone:
+		return func(context.Context) error { return nil }, nil
+	case config.TracingStdout:
+		exp, err = stdouttrace.New()
+	default:
+		err = fmt.Errorf("unknown trace exporter %q", exporter)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exp),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(serviceName))),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
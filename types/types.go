@@ -1,6 +1,7 @@
 package types
 
 import (
+	"Syllybea/status"
 	"encoding/json"
 	"time"
 )
@@ -32,15 +33,88 @@ type Syllabus struct {
 	ID             int             `json:"id"`
 	CourseID       int             `json:"course_id"`
 	LecturerID     int             `json:"lecturer_id"`
-	Status         string          `json:"status"`          // Possible values: "Draft", "Pending", "In Review", "Approved"
+	Status         status.Status   `json:"status"`
 	SubmissionDate time.Time       `json:"submission_date"` // Maps to the DATE column in MySQL
 	CreatedAt      time.Time       `json:"created_at"`
 	UpdatedAt      time.Time       `json:"updated_at"`
 	Data           json.RawMessage `json:"data"` // Stored as JSON in the DB
+	// SourceSyllabusID is the syllabus this one was duplicated from (0 if it was created from scratch).
+	SourceSyllabusID int `json:"source_syllabus_id"`
+	// OfferingID is the course offering (term) the syllabus belongs to (0 until a semester is chosen).
+	OfferingID int `json:"offering_id"`
 }
 
-// SyllabusStatus is a custom type for the status of a syllabus.
-type SyllabusStatus string
+// Term represents a row in the 'terms' table.
+type Term struct {
+	ID           int       `json:"id"`
+	AcademicYear int       `json:"academic_year"` // Calendar year the academic year starts in, e.g. 2025 for תשפ״ו
+	Semester     string    `json:"semester"`      // "1", "2" or "קיץ"
+	StartDate    time.Time `json:"start_date"`
+	EndDate      time.Time `json:"end_date"`
+	HebrewLabel  string    `json:"hebrew_label"` // e.g. "תשפ״ו סמסטר א'"
+}
+
+// PublishedSyllabus represents a row in the 'published_syllabi' table: the public,
+// frozen copy of an approved syllabus, joined with the names shown to students.
+type PublishedSyllabus struct {
+	ID          int             `json:"id"`
+	SyllabusID  int             `json:"syllabus_id"`
+	CourseID    int             `json:"course_id"`
+	TermID      int             `json:"term_id"` // 0 when the syllabus has no term
+	Section     string          `json:"section"`
+	Slug        string          `json:"slug"` // Public URL path, stable across re-approvals
+	Data        json.RawMessage `json:"data"` // The Draft as it was approved
+	PublishedAt time.Time       `json:"published_at"`
+	CourseName  string          `json:"course_name"`
+	Department  string          `json:"department"`
+	TermLabel   string          `json:"term_label"`
+}
+
+// Holiday represents a row in the 'holidays' table: a day or range of days without teaching.
+type Holiday struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	StartDate time.Time `json:"start_date"`
+	EndDate   time.Time `json:"end_date"` // Inclusive; equal to StartDate for a single day
+}
+
+// ProgramOutcome represents a row in the 'program_outcomes' table: a learning
+// outcome of a department's study program that course outcomes contribute to.
+type ProgramOutcome struct {
+	ID             int    `json:"id"`
+	DepartmentID   int    `json:"department_id"`
+	DepartmentName string `json:"department_name"`
+	Code           string `json:"code"` // Short label such as "PO1"
+	Description    string `json:"description"`
+}
+
+// WorkloadNorm represents a row in the 'workload_norms' table: the credit-hour
+// norm student workload is checked against in a department's syllabi.
+type WorkloadNorm struct {
+	DepartmentID     int     `json:"department_id"`
+	DepartmentName   string  `json:"department_name"`
+	HoursPerCredit   float64 `json:"hours_per_credit"`  // Hours of contact and self-study per credit point
+	TolerancePercent float64 `json:"tolerance_percent"` // Allowed deviation from the norm
+}
+
+// CourseOffering represents a row in the 'course_offerings' table: a course given in a specific term.
+type CourseOffering struct {
+	ID          int       `json:"id"`
+	CourseID    int       `json:"course_id"`
+	TermID      int       `json:"term_id"`
+	Section     string    `json:"section"`      // Optional group number, "" when the course has a single group
+	LecturerIDs []int     `json:"lecturer_ids"` // From 'course_offering_lecturers'
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// StatusChange represents a row in the 'syllabus_status_history' table.
+type StatusChange struct {
+	ID         int           `json:"id"`
+	SyllabusID int           `json:"syllabus_id"`
+	FromStatus status.Status `json:"from_status"` // Empty for the first recorded status
+	ToStatus   status.Status `json:"to_status"`
+	ChangedAt  time.Time     `json:"changed_at"`
+}
 
 type Comment struct {
 	ID         int       `json:"id" db:"id"`                   // Unique identifier for the comment.
@@ -50,3 +124,29 @@ type Comment struct {
 	CreatedAt  time.Time `json:"created_at" db:"created_at"`   // Timestamp of when the comment was created.
 	UpdatedAt  time.Time `json:"updated_at" db:"updated_at"`   // Timestamp of the last update (auto-updated on change).
 }
+
+// SyllabusTemplate represents a row in the 'syllabus_templates' table.
+type SyllabusTemplate struct {
+	ID             int             `json:"id"`
+	DepartmentID   int             `json:"department_id"`
+	DepartmentName string          `json:"department_name"` // Filled in by joins, not stored
+	Name           string          `json:"name"`
+	Data           json.RawMessage `json:"data"`            // Draft-shaped JSON content
+	LockedSections []string        `json:"locked_sections"` // Section keys lecturers may not edit
+	CreatedBy      int             `json:"created_by"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+}
+
+// AuditEntry represents a row in the 'audit_log' table.
+type AuditEntry struct {
+	ID         int             `json:"id"`
+	ActorID    int             `json:"actor_id"`   // 0 when the change was not made by a logged-in user
+	ActorName  string          `json:"actor_name"` // Filled in by joins, not stored
+	Action     string          `json:"action"`     // e.g. "syllabus.update", "syllabus.delete"
+	EntityType string          `json:"entity_type"`
+	EntityID   int             `json:"entity_id"`
+	Changes    json.RawMessage `json:"changes"` // {"field": {"before": ..., "after": ...}}
+	IP         string          `json:"ip"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
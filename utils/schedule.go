@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"Syllybea/types"
+	"time"
+)
+
+// WeekdayLetters are the day letters used in the syllabus form, Sunday first.
+var WeekdayLetters = []string{"א", "ב", "ג", "ד", "ה", "ו"}
+
+// ParseHebrewWeekday returns the weekday of a day letter such as "א" (Sunday).
+func ParseHebrewWeekday(letter string) (time.Weekday, bool) {
+	for i, l := range WeekdayLetters {
+		if l == letter {
+			return time.Weekday(i), true
+		}
+	}
+	return 0, false
+}
+
+// Lesson is a meeting of a course on a given date. Holiday names the holiday
+// the date falls on, if any; such lessons are kept so lecturers can reschedule them.
+type Lesson struct {
+	Date    time.Time
+	Holiday string
+}
+
+// LessonSchedule lists the meetings of a course held on the given weekdays
+// between start and end (inclusive), flagging those that fall on a holiday.
+func LessonSchedule(start, end time.Time, days []time.Weekday, holidays []types.Holiday) []Lesson {
+	meets := map[time.Weekday]bool{}
+	for _, d := range days {
+		meets[d] = true
+	}
+	if len(meets) == 0 {
+		return nil
+	}
+
+	start = dateOnly(start)
+	end = dateOnly(end)
+	var lessons []Lesson
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		if !meets[d.Weekday()] {
+			continue
+		}
+		lesson := Lesson{Date: d}
+		for _, h := range holidays {
+			if !d.Before(dateOnly(h.StartDate)) && !d.After(dateOnly(h.EndDate)) {
+				lesson.Holiday = h.Name
+				break
+			}
+		}
+		lessons = append(lessons, lesson)
+	}
+	return lessons
+}
+
+// dateOnly drops the time of day, keeping the calendar date in UTC.
+func dateOnly(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"html"
+	"html/template"
+	"strings"
+	"unicode"
+)
+
+// snippetRadius is the number of characters kept on each side of the first match in a snippet.
+const snippetRadius = 60
+
+// SearchTerms splits a search query into lower-cased words, dropping the
+// characters that have a special meaning in MySQL boolean full-text queries.
+func SearchTerms(query string) []string {
+	cleaned := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`+-<>()~*"@`, r) {
+			return ' '
+		}
+		return r
+	}, query)
+
+	var terms []string
+	seen := map[string]bool{}
+	for _, f := range strings.Fields(strings.ToLower(cleaned)) {
+		if !seen[f] {
+			seen[f] = true
+			terms = append(terms, f)
+		}
+	}
+	return terms
+}
+
+// HighlightSnippets returns up to max lines of text that contain one of the terms,
+// trimmed around the first match and with every match wrapped in <mark>.
+// The text is HTML-escaped, so the result is safe to render.
+func HighlightSnippets(text string, terms []string, max int) []template.HTML {
+	var snippets []template.HTML
+	for _, line := range strings.Split(text, "\n") {
+		if len(snippets) >= max {
+			break
+		}
+		runes := []rune(line)
+		first := -1
+		for _, t := range terms {
+			if i := runeIndex(runes, []rune(t)); i >= 0 && (first < 0 || i < first) {
+				first = i
+			}
+		}
+		if first < 0 {
+			continue
+		}
+
+		start, end := first-snippetRadius, first+snippetRadius
+		prefix, suffix := "…", "…"
+		if start <= 0 {
+			start, prefix = 0, ""
+		}
+		if end >= len(runes) {
+			end, suffix = len(runes), ""
+		}
+		snippets = append(snippets, template.HTML(prefix+markTerms(runes[start:end], terms)+suffix))
+	}
+	return snippets
+}
+
+// markTerms escapes the runes and wraps every occurrence of a term in <mark>.
+func markTerms(runes []rune, terms []string) string {
+	var b strings.Builder
+	for i := 0; i < len(runes); {
+		matched := 0
+		for _, t := range terms {
+			tr := []rune(t)
+			if len(tr) > matched && hasPrefixAt(runes, tr, i) {
+				matched = len(tr)
+			}
+		}
+		if matched > 0 {
+			b.WriteString("<mark>" + html.EscapeString(string(runes[i:i+matched])) + "</mark>")
+			i += matched
+			continue
+		}
+		b.WriteString(html.EscapeString(string(runes[i])))
+		i++
+	}
+	return b.String()
+}
+
+// runeIndex returns the first case-insensitive position of sub in s, or -1.
+func runeIndex(s, sub []rune) int {
+	for i := range s {
+		if hasPrefixAt(s, sub, i) {
+			return i
+		}
+	}
+	return -1
+}
+
+// hasPrefixAt reports whether the lower-cased prefix occurs in s at position at.
+func hasPrefixAt(s, prefix []rune, at int) bool {
+	if len(prefix) == 0 || at+len(prefix) > len(s) {
+		return false
+	}
+	for j, r := range prefix {
+		if unicode.ToLower(s[at+j]) != r {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,177 @@
+package utils
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// academicYearStartMonth is the month from which syllabi belong to the next academic year.
+// Teaching starts in October, but fall syllabi are prepared over the summer.
+const academicYearStartMonth = time.August
+
+// AcademicYear returns the calendar year the academic year of t starts in,
+// e.g. 2025 for any date from August 2025 to July 2026.
+func AcademicYear(t time.Time) int {
+	if t.Month() >= academicYearStartMonth {
+		return t.Year()
+	}
+	return t.Year() - 1
+}
+
+// CurrentSemester returns the semester ("1", "2" or "קיץ") that t falls in.
+func CurrentSemester(t time.Time) string {
+	switch {
+	case t.Month() >= time.October || t.Month() == time.January:
+		return "1"
+	case t.Month() <= time.June:
+		return "2"
+	}
+	return "קיץ"
+}
+
+// AcademicYearLabel formats an academic year as "2025/26".
+func AcademicYearLabel(year int) string {
+	next := strconv.Itoa((year + 1) % 100)
+	if len(next) == 1 {
+		next = "0" + next
+	}
+	return strconv.Itoa(year) + "/" + next
+}
+
+// SemesterLabel returns the Hebrew display name of a semester value.
+func SemesterLabel(semester string) string {
+	switch semester {
+	case "1":
+		return "סמסטר א'"
+	case "2":
+		return "סמסטר ב'"
+	case "קיץ":
+		return "סמסטר קיץ"
+	}
+	return semester
+}
+
+// TermLabel formats an academic year and semester with the Hebrew year,
+// e.g. "תשפ״ו סמסטר א'". It is the label stored on new terms.
+func TermLabel(year int, semester string) string {
+	return HebrewYear(year) + " " + SemesterLabel(semester)
+}
+
+// TermSlug formats an academic year and semester for use in URLs, e.g. "2025-26-a".
+func TermSlug(year int, semester string) string {
+	code := semester
+	switch semester {
+	case "1":
+		code = "a"
+	case "2":
+		code = "b"
+	case "קיץ":
+		code = "summer"
+	}
+	return strings.Replace(AcademicYearLabel(year), "/", "-", 1) + "-" + code
+}
+
+// hebrewLetters maps the values used in Hebrew year numerals to their letters.
+var hebrewLetters = []struct {
+	value  int
+	letter string
+}{
+	{400, "ת"}, {300, "ש"}, {200, "ר"}, {100, "ק"},
+	{90, "צ"}, {80, "פ"}, {70, "ע"}, {60, "ס"}, {50, "נ"}, {40, "מ"}, {30, "ל"}, {20, "כ"}, {10, "י"},
+	{9, "ט"}, {8, "ח"}, {7, "ז"}, {6, "ו"}, {5, "ה"}, {4, "ד"}, {3, "ג"}, {2, "ב"}, {1, "א"},
+}
+
+// HebrewYear returns the Hebrew calendar year an academic year starts in,
+// written without the thousands, e.g. "תשפ״ו" for 2025.
+func HebrewYear(academicYear int) string {
+	n := (academicYear + 3761) % 1000
+	// 15 and 16 are written as 9+6 and 9+7 rather than spelling a divine name.
+	var tail []string
+	switch n % 100 {
+	case 15:
+		tail, n = []string{"ט", "ו"}, n-15
+	case 16:
+		tail, n = []string{"ט", "ז"}, n-16
+	}
+	var letters []string
+	for _, l := range hebrewLetters {
+		for n >= l.value {
+			letters = append(letters, l.letter)
+			n -= l.value
+		}
+	}
+	letters = append(letters, tail...)
+	switch len(letters) {
+	case 0:
+		return ""
+	case 1:
+		return letters[0] + "׳"
+	}
+	return strings.Join(letters[:len(letters)-1], "") + "״" + letters[len(letters)-1]
+}
+
+// ParseAcademicYear reads a free-text academic year as lecturers used to type it
+// ("2025/26", "2025-2026", "2025" or "תשפ״ו") and returns the year it starts in.
+func ParseAcademicYear(s string) (int, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, false
+	}
+	if i := strings.IndexAny(s, "/-"); i > 0 {
+		s = s[:i]
+	}
+	if year, err := strconv.Atoi(strings.TrimSpace(s)); err == nil {
+		if year >= 2000 && year < 2100 {
+			return year, true
+		}
+		return 0, false
+	}
+	for year := 2000; year < 2100; year++ {
+		if normalizeHebrewNumeral(HebrewYear(year)) == normalizeHebrewNumeral(s) {
+			return year, true
+		}
+	}
+	return 0, false
+}
+
+// normalizeHebrewNumeral drops the geresh marks and a leading ה (the thousands),
+// which are written inconsistently.
+func normalizeHebrewNumeral(s string) string {
+	s = strings.NewReplacer("״", "", "׳", "", "\"", "", "'", "", " ", "").Replace(s)
+	if strings.HasPrefix(s, "ה") && len([]rune(s)) > 3 {
+		s = strings.TrimPrefix(s, "ה")
+	}
+	return s
+}
+
+// NormalizeSemester maps the ways a semester is written ("א", "סמסטר ב'", "summer")
+// to the stored values "1", "2" and "קיץ". It returns "" when s is not recognized.
+func NormalizeSemester(s string) string {
+	s = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(s), "סמסטר"))
+	s = strings.Trim(s, "'׳\" ")
+	switch strings.ToLower(s) {
+	case "1", "א", "a", "fall", "winter":
+		return "1"
+	case "2", "ב", "b", "spring":
+		return "2"
+	case "3", "קיץ", "summer":
+		return "קיץ"
+	}
+	return ""
+}
+
+// DefaultTermDates proposes the teaching period of a term when a manager has not
+// entered the official dates yet.
+func DefaultTermDates(academicYear int, semester string) (time.Time, time.Time) {
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+	switch semester {
+	case "2":
+		return date(academicYear+1, time.March, 1), date(academicYear+1, time.June, 30)
+	case "קיץ":
+		return date(academicYear+1, time.July, 15), date(academicYear+1, time.September, 15)
+	}
+	return date(academicYear, time.October, 15), date(academicYear+1, time.January, 31)
+}
@@ -1,19 +0,0 @@
-package utils
-
-import "time"
-
-// Hebrew month names mapping.
-var HebrewMonths = map[time.Month]string{
-	time.January:   "ינואר",
-	time.February:  "פברואר",
-	time.March:     "מרץ",
-	time.April:     "אפריל",
-	time.May:       "מאי",
-	time.June:      "יוני",
-	time.July:      "יולי",
-	time.August:    "אוגוסט",
-	time.September: "ספטמבר",
-	time.October:   "אוקטובר",
-	time.November:  "נובמבר",
-	time.December:  "דצמבר",
-}
@@ -0,0 +1,154 @@
+// Package workload estimates the hours a course asks of its students, in
+// class and on their own, and compares them with the credit-hour norm of the
+// department that gives it.
+package workload
+
+import (
+	"Syllybea/i18n"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Norm is the credit-hour norm of a department: the hours of student work,
+// contact and self-study together, one credit point stands for, and how far
+// in percent a course may stray from them.
+type Norm struct {
+	HoursPerCredit   float64 `json:"hours_per_credit"`
+	TolerancePercent float64 `json:"tolerance_percent"`
+}
+
+// DefaultNorm applies to departments that have not set a norm of their own.
+var DefaultNorm = Norm{HoursPerCredit: 45, TolerancePercent: 20}
+
+// orDefault is the norm, or DefaultNorm when it has no hours per credit.
+func (n Norm) orDefault() Norm {
+	if n.HoursPerCredit <= 0 {
+		return DefaultNorm
+	}
+	return n
+}
+
+// Course holds what the workload of a course is estimated from.
+type Course struct {
+	Credits         float64
+	WeeklyHours     float64   // Contact hours per week
+	MeetingsPerWeek int       // Meetings the weekly hours are split over, 1 when unknown
+	StudyHours      []float64 // Self-study hours estimated per lesson, 0 where not estimated
+	AssignmentHours []float64 // Hours estimated per assignment, 0 where not estimated
+}
+
+// Estimate is the estimated workload of a course against its norm.
+type Estimate struct {
+	Lessons         int
+	Assignments     int
+	ContactHours    float64
+	StudyHours      float64
+	AssignmentHours float64
+	Total           float64
+	Norm            Norm
+	Expected        float64  // Hours the credits stand for under the norm, 0 without credits
+	Warnings        []string // In the locale the estimate was calculated in
+}
+
+// Calculate estimates the workload of a course: the contact hours of its
+// lessons, plus the self-study and assignment hours estimated for them, and
+// warns, in the given locale, when the total strays from the norm or estimates
+// are missing.
+func Calculate(c Course, n Norm, locale string) Estimate {
+	n = n.orDefault()
+	e := Estimate{
+		Lessons:     len(c.StudyHours),
+		Assignments: len(c.AssignmentHours),
+		Norm:        n,
+	}
+
+	meetings := c.MeetingsPerWeek
+	if meetings < 1 {
+		meetings = 1
+	}
+	e.ContactHours = float64(e.Lessons) * c.WeeklyHours / float64(meetings)
+
+	missingStudy, missingAssignments := 0, 0
+	for _, h := range c.StudyHours {
+		if h <= 0 {
+			missingStudy++
+		}
+		e.StudyHours += math.Max(h, 0)
+	}
+	for _, h := range c.AssignmentHours {
+		if h <= 0 {
+			missingAssignments++
+		}
+		e.AssignmentHours += math.Max(h, 0)
+	}
+	e.Total = e.ContactHours + e.StudyHours + e.AssignmentHours
+
+	if c.WeeklyHours <= 0 {
+		e.Warnings = append(e.Warnings, i18n.T(locale, "יש לבחור שעות שבועיות כדי לחשב את שעות המפגש"))
+	}
+	if e.Lessons == 0 {
+		e.Warnings = append(e.Warnings, i18n.T(locale, "אין שיעורים בטבלת נושאי הקורס"))
+	}
+	if missingStudy > 0 {
+		e.Warnings = append(e.Warnings, i18n.T(locale, "ל-%d מתוך %d השיעורים לא הוזנה הערכת שעות למידה עצמית", missingStudy, e.Lessons))
+	}
+	if missingAssignments > 0 {
+		e.Warnings = append(e.Warnings, i18n.T(locale, "ל-%d מתוך %d המטלות לא הוזנה הערכת שעות", missingAssignments, e.Assignments))
+	}
+
+	if c.Credits <= 0 {
+		e.Warnings = append(e.Warnings, i18n.T(locale, "יש לבחור נקודות זכות כדי להשוות את העומס לנורמה"))
+		return e
+	}
+	e.Expected = c.Credits * n.HoursPerCredit
+	switch min, max := e.Range(); {
+	case e.Total < min:
+		e.Warnings = append(e.Warnings, i18n.T(locale, "העומס המוערך (%s שעות) נמוך מהנורמה: %s נ״ז הן %s שעות, ולכל הפחות %s שעות",
+			Hours(e.Total), Hours(c.Credits), Hours(e.Expected), Hours(min)))
+	case e.Total > max:
+		e.Warnings = append(e.Warnings, i18n.T(locale, "העומס המוערך (%s שעות) גבוה מהנורמה: %s נ״ז הן %s שעות, ולכל היותר %s שעות",
+			Hours(e.Total), Hours(c.Credits), Hours(e.Expected), Hours(max)))
+	}
+	return e
+}
+
+// Range is the lowest and highest total the norm accepts for the course.
+func (e Estimate) Range() (min, max float64) {
+	margin := e.Expected * e.Norm.TolerancePercent / 100
+	return e.Expected - margin, e.Expected + margin
+}
+
+// Deviation is how far the total is from the expected hours, in percent of
+// them; 0 when there is nothing to compare against.
+func (e Estimate) Deviation() float64 {
+	if e.Expected <= 0 {
+		return 0
+	}
+	return (e.Total - e.Expected) / e.Expected * 100
+}
+
+// WithinNorm reports whether the total is within the tolerance of the norm.
+func (e Estimate) WithinNorm() bool {
+	min, max := e.Range()
+	return e.Expected > 0 && e.Total >= min && e.Total <= max
+}
+
+// Hours formats a number of hours to at most one decimal, e.g. "67.5".
+func Hours(h float64) string {
+	return strconv.FormatFloat(math.Round(h*10)/10, 'f', -1, 64)
+}
+
+// ParseHours reads a number of hours typed into the form; blank is 0.
+func ParseHours(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	h, err := strconv.ParseFloat(s, 64)
+	if err != nil || h < 0 || math.IsNaN(h) || math.IsInf(h, 0) {
+		return 0, fmt.Errorf("invalid number of hours %q", s)
+	}
+	return h, nil
+}